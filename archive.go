@@ -1,11 +1,13 @@
 package ax
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 const (
@@ -16,7 +18,8 @@ const (
 
 var (
 	// ErrNotDir - path is not a directory.
-	// Currently only archiving dirs has been thoroughly tested.
+	//
+	// Deprecated: files can be archived as well, so it's no longer returned.
 	ErrNotDir = errors.New("path is not a directory")
 
 	// ErrPathEmpty - path can not be empty.
@@ -25,10 +28,18 @@ var (
 
 // PathConfig - path config required for the archiving process.
 type PathConfig struct {
-	// PathToArchive - path which points to the directory which should be archived.
+	// PathToArchive - path which points to the directory (or file) which should be archived.
 	// Should be relative to the current working dir.
 	PathToArchive string
 
+	// Sources - more directories and files which should be archived, along with PathToArchive.
+	Sources []string
+
+	// Root - if set, sources are stored in the archive(s) with paths relative to it, so they all have to be within it.
+	// Otherwise, they're stored relative to the deepest directory holding all of them, i.e. a single directory source
+	// is stored under its own name.
+	Root string
+
 	// OutputPath - path where the temporary archive files should be placed.
 	OutputPath string
 
@@ -51,16 +62,17 @@ type ArchiveConfig struct {
 	// ArchiveType - default setting '-t7z'.
 	ArchiveType string
 
-	// BlockSize - default setting 'm' [BlockSizeMB].
+	// BlockSize - unit of VolumeSize, default setting 'm' [BlockSizeMB]. See SetVolumeSize.
 	BlockSize BlockSize // b,k,m,g - size representation
 
-	// VolumeSize - default setting '-v9m' - representing volumes of 9Megabytes each.
+	// VolumeSize - default setting '-v90m' - representing volumes of 90 Megabytes each.
 	VolumeSize uint64
 
-	// FastBytes - default setting '-mfb=64', where the number set represents the number of Fast bytes for LZMA.
+	// FastBytes - default setting '-mfb=64', where the number set represents the number of Fast bytes.
+	// Used only by LZMA2 (5-273) and Deflate (3-258).
 	FastBytes uint16
 
-	// DictSize - default setting '-md=64m.
+	// DictSize - default setting '-md=64m', dictionary size in Megabytes, used only by LZMA2 (up to 1536).
 	DictSize uint16
 
 	// ApplyPassword - if true, then password flag for 7zip cmd will be used.
@@ -72,18 +84,112 @@ type ArchiveConfig struct {
 	// Compression - default setting '-mx=9', where 9 represents Ultra and 0 would be none compression at all.
 	Compression uint8
 
-	// SolidArchive - default setting '-ms=on'
+	// SolidArchive - default setting '-ms=on'. Only 7z archives can be solid.
 	SolidArchive bool
+
+	// SolidBlockSize - if set, size of the solid blocks in bytes (i.e. '-ms=64m'), so that extracting a single file
+	// doesn't require decompressing everything archived before it. By default, 7zip chooses it by the compression level.
+	SolidBlockSize uint64
+
+	// Method - compression method, one of MethodLZMA2 (default for 7z), MethodPPMd, MethodBZip2 and MethodZstd.
+	// Zip archives support MethodBZip2 and MethodPPMd, on top of their default Deflate.
+	Method string
+
+	// Threads - number of threads 7zip compresses with ('-mmt'), or 0 to let 7zip use all of the CPUs.
+	Threads uint16
+
+	// Logger - if set, progress of the call is reported to it instead of the DefaultLogger.
+	Logger Logger
+
+	// Include - if set, only files matching one of these gitignore-style patterns are archived.
+	Include []string
+
+	// Exclude - gitignore-style patterns of files which aren't archived, on top of the ones in IgnoreFileName.
+	Exclude []string
+
+	// PreserveMetadata - if set, the sources are archived as a single tarball (streamed to 7zip, so it's never written
+	// to disk), which holds their ownership, exact modes, modification times, symlinks and extended attributes.
+	// Those are restored by Extract with ExtractConfig.PreserveMetadata.
+	PreserveMetadata bool
 }
 
-// Archive - used to create archive zip volume(s) from a chosen directory.
+// Archive - used to create archive zip volume(s) from the chosen directories and files.
 func Archive(conf *ArchiveConfig) error {
+	return ArchiveWithContext(context.Background(), conf)
+}
+
+// ArchiveWithContext - same as Archive, but 7zip is killed once the context is done, in which case the returned error
+// wraps the error of the context.
+//
+// If archiving fails (or it's canceled), archive files which it has created are removed, so that partially written
+// volume(s) are never mistaken for a complete archive. Files which have existed before are left in place.
+func ArchiveWithContext(ctx context.Context, conf *ArchiveConfig) error {
+	started := time.Now()
+
 	err := validatePathToArchive(conf)
 	if err != nil {
 		return fmt.Errorf("path validation issue: %w", err)
 	}
 
-	err = executeCommand(cmd7z, cmdArgsArchive(conf))
+	err = conf.Validate()
+	if err != nil {
+		return err
+	}
+
+	log := loggerOr(conf.Logger)
+
+	root, entries, err := listArchiveEntries(conf)
+	if err != nil {
+		return err
+	}
+
+	out, err := archiveOutput(conf)
+	if err != nil {
+		return err
+	}
+
+	existing, err := existingArchives(out)
+	if err != nil {
+		return err
+	}
+
+	if conf.PreserveMetadata {
+		err = archiveWithMetadata(ctx, log, conf, root, entries)
+	} else {
+		err = archiveWithListFile(ctx, log, conf, root, entries)
+	}
+
+	if err != nil {
+		removeNewArchives(log, out, existing)
+
+		return err
+	}
+
+	// Sizes are only reported, so they're left out rather than failing the archiving.
+	bytesIn, _ := entriesSize(root, entries)
+	created, _ := newArchives(out, existing)
+
+	log.Info(EventArchived, "sources", conf.sources(), "output", conf.OutputPath, AttrDuration, time.Since(started),
+		AttrBytesIn, bytesIn, AttrBytesOut, filesSize(created))
+
+	return nil
+}
+
+// archiveWithListFile - archives the entries, relative to root, as they are.
+func archiveWithListFile(ctx context.Context, log Logger, conf *ArchiveConfig, root string, entries []string) error {
+	listFile, err := writeListFile(entries)
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(listFile)
+
+	args, err := cmdArgsArchive(conf, "@"+listFile)
+	if err != nil {
+		return err
+	}
+
+	err = executeCommandIn(ctx, log, root, sevenZipCmd(), args)
 	if err != nil {
 		return fmt.Errorf("failed executing 7zip: %w", err)
 	}
@@ -91,24 +197,110 @@ func Archive(conf *ArchiveConfig) error {
 	return nil
 }
 
-func validatePathToArchive(conf *ArchiveConfig) error {
-	if conf.PathToArchive == "" {
-		return ErrPathEmpty
+// removeNewArchives - removes the archive files at the output, but the ones which have existed before archiving.
+func removeNewArchives(log Logger, out string, existing []string) {
+	created, kept := newArchives(out, existing)
+
+	for _, p := range kept {
+		log.Warn("archive has existed before, it may have been left incomplete", "archive", p)
+	}
+
+	for _, p := range created {
+		_ = os.Remove(p)
 	}
+}
 
-	stat, err := os.Stat(conf.PathToArchive)
+// newArchives - returns the archive files at the output which have been created by archiving, and the ones which have
+// existed before it.
+func newArchives(out string, existing []string) (created, kept []string) {
+	all, err := existingArchives(out)
 	if err != nil {
-		return fmt.Errorf("failed getting path stat: %w", err)
+		return nil, nil
+	}
+
+	existed := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		existed[p] = true
+	}
+
+	created = make([]string, 0, len(all))
+
+	for _, p := range all {
+		if existed[p] {
+			kept = append(kept, p)
+
+			continue
+		}
+
+		created = append(created, p)
 	}
 
-	if !stat.IsDir() {
-		return ErrNotDir
+	return created, kept
+}
+
+// ListArchiveFiles - returns paths of the files Archive would archive, as they are stored within the archive(s).
+// Include/Exclude patterns and IgnoreFileName are taken into account.
+func ListArchiveFiles(conf *ArchiveConfig) ([]string, error) {
+	err := validatePathToArchive(conf)
+	if err != nil {
+		return nil, fmt.Errorf("path validation issue: %w", err)
+	}
+
+	_, entries, err := listArchiveEntries(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// writeListFile - writes entries into a temporary 7zip list file, and returns its path.
+//
+// Passing the entries through a list file, while 7zip is executed from the root, makes the archive(s) hold exactly the
+// listed paths, regardless of how many sources there are.
+func writeListFile(entries []string) (string, error) {
+	listFile, err := os.CreateTemp("", "ax-list-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed creating list file: %w", err)
+	}
+
+	_, err = listFile.WriteString(strings.Join(entries, "\n") + "\n")
+	if closeErr := listFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(listFile.Name())
+
+		return "", fmt.Errorf("failed writing list file: %w", err)
+	}
+
+	return listFile.Name(), nil
+}
+
+// validatePathToArchive - validates that there is at least one source, and that all of them exist.
+// Sources can be both directories and files.
+func validatePathToArchive(conf *ArchiveConfig) error {
+	sources := conf.sources()
+	if len(sources) == 0 {
+		return ErrPathEmpty
+	}
+
+	for _, src := range sources {
+		if src == "" {
+			return ErrPathEmpty
+		}
+
+		_, err := os.Stat(src)
+		if err != nil {
+			return fmt.Errorf("failed getting path stat: %w", err)
+		}
 	}
 
 	return nil
 }
 
-// BlockSize - represents size of the volume blocks in b|k|m|g.
+// BlockSize - represents the unit of the volume size in b|k|m|g.
 type BlockSize string
 
 func (v *BlockSize) String() string {
@@ -179,39 +371,26 @@ func NewDefaultArchiveConfig() ArchiveConfig {
 	}
 }
 
-// cmdArgsArchive - used to build command arguments for Archive Compression process.
-// Returned string will be transformed into arguments slice which is later used for Output() func on [exec.Command].
-func cmdArgsArchive(ac *ArchiveConfig) string {
-	var (
-		exportArchive struct{ name, typ string }
-		cmdStr        = "a"
-	)
+// cmdArgsArchive - used to build command arguments for Archive Compression process, archiving the input, which is
+// either a list file ('@list.txt') or stdin ('-siname'). Output path is resolved to an absolute one, as 7zip is
+// executed from the root of the sources.
+func cmdArgsArchive(ac *ArchiveConfig, input string) ([]string, error) {
+	args := []string{"a"}
 
 	if ac.HeadersEncryption {
-		appendToString(&cmdStr, "-mhe=on")
+		args = append(args, "-mhe=on")
 	}
 
 	if ac.Password != nil && ac.ApplyPassword {
-		appendToString(&cmdStr, fmt.Sprintf("-p%s", ac.Password))
+		args = append(args, fmt.Sprintf("-p%s", ac.Password))
 	}
 
 	if ac.ArchiveType != "" {
-		exportArchive.typ = ac.ArchiveType
-		appendToString(&cmdStr, fmt.Sprintf("-t%s", ac.ArchiveType))
-	} else {
-		exportArchive.typ = "7z"
-	}
-
-	if ac.Compression != 0 {
-		appendToString(&cmdStr, fmt.Sprintf("-mx=%d", ac.Compression))
-	}
-
-	if ac.FastBytes != 0 {
-		appendToString(&cmdStr, fmt.Sprintf("-mfb=%d", ac.FastBytes))
+		args = append(args, fmt.Sprintf("-t%s", ac.ArchiveType))
 	}
 
-	if ac.DictSize != 0 {
-		appendToString(&cmdStr, fmt.Sprintf("-md=%dm", ac.DictSize))
+	if ac.archiveType() != archiveTypeTar {
+		args = append(args, cmdArgsCompression(ac)...)
 	}
 
 	if ac.VolumeSize != 0 {
@@ -219,37 +398,90 @@ func cmdArgsArchive(ac *ArchiveConfig) string {
 			ac.BlockSize = BlockSizeMB
 		}
 
-		appendToString(&cmdStr, fmt.Sprintf("-v%d%s", ac.VolumeSize, ac.BlockSize))
-	}
-
-	if ac.SolidArchive {
-		appendToString(&cmdStr, "-ms=on")
+		args = append(args, fmt.Sprintf("-v%d%s", ac.VolumeSize, ac.BlockSize))
 	}
 
-	if ac.NewArchiveName != "" {
-		exportArchive.name = ac.NewArchiveName
-	} else {
-		exportArchive.name = "archive"
+	if ac.SolidArchive && ac.archiveType() == archiveType7z {
+		if ac.SolidBlockSize != 0 {
+			args = append(args, "-ms="+formatSize(ac.SolidBlockSize))
+		} else {
+			args = append(args, "-ms=on")
+		}
 	}
 
 	if ac.OutputPath == "" {
 		ac.OutputPath = defaultArchiveOutput
 	}
 
-	if exportArchive.name != "" && exportArchive.typ != "" {
-		appendToString(
-			&cmdStr,
-			fmt.Sprintf("%s%c%s.%s", ac.OutputPath, os.PathSeparator, exportArchive.name, exportArchive.typ),
-		)
+	outArchive, err := archiveOutput(ac)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(args, "-scsUTF-8", outArchive, input), nil
+}
+
+// archiveOutput - returns absolute path of the archive, without the volume suffix.
+func archiveOutput(ac *ArchiveConfig) (string, error) {
+	out := ac.OutputPath
+	if out == "" {
+		out = defaultArchiveOutput
+	}
+
+	outArchive, err := filepath.Abs(filepath.Join(out, archiveFileName(ac)))
+	if err != nil {
+		return "", fmt.Errorf("failed resolving output path: %w", err)
+	}
+
+	return outArchive, nil
+}
+
+// cmdArgsCompression - used to build command arguments for the compression method and its settings. Settings which
+// aren't used by the method are left out, as 7zip rejects some of them.
+func cmdArgsCompression(ac *ArchiveConfig) []string {
+	args := make([]string, 0)
+	method := ac.method()
+
+	if ac.Method != "" {
+		if ac.archiveType() == archiveTypeZip {
+			args = append(args, "-mm="+method)
+		} else {
+			args = append(args, "-m0="+method)
+		}
+	}
+
+	if ac.Compression != 0 {
+		args = append(args, fmt.Sprintf("-mx=%d", ac.Compression))
+	}
+
+	if ac.FastBytes != 0 && (method == MethodLZMA2 || method == methodDeflate) {
+		args = append(args, fmt.Sprintf("-mfb=%d", ac.FastBytes))
+	}
+
+	if ac.DictSize != 0 && method == MethodLZMA2 {
+		args = append(args, fmt.Sprintf("-md=%dm", ac.DictSize))
 	}
 
-	appendToString(&cmdStr, ac.PathToArchive)
+	if ac.Threads != 0 {
+		args = append(args, fmt.Sprintf("-mmt=%d", ac.Threads))
+	}
 
-	return cmdStr
+	return args
 }
 
-func appendToString(command *string, flag string) {
-	*command = fmt.Sprintf("%s %s", *command, flag)
+// archiveFileName - returns the name of the output archive, i.e. 'archive.7z'.
+func archiveFileName(ac *ArchiveConfig) string {
+	name, typ := "archive", archiveType
+
+	if ac.NewArchiveName != "" {
+		name = ac.NewArchiveName
+	}
+
+	if ac.ArchiveType != "" {
+		typ = ac.ArchiveType
+	}
+
+	return fmt.Sprintf("%s.%s", name, typ)
 }
 
 // ListFiles - used to list files, without directories in a chosen path.
@@ -44,7 +44,7 @@ func (s *Suite) TestUnitValidatePath() {
 			},
 		},
 		{
-			Name: "err file is not of dir type",
+			Name: "successful validation of file",
 			PreRequisites: func() {
 				s.ac = &ArchiveConfig{
 					PathConfig: PathConfig{
@@ -55,8 +55,23 @@ func (s *Suite) TestUnitValidatePath() {
 			Assert: func() {
 				err := validatePathToArchive(s.ac)
 
+				assert.Nil(s.T(), err)
+			},
+		},
+		{
+			Name: "err one of the sources does not exist",
+			PreRequisites: func() {
+				s.ac = &ArchiveConfig{
+					PathConfig: PathConfig{
+						PathToArchive: fmt.Sprintf(".%ccmd", os.PathSeparator),
+						Sources:       []string{nameOfArchiveGoSrcFile, invalidPath},
+					},
+				}
+			},
+			Assert: func() {
+				err := validatePathToArchive(s.ac)
+
 				assert.NotNil(s.T(), err)
-				assert.EqualValues(s.T(), ErrNotDir, err)
 			},
 		},
 		{
@@ -0,0 +1,138 @@
+package ax
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// partSuffix - suffix of the files which are still being written. They're renamed to their final name only once
+	// they're complete, so an interrupted run never leaves a truncated file under the name of a complete one.
+	partSuffix = ".part"
+
+	// IncompleteMarker - file left in the output directory by ExtractWithContext, if the extraction has been canceled,
+	// as the directory may hold partially extracted files then. It's removed once an extraction into it completes.
+	IncompleteMarker        = ".ax-incomplete"
+	incompleteMarkerContent = "Extraction of %s into this directory has been interrupted, so it may hold partially " +
+		"extracted files. This file is removed by ax once an extraction into the directory completes.\n"
+)
+
+// contextReader - reader failing with the error of the context, once it's done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	err := r.ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
+
+// writePartFile - creates the part file next to the path (i.e. 'name.7z.001.1234.part', 1234 being the PID), passes
+// it to write, and renames it to the path once it's written and synced. The part file is removed if anything fails.
+//
+// The part file is created exclusively, so an existing file (or a symlink) is never written through.
+func writePartFile(path string, perm os.FileMode, write func(w io.Writer) error) error {
+	part := fmt.Sprintf("%s.%d%s", path, os.Getpid(), partSuffix)
+
+	f, err := os.OpenFile(part, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return fmt.Errorf("failed creating %s: %w", part, err)
+	}
+
+	err = write(f)
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(part, path)
+	}
+
+	if err != nil {
+		_ = os.Remove(part)
+
+		return fmt.Errorf("failed writing %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// isPartFile - reports whether the path is a part file, left behind by a run which has been killed while writing it.
+func isPartFile(path string) bool {
+	return strings.HasSuffix(path, partSuffix)
+}
+
+// markIncomplete - marks the output directory by IncompleteMarker, creating the directory if it's missing. The returned
+// func is called once the extraction ends: the marker is left in place if it has been canceled, otherwise it's removed,
+// along with the directory if it has been created for an extraction which has failed.
+func markIncomplete(log Logger, dir, archive string) (func(err error, canceled bool), error) {
+	marker := filepath.Join(dir, IncompleteMarker)
+
+	_, statErr := os.Stat(dir)
+	created := os.IsNotExist(statErr)
+
+	if _, err := os.Lstat(marker); err == nil {
+		log.Warn("previous extraction into the directory has been interrupted, it may hold partially extracted files",
+			"dir", dir)
+
+		// Removed first, so that it's never written through, if it has been replaced by a symlink.
+		_ = os.Remove(marker)
+	}
+
+	err := mkdirAll(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeMarker(marker, fmt.Sprintf(incompleteMarkerContent, archive))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(err error, canceled bool) {
+		if err != nil && canceled {
+			log.Warn("extraction has been interrupted, the directory is marked as incomplete", "marker", marker)
+
+			return
+		}
+
+		_ = os.Remove(marker)
+
+		if err != nil && created {
+			// Removed only if it's empty, as nothing has been extracted into it then.
+			_ = os.Remove(dir)
+		}
+	}, nil
+}
+
+// writeMarker - writes the marker file, which mustn't exist.
+func writeMarker(marker, content string) error {
+	f, err := os.OpenFile(marker, os.O_WRONLY|os.O_CREATE|os.O_EXCL, encFilePerm)
+	if err != nil {
+		return fmt.Errorf("failed creating %s: %w", marker, err)
+	}
+
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed writing %s: %w", marker, err)
+	}
+
+	return nil
+}
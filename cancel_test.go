@@ -0,0 +1,191 @@
+package ax
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitCancel() {
+	var (
+		dir      string
+		passwd   = []byte("cancel-secret")
+		canceled = func() context.Context {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			return ctx
+		}
+	)
+
+	prepare := func() {
+		dir = s.T().TempDir()
+
+		s.Require().Nil(os.MkdirAll(filepath.Join(dir, "src"), 0o700))
+		s.Require().Nil(os.WriteFile(filepath.Join(dir, "src", "a.txt"), []byte("content"), 0o600))
+	}
+
+	streamed := func() string {
+		ac := NewDefaultArchiveConfig()
+		ac.PathToArchive = filepath.Join(dir, "src")
+		ac.OutputPath = filepath.Join(dir, "out")
+		ac.NewArchiveName = "backup"
+		ac.SetVolumeSize(0)
+
+		volumes, err := StreamArchive(&ac, passwd)
+		s.Require().Nil(err)
+
+		return volumes[0]
+	}
+
+	testCases := []TestCase{
+		{
+			Name:          "success part file is renamed once complete, and removed on failure",
+			PreRequisites: prepare,
+			Assert: func() {
+				p := filepath.Join(dir, "file")
+
+				s.Require().Nil(writePartFile(p, 0o600, func(w io.Writer) error {
+					_, err := w.Write([]byte("done"))
+
+					return err
+				}))
+
+				content, err := os.ReadFile(p)
+				s.Require().Nil(err)
+				assert.Equal(s.T(), "done", string(content))
+
+				failure := errors.New("failure")
+				err = writePartFile(filepath.Join(dir, "other"), 0o600, func(w io.Writer) error { return failure })
+				assert.ErrorIs(s.T(), err, failure)
+
+				entries, err := os.ReadDir(dir)
+				s.Require().Nil(err)
+				assert.Len(s.T(), entries, 2) // src & file.
+			},
+		},
+		{
+			Name:          "err command is killed once the context is done",
+			PreRequisites: prepare,
+			Assert: func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+
+				started := time.Now()
+				err := executeCommandIn(ctx, nopLogger{}, "", "sleep", []string{"10"})
+
+				assert.ErrorIs(s.T(), err, context.DeadlineExceeded)
+				assert.Less(s.T(), int64(time.Since(started)), int64(5*time.Second))
+			},
+		},
+		{
+			Name:          "err canceled encryption leaves files as they were, and resumes",
+			PreRequisites: prepare,
+			Assert: func() {
+				p := filepath.Join(dir, "src", "a.txt")
+
+				err := DefaultFileEncryptionWithContext(canceled(), passwd, []string{p})
+				assert.ErrorIs(s.T(), err, context.Canceled)
+				assert.FileExists(s.T(), p)
+
+				s.Require().Nil(DefaultFileEncryption(passwd, []string{p}))
+
+				// Already encrypted file is skipped, rather than encrypted twice.
+				encrypted := p + encryptedFileMarker + "0"
+				s.Require().Nil(DefaultFileEncryption(passwd, []string{encrypted}))
+				assert.FileExists(s.T(), encrypted)
+				assert.NoFileExists(s.T(), encrypted+encryptedFileMarker+"0")
+
+				s.Require().Nil(DefaultFileDecryption(passwd, []string{encrypted}))
+
+				content, err := os.ReadFile(p)
+				s.Require().Nil(err)
+				assert.Equal(s.T(), "content", string(content))
+			},
+		},
+		{
+			Name:          "err canceled streaming leaves no volumes",
+			PreRequisites: prepare,
+			Assert: func() {
+				ac := NewDefaultArchiveConfig()
+				ac.PathToArchive = filepath.Join(dir, "src")
+				ac.OutputPath = filepath.Join(dir, "out")
+
+				_, err := StreamArchiveWithContext(canceled(), &ac, passwd)
+				assert.ErrorIs(s.T(), err, context.Canceled)
+
+				volumes, err := filepath.Glob(filepath.Join(dir, "out", "*"))
+				s.Require().Nil(err)
+				assert.Empty(s.T(), volumes)
+			},
+		},
+		{
+			Name:          "err canceled extraction marks the output dir as incomplete",
+			PreRequisites: prepare,
+			Assert: func() {
+				ec := &ExtractConfig{
+					ArchivePath:        streamed(),
+					OutputDir:          filepath.Join(dir, "restored"),
+					EncryptionPassword: passwd,
+				}
+
+				err := ExtractWithContext(canceled(), ec)
+				assert.ErrorIs(s.T(), err, context.Canceled)
+				assert.FileExists(s.T(), filepath.Join(ec.OutputDir, IncompleteMarker))
+
+				s.Require().Nil(ExtractWithContext(context.Background(), ec))
+				assert.NoFileExists(s.T(), filepath.Join(ec.OutputDir, IncompleteMarker))
+				assert.FileExists(s.T(), filepath.Join(ec.OutputDir, "src", "a.txt"))
+			},
+		},
+		{
+			Name:          "err failed extraction leaves no trace",
+			PreRequisites: prepare,
+			Assert: func() {
+				ec := &ExtractConfig{
+					ArchivePath:        streamed(),
+					OutputDir:          filepath.Join(dir, "restored"),
+					EncryptionPassword: []byte("wrong"),
+				}
+
+				err := ExtractWithContext(context.Background(), ec)
+				assert.ErrorIs(s.T(), err, ErrWrongPassword)
+				assert.NoDirExists(s.T(), ec.OutputDir)
+			},
+		},
+		{
+			Name:          "err canceled push removes the repository it has initialized",
+			PreRequisites: prepare,
+			Assert: func() {
+				cwd, err := os.Getwd()
+				s.Require().Nil(err)
+				s.Require().Nil(os.Chdir(dir))
+
+				defer func() { s.Require().Nil(os.Chdir(cwd)) }()
+
+				ctx, cancel := context.WithCancel(context.Background())
+				chain := buildDefaultForcePushGitChain(ctx)
+				gitInit := chain.gitInit
+
+				// Canceled right after the repository has been initialized.
+				chain.gitInit = func() error {
+					err := gitInit()
+					cancel()
+
+					return err
+				}
+
+				err = PushToGITWithContext(ctx, filepath.Join(dir, "remote.git"), chain)
+				assert.ErrorIs(s.T(), err, context.Canceled)
+				assert.NoDirExists(s.T(), filepath.Join(dir, gitDir))
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
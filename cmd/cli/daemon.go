@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/cli/config"
+	"github.com/kaynetik/ax/pkg/cli/flags"
+	"github.com/kaynetik/ax/pkg/cli/output"
+	"github.com/kaynetik/ax/pkg/daemon"
+	"github.com/kaynetik/ax/pkg/metrics"
+)
+
+// historyFileName - file recording the pushes of the daemon, next to the config file unless chosen otherwise.
+const historyFileName = "history.json"
+
+// runDaemon - pushes every profile of the config which has a schedule, until ax is interrupted. Passwords are never
+// prompted for, so each profile has to choose their non-interactive sources.
+func runDaemon(ctx context.Context, cs *flags.CmdScan) error {
+	path, conf, err := loadDaemonConfig(cs.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	settings, err := daemonSettings(cs, conf, path)
+	if err != nil {
+		return output.Usage(err)
+	}
+
+	reg := metrics.NewRegistry()
+
+	jobs, err := scheduledJobs(path, conf, reg)
+	if err != nil {
+		return err
+	}
+
+	history, err := daemon.OpenHistory(settings.History, settings.HistorySize)
+	if err != nil {
+		return fmt.Errorf("failed opening daemon history: %w", err)
+	}
+
+	d, err := daemon.New(daemon.Config{
+		Jobs:        jobs,
+		Concurrency: int(settings.Concurrency),
+		Jitter:      cs.Jitter,
+		History:     history,
+	})
+	if err != nil {
+		return output.Usage(fmt.Errorf("failed starting daemon: %w", err))
+	}
+
+	ln, err := daemon.Listen(ctx, settings.Socket)
+	if err != nil {
+		return fmt.Errorf("failed starting daemon: %w", err)
+	}
+
+	go func() {
+		serveErr := d.Serve(ctx, ln)
+		if serveErr != nil {
+			ax.DefaultLogger().Warn("daemon stopped reporting its status", "err", serveErr)
+		}
+	}()
+
+	ax.DefaultLogger().Info("Reporting daemon status", "socket", settings.Socket)
+
+	if settings.MetricsAddr != "" {
+		err = serveMetrics(ctx, settings.MetricsAddr, reg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.Run(ctx)
+}
+
+// runStatus - returns the status of the running daemon.
+func runStatus(ctx context.Context, cs *flags.CmdScan) (interface{}, error) {
+	socket := cs.Socket
+	if socket == "" {
+		socket = daemon.DefaultSocketPath()
+	}
+
+	status, err := daemon.QueryStatus(ctx, socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying daemon status: %w", err)
+	}
+
+	return status, nil
+}
+
+// loadDaemonConfig - loads the config file holding the scheduled profiles, returning its path along with it.
+func loadDaemonConfig(path string) (string, *config.Config, error) {
+	if path == "" {
+		defaultPath, err := config.DefaultPath()
+		if err != nil {
+			return "", nil, fmt.Errorf("no config file provided: %w", err)
+		}
+
+		path = defaultPath
+	}
+
+	conf, err := config.Load(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed loading daemon config: %w", err)
+	}
+
+	return path, conf, nil
+}
+
+// daemonSettings - returns the settings of the daemon section of the config, overridden by the flags which have been
+// set, with defaults for the rest. The jitter is parsed into cs.Jitter.
+func daemonSettings(cs *flags.CmdScan, conf *config.Config, configPath string) (config.DaemonSettings, error) {
+	settings := conf.Daemon
+
+	if cs.Socket != "" {
+		settings.Socket = cs.Socket
+	}
+
+	if settings.Socket == "" {
+		settings.Socket = daemon.DefaultSocketPath()
+	}
+
+	if cs.Concurrency != 0 {
+		settings.Concurrency = cs.Concurrency
+	}
+
+	if cs.MetricsAddr != "" {
+		settings.MetricsAddr = cs.MetricsAddr
+	}
+
+	if cs.HistoryPath != "" {
+		settings.History = cs.HistoryPath
+	}
+
+	if settings.History == "" {
+		settings.History = filepath.Join(filepath.Dir(configPath), historyFileName)
+	}
+
+	if cs.Jitter == 0 && settings.Jitter != "" {
+		jitter, err := time.ParseDuration(settings.Jitter)
+		if err != nil {
+			return settings, fmt.Errorf("invalid daemon jitter: %w", err)
+		}
+
+		cs.Jitter = jitter
+	}
+
+	return settings, nil
+}
+
+// scheduledJobs - returns a job pushing each profile which has a schedule. Each profile is parsed once up front, so
+// that missing flags or password sources are reported at start, rather than once its push is due. Metrics of the
+// pushes are recorded into the registry.
+func scheduledJobs(configPath string, conf *config.Config, reg *metrics.Registry) ([]daemon.Job, error) {
+	names := make([]string, 0, len(conf.Profiles))
+
+	for name, p := range conf.Profiles {
+		if p.Schedule != "" {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	jobs := make([]daemon.Job, 0, len(names))
+
+	for _, name := range names {
+		sched, err := daemon.ParseSchedule(conf.Profiles[name].Schedule)
+		if err != nil {
+			return nil, output.Usage(fmt.Errorf("profile %q: %w", name, err))
+		}
+
+		push := scheduledPush{configPath: configPath, profile: name, metrics: reg}
+
+		_, err = push.parse()
+		if err != nil {
+			return nil, output.Usage(fmt.Errorf("profile %q: %w", name, err))
+		}
+
+		jobs = append(jobs, daemon.Job{Name: name, Schedule: sched, Run: push.run})
+	}
+
+	if len(jobs) == 0 {
+		return nil, output.Usage(fmt.Errorf("%w: none of the profiles in %s has a schedule", daemon.ErrNoJobs, configPath))
+	}
+
+	return jobs, nil
+}
+
+// scheduledPush - push of a profile, started by the daemon as if `ax push -non-interactive -profile <name>` had run.
+type scheduledPush struct {
+	configPath, profile string
+
+	// metrics - registry the metrics of the push are recorded into, served by the daemon.
+	metrics *metrics.Registry
+}
+
+// parse - parses the flags of the push, reading its passwords from their non-interactive sources.
+func (p scheduledPush) parse() (*flags.CmdScan, error) {
+	cmd, err := flags.ParseCommand([]string{
+		"push", "-non-interactive", "-config", p.configPath, "-profile", p.profile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid push: %w", err)
+	}
+
+	return &cmd.Scan, nil
+}
+
+// run - pushes the profile, reading the config (and passwords) anew, so that changes apply to the next push. Its
+// outcome is reported to the hooks of the profile, and its metrics are recorded.
+func (p scheduledPush) run(ctx context.Context) error {
+	cs, err := p.parse()
+	if err != nil {
+		return err
+	}
+
+	_, err = pushAndReport(ctx, cs, cmdNameDaemon, p.metrics)
+
+	return err
+}
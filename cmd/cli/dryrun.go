@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/cli/flags"
+)
+
+// dryRunReport - what archive or push would do, as reported by -dry-run, which touches neither the disk nor the remote.
+type dryRunReport struct {
+	// WorkDir - directory push places the Archive(s) into.
+	WorkDir *workDirPlan `json:"work_dir,omitempty"`
+
+	Archive *ax.ArchivePlan `json:"archive"`
+
+	// Stream - if the Archive is streamed through the encryption, instead of being archived by 7zip.
+	Stream bool `json:"stream,omitempty"`
+
+	// Verify & Encrypt - if integrity of the archive(s) is tested, and if they're encrypted, before pushing them.
+	Verify  bool `json:"verify,omitempty"`
+	Encrypt bool `json:"encrypt,omitempty"`
+
+	// Git - commands executed within the output directory, to push the encrypted volume(s).
+	Git []string `json:"git,omitempty"`
+
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// workDirPlan - represents the work directory push would use, along with the number and total size of files left in it
+// by a previous run, which would be deleted first.
+type workDirPlan struct {
+	Path string `json:"path"`
+
+	// Created - if it's the work directory of the job under the system temp dir, which is removed once done.
+	Created bool `json:"created"`
+
+	Files int    `json:"files"`
+	Size  uint64 `json:"size"`
+
+	// Resumable - if it holds encrypted volume(s) of an interrupted push with the same settings, which would be pushed
+	// instead of archiving again.
+	Resumable bool `json:"resumable,omitempty"`
+}
+
+// planArchive - reports what archive would do.
+func planArchive(cs *flags.CmdScan) (interface{}, error) {
+	plan, err := ax.PlanArchive(prepareConfigForArchiving(cs))
+	if err != nil {
+		return nil, fmt.Errorf("an issue occurred while planning the archive: %w", err)
+	}
+
+	report := &dryRunReport{Archive: plan}
+
+	for _, existing := range plan.Existing {
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("%s already exists, 7zip would update it instead of replacing it", existing))
+	}
+
+	return report, nil
+}
+
+// planPush - reports what push would do, step by step.
+func planPush(cs *flags.CmdScan) (interface{}, error) {
+	conf := prepareConfigForArchiving(cs)
+	key := ax.NewJobKey(conf, cs.Stream)
+
+	wd, err := planWorkDir(cs.ArchiveOutPath, key)
+	if err != nil {
+		return nil, err
+	}
+
+	conf.OutputPath = wd.Path
+
+	planFn := ax.PlanArchive
+	if cs.Stream {
+		planFn = ax.PlanStream
+	}
+
+	plan, err := planFn(conf)
+	if err != nil {
+		return nil, fmt.Errorf("an issue occurred while planning the archive: %w", err)
+	}
+
+	// Archive(s) already in the work directory are deleted first, so they'd never be updated by 7zip.
+	plan.Existing = nil
+
+	job, err := ax.LoadJob(&ax.WorkDir{Path: wd.Path})
+	wd.Resumable = err == nil && job.Key == key && job.Resumable()
+
+	report := &dryRunReport{
+		WorkDir: wd,
+		Archive: plan,
+		Stream:  cs.Stream,
+		Verify:  cs.Verify,
+		Encrypt: !cs.Stream,
+		Git:     ax.JobPushCommands(cs.GitRepo),
+	}
+
+	if !wd.Created && overlapsSources(wd.Path, cs.Sources) {
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("%s overlaps the sources, whatever of them it holds would be deleted before archiving", wd.Path))
+	}
+
+	report.Warnings = append(report.Warnings,
+		"git add, commit and push are repeated for each volume, and only the first push is forced, so history of "+
+			"master at the remote would be overwritten")
+
+	if wd.Resumable {
+		report.Warnings = append(report.Warnings,
+			"work directory holds encrypted volume(s) of an interrupted push with the same settings, which would be "+
+				"pushed instead of archiving again, once their hashes are checked")
+	}
+
+	return report, nil
+}
+
+// planWorkDir - returns the work directory push would use for the job with the key, failing just as push would if it
+// isn't its own.
+func planWorkDir(dir, key string) (*workDirPlan, error) {
+	created := dir == ""
+	if created {
+		dir = ax.JobWorkDirPath("", key)
+	}
+
+	err := ax.CheckWorkDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening work directory: %w", err)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving path: %w", err)
+	}
+
+	wd := &workDirPlan{Path: abs, Created: created}
+
+	err = filepath.Walk(abs, func(p string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && p == abs {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() && !strings.HasPrefix(info.Name(), ax.WorkDirMarker) {
+			wd.Files++
+			wd.Size += uint64(info.Size())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed walking %s: %w", abs, err)
+	}
+
+	return wd, nil
+}
+
+// overlapsSources - reports whether any of the sources is within the directory, or the other way around.
+func overlapsSources(dir string, sources []string) bool {
+	for _, src := range sources {
+		abs, err := filepath.Abs(src)
+		if err == nil && (isWithin(dir, abs) || isWithin(abs, dir)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isWithin - reports whether the path is the root itself, or anything below it.
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// WriteText - writes the steps which would be taken, in order, followed by the warnings.
+func (r *dryRunReport) WriteText(w io.Writer) error {
+	var (
+		p     = r.Archive
+		steps = make([]string, 0)
+		add   = func(format string, args ...interface{}) { steps = append(steps, fmt.Sprintf(format, args...)) }
+	)
+
+	switch wd := r.WorkDir; {
+	case wd == nil:
+	case wd.Files != 0:
+		add("delete %d file(s) of %s, left in the work directory %s", wd.Files, ax.HumanSize(wd.Size), wd.Path)
+	case wd.Created:
+		add("create a work directory %s", wd.Path)
+	}
+
+	if r.Stream {
+		add("stream %d file(s) of %s (before compression) from %s as an encrypted tar.gz, never written unencrypted",
+			len(p.Files), ax.HumanSize(p.Size), p.Root)
+	} else {
+		add("archive %d file(s) of %s (before compression) from %s", len(p.Files), ax.HumanSize(p.Size), p.Root)
+		add("execute in %s: %s", p.Root, strings.Join(p.Command, " "))
+	}
+
+	if p.VolumeSize != 0 {
+		add("write up to %d volume(s) of %s, starting with %s", p.Volumes, ax.HumanSize(p.VolumeSize), p.Archive)
+	} else {
+		add("write %s", p.Archive)
+	}
+
+	if r.Verify {
+		add("test integrity of %s", p.Archive)
+	}
+
+	if r.Encrypt {
+		add("encrypt each volume into <volume>.enc.<n>, deleting the volume")
+	}
+
+	for _, cmd := range r.Git {
+		add("execute in %s: %s", filepath.Dir(p.Archive), cmd)
+	}
+
+	if r.WorkDir != nil && r.WorkDir.Created {
+		add("remove the work directory, wiping anything left in it")
+	}
+
+	_, err := fmt.Fprintln(w, "Dry run, nothing has been changed. Steps which would be taken:")
+	if err != nil {
+		return fmt.Errorf("failed writing dry run: %w", err)
+	}
+
+	for i, step := range steps {
+		_, err = fmt.Fprintf(w, "%3d. %s\n", i+1, step)
+		if err != nil {
+			return fmt.Errorf("failed writing dry run: %w", err)
+		}
+	}
+
+	_, err = fmt.Fprintln(w, "\nFiles:")
+	if err != nil {
+		return fmt.Errorf("failed writing dry run: %w", err)
+	}
+
+	for _, f := range p.Files {
+		_, err = fmt.Fprintln(w, "  "+f)
+		if err != nil {
+			return fmt.Errorf("failed writing dry run: %w", err)
+		}
+	}
+
+	if len(r.Warnings) != 0 {
+		_, err = fmt.Fprintln(w)
+		if err != nil {
+			return fmt.Errorf("failed writing dry run: %w", err)
+		}
+	}
+
+	for _, warning := range r.Warnings {
+		_, err = fmt.Fprintln(w, "Warning: "+warning)
+		if err != nil {
+			return fmt.Errorf("failed writing dry run: %w", err)
+		}
+	}
+
+	return nil
+}
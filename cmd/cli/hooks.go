@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/cli/flags"
+	"github.com/kaynetik/ax/pkg/cli/output"
+	"github.com/kaynetik/ax/pkg/hooks"
+	"github.com/kaynetik/ax/pkg/metrics"
+)
+
+// warnNoChanges - warning of a push of sources which haven't changed since the previous one.
+const warnNoChanges = "no changes since the last push"
+
+// pushReport - Result.Data of a push which has succeeded with warnings.
+type pushReport struct {
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// hookPayload - JSON the hooks receive: the result of the push, as printed with -output json, along with its outcome.
+type hookPayload struct {
+	output.Result
+
+	Outcome    hooks.Outcome `json:"outcome"`
+	Profile    string        `json:"profile,omitempty"`
+	Sources    []string      `json:"sources"`
+	FinishedAt time.Time     `json:"finished_at"`
+}
+
+// runPush - pushes the sources, reporting the outcome to the hooks.
+func runPush(ctx context.Context, cs *flags.CmdScan) (interface{}, error) {
+	report, err := pushAndReport(ctx, cs, cmdNamePush, nil)
+	if report == nil {
+		return nil, err
+	}
+
+	return report, err
+}
+
+// pushAndReport - pushes the sources, warning if they haven't changed since the previous push of the same backup, and
+// reports the outcome to the hooks. Its metrics are recorded into the registry (if it's set) and the metrics file.
+// Failing hooks and metrics are only logged, so that they never fail the push itself.
+func pushAndReport(
+	ctx context.Context, cs *flags.CmdScan, command string, reg *metrics.Registry,
+) (*pushReport, error) {
+	started := time.Now()
+	log := ax.DefaultLogger()
+
+	// Progress goes through the recorder, so that the metrics come from the same events as the printed progress.
+	rec := metrics.NewRecorder(log)
+
+	// Fingerprint is taken before pushing, so that changes made meanwhile are pushed along with the next push.
+	key, repo := ax.NewJobKey(prepareConfigForArchiving(cs), cs.Stream), cs.GitRepo
+	fingerprint, pushed := sourcesFingerprint(cs)
+
+	var report *pushReport
+
+	err := archiveEncryptAndPushToGit(ctx, cs, rec)
+	if err == nil && fingerprint != "" {
+		if fingerprint == pushed.Pushed(key, repo) {
+			log.Warn("sources haven't changed since the last push")
+
+			report = &pushReport{Warnings: []string{warnNoChanges}}
+		}
+
+		setErr := pushed.SetPushed(key, repo, fingerprint)
+		if setErr != nil {
+			log.Debug("failed recording fingerprint of the sources", "err", setErr)
+		}
+	}
+
+	var data interface{}
+	if report != nil {
+		data = report
+	}
+
+	result := output.NewResult(command, started, data, err)
+
+	recordMetrics(cs, reg, rec.Run(), result)
+	runHooks(cs, result, report != nil)
+
+	return report, err
+}
+
+// runHooks - reports the result of the push to the hooks, as a warning if it has succeeded with warnings.
+func runHooks(cs *flags.CmdScan, result output.Result, warned bool) {
+	if !cs.Hooks.Enabled() {
+		return
+	}
+
+	outcome := hooks.OutcomeSuccess
+
+	switch {
+	case result.Error != nil:
+		outcome = hooks.OutcomeFailure
+	case warned:
+		outcome = hooks.OutcomeWarning
+	}
+
+	payload := hookPayload{
+		Result:     result,
+		Outcome:    outcome,
+		Profile:    cs.Profile,
+		Sources:    cs.Sources,
+		FinishedAt: time.Now().UTC(),
+	}
+
+	// Hooks run even if ax has been interrupted, as that's an outcome worth reporting too.
+	err := hooks.Run(context.Background(), &cs.Hooks, outcome, payload)
+	if err != nil {
+		ax.DefaultLogger().Warn("reporting the outcome of the push failed", "err", err)
+	}
+}
+
+// sourcesFingerprint - returns the fingerprint of the sources, along with the store holding the fingerprints of the
+// previous pushes. Fingerprint is empty if it can't be taken, as telling whether the sources have changed is only best
+// effort.
+func sourcesFingerprint(cs *flags.CmdScan) (string, *ax.FingerprintStore) {
+	log := ax.DefaultLogger()
+
+	store, err := ax.OpenFingerprintStore("")
+	if err != nil {
+		log.Debug("failed opening fingerprints of the sources", "err", err)
+
+		return "", nil
+	}
+
+	fingerprint, err := ax.SourcesFingerprint(prepareConfigForArchiving(cs))
+	if err != nil {
+		log.Debug("failed fingerprinting the sources", "err", err)
+
+		return "", nil
+	}
+
+	return fingerprint, store
+}
@@ -2,144 +2,495 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/kaynetik/ax"
 	"github.com/kaynetik/ax/pkg/cli/flags"
+	"github.com/kaynetik/ax/pkg/cli/output"
 )
 
 const (
 	oneInt = int(1)
 
+	cmdNameInteractive = "interactive"
+	cmdNamePush        = "push"
+	cmdNameWatch       = "watch"
+	cmdNameDaemon      = "daemon"
+
 	flagCompareHelp           = "-help"
 	flagCompareArchiveIn      = "-arc-in"
 	flagCompareArchiveExtract = "-arc-extract"
 	flagCompareEncryptIn      = "-enc-in"
 	flagCompareDecryptIn      = "-dec-in"
 	flagCompareGitRepo        = "-git-repo"
+
+	encryptedFileMarker = ".enc."
 )
 
+// ErrNotImplemented - subcommand has been reserved, but isn't supported yet.
+var ErrNotImplemented = errors.New("command is not implemented yet")
+
 func main() {
-	args := os.Args
-	cmdScan := &flags.CmdScan{}
+	os.Exit(run(os.Args[oneInt:]))
+}
+
+// run - executes ax with the args (program name excluded), returning the exit code.
+func run(args []string) int {
+	started := time.Now()
 
-	if len(args) <= 1 {
+	ax.SetDefaultLogger(output.NewHumanLogger(os.Stderr, output.LevelInfo))
+
+	if len(args) == 0 {
 		printInteractiveModeHelp()
 
-		getScannerFn := func() *bufio.Scanner {
-			return bufio.NewScanner(os.Stdin)
-		}
+		return finish(cmdNameInteractive, output.FormatText, started, nil, runInteractive())
+	}
+
+	if flags.IsLegacyInvocation(args) {
+		return finish(args[0], output.FormatText, started, nil, runLegacy(args[0]))
+	}
+
+	cmd, err := flags.ParseCommand(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return output.ExitOK
+	}
 
-		cmdScan.ReadUserInput(getScannerFn)
+	if cmd == nil {
+		return finish(args[0], output.FormatText, started, nil, output.Usage(err))
+	}
 
-		archiveEncryptAndPushToGit(cmdScan)
+	if err != nil {
+		return finish(cmd.Name, cmd.Scan.Output, started, nil, output.Usage(err))
+	}
 
-		return
+	run, ok := commandRunners()[cmd.Name]
+	if !ok {
+		return finish(cmd.Name, cmd.Scan.Output, started, nil, fmt.Errorf("%w: %s", ErrNotImplemented, cmd.Name))
 	}
 
-	cmdScan = flags.ParseAllFlags()
+	// Progress goes to stderr, so that it never ends up mixed with the JSON result.
+	ax.SetDefaultLogger(output.NewHumanLogger(os.Stderr, output.LevelFor(cmd.Scan.Verbose, cmd.Scan.Quiet)))
 
-	switch args[oneInt] {
-	case flagCompareHelp:
-		printHelp()
-	case flagCompareArchiveIn:
-		conf := prepareConfigForArchiving(cmdScan)
+	// Interrupting ax cancels the command, so that it cleans up after itself before exiting.
+	ctx, stop := withInterrupt(context.Background())
+	defer stop()
 
-		err := archive(conf)
-		if err != nil {
-			panic(err)
+	data, err := run(ctx, &cmd.Scan)
+
+	return finish(cmd.Name, cmd.Scan.Output, started, data, err)
+}
+
+// finish - reports the result of the command in the chosen format, and returns the exit code matching it.
+func finish(command, format string, started time.Time, data interface{}, err error) int {
+	r := output.NewResult(command, started, data, err)
+
+	printErr := output.Print(os.Stdout, os.Stderr, format, r)
+	if printErr != nil {
+		_, _ = fmt.Fprintln(os.Stderr, printErr)
+	}
+
+	return r.ExitCode
+}
+
+func runInteractive() error {
+	cmdScan := &flags.CmdScan{}
+
+	getScannerFn := func() *bufio.Scanner {
+		return bufio.NewScanner(os.Stdin)
+	}
+
+	err := cmdScan.ReadUserInput(getScannerFn)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := withInterrupt(context.Background())
+	defer stop()
+
+	return archiveEncryptAndPushToGit(ctx, cmdScan, ax.DefaultLogger())
+}
+
+// commandRunner - executes a subcommand with the scanned flags, returning what it reports (if anything). It stops once
+// the context is done.
+type commandRunner func(ctx context.Context, cs *flags.CmdScan) (interface{}, error)
+
+// commandRunners - maps each subcommand to the func which executes it with the scanned flags.
+func commandRunners() map[string]commandRunner {
+	return map[string]commandRunner{
+		"archive": previewOr(noData(runArchive), planArchive),
+		"extract": noData(runExtract),
+		"encrypt": noData(runEncrypt),
+		"decrypt": noData(runDecrypt),
+		"push":    previewOr(runPush, planPush),
+		"watch":   previewOr(noData(runWatch), planPush),
+		"pull":    noData(runPull),
+		"restore": noData(runRestore),
+		"verify":  noData(runVerify),
+		"list":    runList,
+		"doctor":  runDoctor,
+		"resume":  noData(runResume),
+		"daemon":  noData(runDaemon),
+		"status":  runStatus,
+	}
+}
+
+// noData - adapts the func of a subcommand which reports nothing but its error.
+func noData(run func(ctx context.Context, cs *flags.CmdScan) error) commandRunner {
+	return func(ctx context.Context, cs *flags.CmdScan) (interface{}, error) {
+		return nil, run(ctx, cs)
+	}
+}
+
+// previewOr - lists the files which would be archived if -list-only has been set, reports what would be done if
+// -dry-run has been set, or else runs the subcommand.
+func previewOr(run commandRunner, plan func(cs *flags.CmdScan) (interface{}, error)) commandRunner {
+	return func(ctx context.Context, cs *flags.CmdScan) (interface{}, error) {
+		if cs.DryRun {
+			return plan(cs)
 		}
 
-		return
-	case flagCompareArchiveExtract:
-		conf := prepareConfigForExtracting(cmdScan)
+		if !cs.ListOnly {
+			return run(ctx, cs)
+		}
 
-		err := extract(conf)
+		fileList, err := ax.ListArchiveFiles(prepareConfigForArchiving(cs))
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("failed listing files to archive: %w", err)
 		}
 
-		return
+		return output.Lines(fileList), nil
+	}
+}
+
+// runLegacy - keeps the deprecated flag-style invocations working, i.e. `ax -arc-in ../tmp_to_archive`.
+func runLegacy(firstArg string) error {
+	if firstArg == flagCompareHelp {
+		printHelp()
+
+		return nil
+	}
+
+	ax.DefaultLogger().Warn(
+		"flag-style invocation is deprecated and will be removed, use subcommands instead (see 'ax help').")
+
+	//nolint:staticcheck // Deprecated parsing is used on purpose, to keep old invocations working.
+	cmdScan, err := flags.ParseAllFlags()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := withInterrupt(context.Background())
+	defer stop()
+
+	switch firstArg {
+	case flagCompareArchiveIn:
+		err = runArchive(ctx, cmdScan)
+	case flagCompareArchiveExtract:
+		err = runExtract(ctx, cmdScan)
 	case flagCompareEncryptIn:
-		fileList, err := ax.ListFiles(cmdScan.EncryptPath, ax.DefaultPathWalkerFunc)
-		if err != nil {
-			panic(err)
+		err = runEncrypt(ctx, cmdScan)
+	case flagCompareDecryptIn:
+		err = runDecrypt(ctx, cmdScan)
+	case flagCompareGitRepo:
+		err = archiveEncryptAndPushToGit(ctx, cmdScan, ax.DefaultLogger())
+	default:
+		err = output.Usage(errors.New("unknown flag provided"))
+	}
+
+	return err
+}
+
+func runArchive(ctx context.Context, cs *flags.CmdScan) error {
+	return archive(ctx, prepareConfigForArchiving(cs))
+}
+
+func runExtract(ctx context.Context, cs *flags.CmdScan) error {
+	return extract(ctx, prepareConfigForExtracting(cs))
+}
+
+func runEncrypt(ctx context.Context, cs *flags.CmdScan) error {
+	fileList, err := ax.ListFiles(cs.EncryptPath, ax.DefaultPathWalkerFunc)
+	if err != nil {
+		return fmt.Errorf("failed listing files for encryption: %w", err)
+	}
+
+	return ax.DefaultFileEncryptionWithContext(ctx, cs.EncryptPassword, fileList)
+}
+
+func runDecrypt(ctx context.Context, cs *flags.CmdScan) error {
+	fileList, err := ax.ListFiles(cs.DecryptPath, ax.DefaultPathWalkerFunc)
+	if err != nil {
+		return fmt.Errorf("failed listing files for decryption: %w", err)
+	}
+
+	return ax.DefaultFileDecryptionWithContext(ctx, cs.DecryptPassword, fileList)
+}
+
+func runPull(ctx context.Context, cs *flags.CmdScan) error {
+	err := ax.PullFromGITWithContext(ctx, cs.GitRepo, cs.ArchiveExtract)
+	if err != nil {
+		return fmt.Errorf("an issue occurred while pulling archive(s): %w", err)
+	}
+
+	ax.DefaultLogger().Info("Pulled from GIT! Your encrypted Archive(s) are at: " + cs.ArchiveExtract)
+
+	return nil
+}
+
+func runRestore(ctx context.Context, cs *flags.CmdScan) error {
+	err := runPull(ctx, cs)
+	if err != nil {
+		return err
+	}
+
+	fileList, err := ax.ListFiles(cs.DecryptPath, ax.DefaultPathWalkerFunc)
+	if err != nil {
+		return fmt.Errorf("failed listing files for decryption: %w", err)
+	}
+
+	// Only the encrypted volume(s) are decrypted, anything else pulled along with them is left untouched.
+	encryptedFiles := make([]string, 0, len(fileList))
+
+	for _, file := range fileList {
+		if strings.Contains(file, encryptedFileMarker) {
+			encryptedFiles = append(encryptedFiles, file)
 		}
+	}
 
-		err = ax.DefaultFileEncryption(cmdScan.EncryptPassword, fileList)
-		if err != nil {
-			panic(err)
+	err = ax.DefaultFileDecryptionWithContext(ctx, cs.DecryptPassword, encryptedFiles)
+	if err != nil {
+		return fmt.Errorf("an issue occurred while decrypting archive(s): %w", err)
+	}
+
+	if cs.VerifyOnly {
+		return runVerify(ctx, cs)
+	}
+
+	return runExtract(ctx, cs)
+}
+
+func runVerify(ctx context.Context, cs *flags.CmdScan) error {
+	err := ax.TestArchiveWithContext(ctx, prepareConfigForExtracting(cs))
+	if err != nil {
+		return fmt.Errorf("an issue occurred while verifying archive(s): %w", err)
+	}
+
+	return nil
+}
+
+func runList(ctx context.Context, cs *flags.CmdScan) (interface{}, error) {
+	entries, err := ax.ListWithContext(ctx, prepareConfigForExtracting(cs))
+	if err != nil {
+		return nil, fmt.Errorf("an issue occurred while listing archive(s): %w", err)
+	}
+
+	return entryTable(entries), nil
+}
+
+func runDoctor(_ context.Context, cs *flags.CmdScan) (interface{}, error) {
+	env := ax.CheckEnvironment(&ax.EnvironmentConfig{OutputPath: cs.ArchiveOutPath, Repo: cs.GitRepo})
+
+	return checkTable{env}, env.Err()
+}
+
+// archiveEncryptAndPushToGit - archives, encrypts & pushes the sources, reporting the progress to the log.
+func archiveEncryptAndPushToGit(ctx context.Context, cs *flags.CmdScan, log ax.Logger) (err error) {
+	created := cs.ArchiveOutPath == ""
+	key := ax.NewJobKey(prepareConfigForArchiving(cs), cs.Stream)
+
+	wd, err := openWorkDir(cs, key)
+	if err != nil {
+		return err
+	}
+
+	// Whatever a previous run has left behind is never pushed along, unless it's the encrypted volume(s) of the same
+	// backup, which has been interrupted while pushing them.
+	job, err := ax.OpenJob(log, wd, key, cs.GitRepo)
+	if err != nil {
+		_ = wd.Close()
+
+		return fmt.Errorf("failed cleaning up work directory: %w", err)
+	}
+
+	// Plaintext Archive(s) are cleaned up however the pipeline ends, even if it's interrupted.
+	defer func() {
+		closeErr := closeWorkDir(wd, job, created, err != nil)
+		if err == nil {
+			err = closeErr
 		}
+	}()
 
-		return
-	case flagCompareDecryptIn:
-		fileList, err := ax.ListFiles(cmdScan.DecryptPath, ax.DefaultPathWalkerFunc)
-		if err != nil {
-			panic(err)
+	cs.ArchiveOutPath, cs.EncryptPath = wd.Path, wd.Path
+
+	if !job.Resumable() {
+		if cs.Stream {
+			err = streamArchive(ctx, cs, job, log)
+		} else {
+			err = archiveAndEncrypt(ctx, cs, wd, job, log)
 		}
 
-		err = ax.DefaultFileDecryption(cmdScan.DecryptPassword, fileList)
 		if err != nil {
-			panic(err)
+			return err
 		}
+	}
 
-		return
-	case flagCompareGitRepo:
-		archiveEncryptAndPushToGit(cmdScan)
-	default:
-		panic(errors.New("unknown flag provided"))
+	// Push to GIT Repository
+	return pushJob(ctx, job, cs.GitRepo)
+}
+
+// runResume - pushes the encrypted volume(s) of an interrupted push, which haven't reached the remote yet.
+func runResume(ctx context.Context, cs *flags.CmdScan) (err error) {
+	// Checked first, as opening the work directory would create it.
+	if _, statErr := os.Stat(cs.ArchiveOutPath); os.IsNotExist(statErr) {
+		return fmt.Errorf("an issue occurred while resuming: %w: %s doesn't exist", ax.ErrNothingToResume,
+			cs.ArchiveOutPath)
+	}
+
+	wd, err := ax.OpenWorkDir(cs.ArchiveOutPath)
+	if err != nil {
+		return fmt.Errorf("failed opening work directory: %w", err)
+	}
+
+	job, err := ax.ResumeJob(ax.DefaultLogger(), wd, cs.GitRepo)
+	if err != nil {
+		_ = wd.Close()
+
+		if errors.Is(err, ax.ErrRedactedRepo) {
+			err = output.Usage(fmt.Errorf("%w, choose it with -repo", err))
+		}
+
+		return fmt.Errorf("an issue occurred while resuming: %w", err)
+	}
+
+	defer func() {
+		closeErr := closeWorkDir(wd, job, false, err != nil)
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	repo := cs.GitRepo
+	if repo == "" {
+		repo = job.Repo
 	}
+
+	return pushJob(ctx, job, repo)
 }
 
-func archiveEncryptAndPushToGit(cs *flags.CmdScan) {
-	// Cleanup
-	err := os.RemoveAll(cs.ArchiveOutPath)
+// streamArchive - streams the Archive through the encryption into volume(s), so it's never written unencrypted.
+func streamArchive(ctx context.Context, cs *flags.CmdScan, job *ax.Job, log ax.Logger) error {
+	conf := prepareConfigForArchiving(cs)
+	conf.Logger = log
+
+	volumes, err := ax.StreamArchiveWithContext(ctx, conf, cs.EncryptPassword)
+	if err != nil {
+		return fmt.Errorf("an issue occurred while streaming: %w", err)
+	}
+
+	err = job.Complete(ax.JobArchived)
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	// Verify, by decrypting and decompressing the stream in memory
+	if cs.Verify {
+		err = ax.TestArchiveWithContext(ctx,
+			&ax.ExtractConfig{ArchivePath: cs.ArchiveOutPath, EncryptionPassword: cs.EncryptPassword, Logger: log})
+		if err != nil {
+			return fmt.Errorf("an issue occurred while verifying archive(s): %w", err)
+		}
+
+		err = job.Complete(ax.JobVerified)
+		if err != nil {
+			return err
+		}
 	}
 
+	return completeEncryption(job, volumes)
+}
+
+// archiveAndEncrypt - archives into the work directory with 7zip, and encrypts the volume(s) afterwards.
+func archiveAndEncrypt(ctx context.Context, cs *flags.CmdScan, wd *ax.WorkDir, job *ax.Job, log ax.Logger) error {
 	// Archive
-	arcConf := prepareConfigForArchiving(cs)
+	conf := prepareConfigForArchiving(cs)
+	conf.Logger = log
 
-	err = archive(arcConf)
+	err := archive(ctx, conf)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	// Encrypt
-	fileList, err := ax.ListFiles(cs.EncryptPath, ax.DefaultPathWalkerFunc)
+	err = job.Complete(ax.JobArchived)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	err = ax.DefaultFileEncryption(cs.EncryptPassword, fileList)
+	// Verify, so that a broken Archive is never pushed
+	if cs.Verify {
+		err = ax.TestArchiveWithContext(ctx,
+			&ax.ExtractConfig{Password: cs.PasswordByte, ArchivePath: cs.ArchiveOutPath, Logger: log})
+		if err != nil {
+			return fmt.Errorf("an issue occurred while verifying archive(s): %w", err)
+		}
+
+		err = job.Complete(ax.JobVerified)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Encrypt, leaving out the marker of the work directory
+	files, err := wd.Files()
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed listing files for encryption: %w", err)
 	}
 
-	// Push to GIT Repository
-	err = os.Chdir(cs.ArchiveOutPath)
+	err = ax.DefaultFileEncryptionWithLogger(ctx, log, cs.EncryptPassword, files)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	err = ax.PushToGIT(cs.GitRepo)
+	volumes, err := wd.Files()
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed listing encrypted files: %w", err)
 	}
 
-	printStdoutLn("Pushed to GIT! Your Archive(s) have been backed up!")
+	return completeEncryption(job, volumes)
 }
 
-func archive(conf *ax.ArchiveConfig) error {
-	err := ax.Archive(conf)
+// completeEncryption - records the encrypted volume(s) of the job, from which point on it's resumable.
+func completeEncryption(job *ax.Job, volumes []string) error {
+	err := job.SetVolumes(volumes)
 	if err != nil {
-		return fmt.Errorf("an issue occurred while archiving: %w", err)
+		return fmt.Errorf("failed recording encrypted volume(s): %w", err)
 	}
 
-	printStdoutLn("Finished Archiving!")
+	return job.Complete(ax.JobEncrypted)
+}
+
+// pushJob - pushes the encrypted volume(s) of the job, which haven't reached the remote yet.
+func pushJob(ctx context.Context, job *ax.Job, gitRepo string) error {
+	err := ax.PushJobWithContext(ctx, job, gitRepo)
+	if err != nil {
+		return fmt.Errorf("an issue occurred while pushing archive(s): %w", err)
+	}
+
+	ax.DefaultLogger().Info("Pushed to GIT! Your Archive(s) have been backed up!")
+
+	return nil
+}
+
+func archive(ctx context.Context, conf *ax.ArchiveConfig) error {
+	err := ax.ArchiveWithContext(ctx, conf)
+	if err != nil {
+		return fmt.Errorf("an issue occurred while archiving: %w", err)
+	}
 
 	return nil
 }
@@ -149,27 +500,50 @@ func prepareConfigForArchiving(scannedFlags *flags.CmdScan) *ax.ArchiveConfig {
 	ac.Password = scannedFlags.PasswordByte
 	ac.ApplyPassword = scannedFlags.ProtectArchiveWithPasswd
 	ac.PathToArchive = scannedFlags.PathToArchive
+	ac.Sources = scannedFlags.Sources
+	ac.Root = scannedFlags.Root
 	ac.OutputPath = scannedFlags.ArchiveOutPath
 	ac.NewArchiveName = scannedFlags.NewArchiveName
+	ac.ArchiveType = scannedFlags.ArchiveType
+	ac.SetVolumeSize(scannedFlags.VolumeBytes)
+	ac.FastBytes = uint16(scannedFlags.FastBytes)
+	ac.DictSize = uint16(scannedFlags.DictBytes >> 20)
+	ac.Compression = uint8(scannedFlags.Compression)
+	ac.HeadersEncryption = scannedFlags.HeadersEncryption
+	ac.SolidArchive = scannedFlags.SolidArchive
+	ac.SolidBlockSize = scannedFlags.SolidBlockBytes
+	ac.Method = scannedFlags.Method
+	ac.Threads = uint16(scannedFlags.Threads)
+	ac.PreserveMetadata = scannedFlags.PreserveMetadata
+	ac.Include = scannedFlags.Include
+	ac.Exclude = scannedFlags.Exclude
 
 	return &ac
 }
 
-func extract(conf *ax.ExtractConfig) error {
-	err := ax.Extract(conf)
+func extract(ctx context.Context, conf *ax.ExtractConfig) error {
+	err := ax.ExtractWithContext(ctx, conf)
 	if err != nil {
 		return fmt.Errorf("an issue occurred while etxtacting archive(s): %w", err)
 	}
 
-	printStdoutLn("Finished Extracting!")
-
 	return nil
 }
 
 func prepareConfigForExtracting(scannedFlags *flags.CmdScan) *ax.ExtractConfig {
 	ec := ax.ExtractConfig{
 		Password:    scannedFlags.PasswordByte,
-		ExtractPath: scannedFlags.ArchiveExtract,
+		ArchivePath: scannedFlags.ArchiveExtract,
+		OutputDir:   scannedFlags.ExtractOut,
+		Overwrite:   ax.OverwritePolicy(scannedFlags.Overwrite),
+		Files:       scannedFlags.Files,
+
+		AllowUnsafePaths: scannedFlags.AllowUnsafePaths,
+		PreserveMetadata: scannedFlags.PreserveMetadata,
+		NumericOwner:     scannedFlags.NumericOwner,
+
+		// Streamed Archive(s) are decrypted along the way, with the password the encrypted volume(s) are decrypted with.
+		EncryptionPassword: scannedFlags.DecryptPassword,
 	}
 
 	return &ec
@@ -180,11 +554,8 @@ func printStdoutLn(args ...interface{}) {
 }
 
 func printHelp() {
-	printStdoutLn("TODO: Additional info about the crypto CLI implementation.\n\n")
-	printStdoutLn("When called without any arguments/flags, interactive mode will be initated.\n")
-	printStdoutLn(
-		"When called with arguments/flags, those that are left out will assume their default required values.\n\n",
-	)
+	flags.PrintUsage(os.Stdout)
+	printStdoutLn("\nFlag-style invocation (i.e. 'ax -arc-in ../tmp_to_archive') is deprecated, but still supported.")
 }
 
 func printInteractiveModeHelp() {
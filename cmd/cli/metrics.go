@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/cli/flags"
+	"github.com/kaynetik/ax/pkg/cli/output"
+	"github.com/kaynetik/ax/pkg/metrics"
+)
+
+const (
+	// metricsPath - path the daemon serves the metrics on.
+	metricsPath = "/metrics"
+
+	// metricsReadTimeout & metricsShutdownTimeout - how long a scrape may take to send its request, and how long the
+	// scrapes in progress may take to finish, once the daemon stops.
+	metricsReadTimeout     = 10 * time.Second
+	metricsShutdownTimeout = 5 * time.Second
+)
+
+// recordMetrics - records the metrics of the push into the registry, if it's set, and into the metrics file, if one
+// has been chosen.
+func recordMetrics(cs *flags.CmdScan, reg *metrics.Registry, run metrics.Run, result output.Result) {
+	if reg == nil && cs.MetricsFile == "" {
+		return
+	}
+
+	res := metrics.Result{
+		Run:      run,
+		Finished: time.Now(),
+		Duration: time.Duration(result.DurationSeconds * float64(time.Second)),
+	}
+
+	if result.Error != nil {
+		res.FailureReason = result.Error.Kind
+	}
+
+	name := backupName(cs)
+
+	if reg != nil {
+		reg.Observe(name, res)
+	}
+
+	if cs.MetricsFile == "" {
+		return
+	}
+
+	log := ax.DefaultLogger()
+
+	// Metrics of the previous pushes are read back, so that the counters and the last success are kept between them.
+	file := metrics.NewRegistry()
+
+	err := file.LoadTextfile(cs.MetricsFile)
+	if err != nil {
+		log.Warn("metrics of the previous pushes can't be read, starting over", "err", err)
+
+		file = metrics.NewRegistry()
+	}
+
+	file.Observe(name, res)
+
+	err = file.WriteTextfile(cs.MetricsFile)
+	if err != nil {
+		log.Warn("recording metrics of the push failed", "err", err)
+	}
+}
+
+// backupName - returns the name the metrics of the push are labeled with: its profile, or else the name of its
+// archive(s).
+func backupName(cs *flags.CmdScan) string {
+	if cs.Profile != "" {
+		return cs.Profile
+	}
+
+	return cs.NewArchiveName
+}
+
+// serveMetrics - serves the metrics of the registry over HTTP on the address, until the context is done.
+func serveMetrics(ctx context.Context, addr string, reg *metrics.Registry) error {
+	ln, err := (&net.ListenConfig{}).Listen(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed serving metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, reg.Handler())
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: metricsReadTimeout}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	go func() {
+		serveErr := srv.Serve(ln)
+		if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			ax.DefaultLogger().Warn("daemon stopped serving metrics", "err", serveErr)
+		}
+	}()
+
+	ax.DefaultLogger().Info("Serving metrics", "addr", ln.Addr().String(), "path", metricsPath)
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kaynetik/ax"
+)
+
+// withInterrupt - returns the context which is canceled once ax is interrupted (SIGINT or SIGTERM), so that the running
+// command kills whatever it has executed, cleans up after itself, and ax exits with output.ExitInterrupted. Once it's
+// interrupted, default handling of the signals is restored, so that another one kills ax right away, if cleaning up
+// hangs. The returned func releases the context.
+func withInterrupt(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			ax.DefaultLogger().Error("interrupted, cleaning up (interrupt again to exit right away)",
+				"signal", sig.String())
+			cancel()
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/kaynetik/ax"
+)
+
+const (
+	tableMinWidth = 0
+	tableTabWidth = 8
+	tablePadding  = 2
+
+	tableTimeLayout = "2006-01-02 15:04:05"
+	tableFlagSet    = "+"
+	tableFlagUnset  = "-"
+)
+
+// entryTable - entries of the archive(s), printed as a table in text format.
+type entryTable []ax.Entry
+
+// WriteText - writes the entries as a table, followed by the totals.
+func (t entryTable) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, tableMinWidth, tableTabWidth, tablePadding, ' ', tabwriter.AlignRight)
+
+	_, err := fmt.Fprintln(tw, "Modified\tAttributes\tSize\tPacked\tCRC\tEnc\t Path")
+	if err != nil {
+		return fmt.Errorf("failed writing table: %w", err)
+	}
+
+	var size, packed uint64
+
+	for _, e := range t {
+		modified := ""
+		if !e.Modified.IsZero() {
+			modified = e.Modified.Format(tableTimeLayout)
+		}
+
+		encrypted := tableFlagUnset
+		if e.Encrypted {
+			encrypted = tableFlagSet
+		}
+
+		_, err = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\t %s\n",
+			modified, e.Attributes, e.Size, e.PackedSize, e.CRC, encrypted, e.Path)
+		if err != nil {
+			return fmt.Errorf("failed writing table: %w", err)
+		}
+
+		size += e.Size
+		packed += e.PackedSize
+	}
+
+	_, err = fmt.Fprintf(tw, "\t\t%d\t%d\t\t\t %d entries\n", size, packed, len(t))
+	if err != nil {
+		return fmt.Errorf("failed writing table: %w", err)
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return fmt.Errorf("failed writing table: %w", err)
+	}
+
+	return nil
+}
+
+// checkTable - outcome of the environment checks, printed as a table in text format, followed by the fixes.
+type checkTable struct {
+	*ax.Environment
+}
+
+// WriteText - writes status and detail of each check, and the fixes of the ones which haven't passed.
+func (t checkTable) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, tableMinWidth, tableTabWidth, tablePadding, ' ', 0)
+
+	_, err := fmt.Fprintln(tw, "Check\tStatus\tDetail")
+	if err != nil {
+		return fmt.Errorf("failed writing table: %w", err)
+	}
+
+	for _, c := range t.Checks {
+		_, err = fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, c.Status, c.Detail)
+		if err != nil {
+			return fmt.Errorf("failed writing table: %w", err)
+		}
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return fmt.Errorf("failed writing table: %w", err)
+	}
+
+	for _, c := range t.Checks {
+		if c.Fix == "" {
+			continue
+		}
+
+		_, err = fmt.Fprintf(w, "\nFix %s: %s", c.Name, c.Fix)
+		if err != nil {
+			return fmt.Errorf("failed writing fixes: %w", err)
+		}
+	}
+
+	_, err = fmt.Fprintln(w)
+	if err != nil {
+		return fmt.Errorf("failed writing fixes: %w", err)
+	}
+
+	return nil
+}
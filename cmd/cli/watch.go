@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/cli/flags"
+	"github.com/kaynetik/ax/pkg/watch"
+)
+
+// runWatch - pushes the sources once at start, and then whenever they change, until ax is interrupted.
+func runWatch(ctx context.Context, cs *flags.CmdScan) error {
+	notifier, err := watch.NewNotifier(cs.Sources, cs.PollInterval, ax.DefaultLogger())
+	if err != nil {
+		return fmt.Errorf("failed watching sources: %w", err)
+	}
+
+	defer notifier.Close()
+
+	workDir := ""
+	if cs.ArchiveOutPath != "" {
+		workDir, err = filepath.Abs(cs.ArchiveOutPath)
+		if err != nil {
+			return fmt.Errorf("failed resolving work directory: %w", err)
+		}
+	}
+
+	return watch.Run(ctx, watch.Config{
+		Notifier:    notifier,
+		Debounce:    cs.Debounce,
+		MinInterval: cs.MinInterval,
+		MaxBackoff:  cs.MaxBackoff,
+		// Archive(s) written into a work directory within the sources mustn't trigger another push.
+		Ignore: func(path string) bool {
+			abs, absErr := filepath.Abs(path)
+
+			return workDir != "" && absErr == nil && isWithin(workDir, abs)
+		},
+		Run: func(ctx context.Context) error {
+			// Each push gets its own copy of the flags, as the pipeline fills in the work directory it has used.
+			push := *cs
+
+			_, err := pushAndReport(ctx, &push, cmdNameWatch, nil)
+
+			return err
+		},
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/cli/flags"
+)
+
+// openWorkDir - opens the work directory of the job with the key under the system temp dir, unless -out has been
+// chosen, in which case it's used only if it's empty or has been created by ax.
+func openWorkDir(cs *flags.CmdScan, key string) (*ax.WorkDir, error) {
+	if cs.ArchiveOutPath == "" {
+		wd, err := ax.OpenJobWorkDir("", key)
+		if err != nil {
+			return nil, fmt.Errorf("failed creating work directory: %w", err)
+		}
+
+		return wd, nil
+	}
+
+	wd, err := ax.OpenWorkDir(cs.ArchiveOutPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening work directory: %w", err)
+	}
+
+	return wd, nil
+}
+
+// closeWorkDir - removes the work directory of the job under the system temp dir once it's done with. The chosen one
+// is kept, holding the encrypted volume(s) on success. On failure either one holds only the encrypted volume(s) of
+// the job, if it can be resumed, and nothing otherwise, so that plaintext Archive(s) are never left behind.
+func closeWorkDir(wd *ax.WorkDir, job *ax.Job, created, failed bool) error {
+	var err error
+
+	switch {
+	case created && (!failed || !job.Resumable()):
+		err = wd.Remove()
+	case failed:
+		err = job.CleanWorkDir(wd)
+		if closeErr := wd.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err == nil && job.Resumable() {
+			ax.DefaultLogger().Warn("encrypted volume(s) are kept, push again or run 'ax resume' with -out to push "+
+				"the rest of them", "out", wd.Path, "pending", len(job.Pending()))
+		}
+	default:
+		err = wd.Close()
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed cleaning up work directory: %w", err)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package ax
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/sha256"
@@ -11,53 +12,95 @@ import (
 )
 
 // FileDecryption - decrypt a file.
+//
+// It panics on failure, DefaultFileDecryption should be preferred as it returns the error instead.
 func FileDecryption(key []byte, encFileName, decFileName string) {
-	inFile, err := os.Open(encFileName)
+	err := decryptFile(key, encFileName, decFileName)
 	if err != nil {
 		panic(err)
 	}
+}
+
+func decryptFile(key []byte, encFileName, decFileName string) error {
+	return decryptFileContext(context.Background(), key, encFileName, decFileName)
+}
+
+// decryptFileContext - decrypts the file, stopping once the context is done. The decrypted file is written under its
+// name only once it's complete, see writePartFile.
+func decryptFileContext(ctx context.Context, key []byte, encFileName, decFileName string) error {
+	inFile, err := os.Open(encFileName)
+	if err != nil {
+		return fmt.Errorf("failed opening file for decryption: %w", err)
+	}
 
 	defer inFile.Close()
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed creating cipher: %w", err)
 	}
 
 	iv := make([]byte, aes.BlockSize)
 	stream := cipher.NewOFB(block, iv)
 
-	outFile, err := os.OpenFile(decFileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, encFilePerm)
-	if err != nil {
-		panic(err)
-	}
-
-	defer outFile.Close()
+	reader := &cipher.StreamReader{S: stream, R: &contextReader{ctx: ctx, r: inFile}}
 
-	reader := &cipher.StreamReader{S: stream, R: inFile}
+	err = writePartFile(decFileName, encFilePerm, func(w io.Writer) error {
+		_, err := io.Copy(w, reader)
 
-	if _, err = io.Copy(outFile, reader); err != nil {
-		panic(err)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed decrypting file [%s]: %w", encFileName, err)
 	}
+
+	return nil
 }
 
 // DefaultFileDecryption -- represents basic usage of the FileDecryption func.
+// Progress is reported to the DefaultLogger.
 func DefaultFileDecryption(passwd []byte, fileList []string) error {
+	return DefaultFileDecryptionWithContext(context.Background(), passwd, fileList)
+}
+
+// DefaultFileDecryptionWithContext - same as DefaultFileDecryption, but it stops once the context is done, returning
+// its error.
+//
+// Each encrypted file is removed only once its decrypted counterpart is complete, so running it again over the files
+// left by an interrupted run resumes the decryption. Unfinished files left by a killed run are skipped.
+func DefaultFileDecryptionWithContext(ctx context.Context, passwd []byte, fileList []string) error {
 	key := sha256.Sum256(passwd)
+	decrypted := 0
 
 	for _, file := range fileList {
+		if isPartFile(file) {
+			DefaultLogger().Debug("skipped unfinished file", "file", file)
+
+			continue
+		}
+
+		err := ctx.Err()
+		if err != nil {
+			return fmt.Errorf("decryption interrupted: %w", err)
+		}
+
 		fileNameSlc := strings.Split(file, ".")
 		decryptedFileName := strings.Join(fileNameSlc[:len(fileNameSlc)-2], ".")
 
-		FileDecryption(key[:], file, decryptedFileName)
+		err = decryptFileContext(ctx, key[:], file, decryptedFileName)
+		if err != nil {
+			return err
+		}
 
-		err := os.Remove(file)
+		err = os.Remove(file)
 		if err != nil {
 			return fmt.Errorf("failed removing file at path [%s]: %w", file, err)
 		}
+
+		decrypted++
 	}
 
-	printStdoutLn("Archives Decrypted!")
+	DefaultLogger().Info("Archives Decrypted!", "count", decrypted)
 
 	return nil
 }
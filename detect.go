@@ -0,0 +1,252 @@
+package ax
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Format - represents format of an archive, as detected by its magic bytes.
+type Format string
+
+// Formats which can be detected. Compressed tarballs are detected as such, by peeking into the decompressed stream.
+const (
+	FormatUnknown  Format = ""
+	Format7z       Format = "7z"
+	FormatZip      Format = "zip"
+	FormatRar      Format = "rar"
+	FormatTar      Format = "tar"
+	FormatTarGzip  Format = "tar.gz"
+	FormatTarBzip2 Format = "tar.bz2"
+	FormatGzip     Format = "gz"
+	FormatBzip2    Format = "bz2"
+	FormatXz       Format = "xz"
+	FormatZstd     Format = "zstd"
+
+	// FormatStream - gzip compressed tarball encrypted by ax, as written by StreamArchive.
+	FormatStream Format = "ax"
+)
+
+const (
+	// magicPeekSize - number of bytes needed to detect any of the formats, which is the size of a tar header block.
+	magicPeekSize = 512
+
+	tarMagicOffset = 257
+	tarMagic       = "ustar"
+)
+
+// ErrNoArchives - path holds no archive in a known format.
+var ErrNoArchives = errors.New("no archives found")
+
+// volumeSuffixRe - matches the suffix of split volumes, i.e. '.001', which 7zip appends to the name of the archive.
+var volumeSuffixRe = regexp.MustCompile(`\.(\d{3,})$`) //nolint:gochecknoglobals // Compiled once, read-only.
+
+// magicNumber - represents the bytes files of the format start with.
+type magicNumber struct {
+	format Format
+	magic  []byte
+}
+
+// magicNumbers - returns magic numbers of the detected formats, except tar, whose magic isn't at the start.
+func magicNumbers() []magicNumber {
+	return []magicNumber{
+		{Format7z, []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}},
+		{FormatZip, []byte{'P', 'K', 0x03, 0x04}},
+		{FormatZip, []byte{'P', 'K', 0x05, 0x06}},
+		{FormatZip, []byte{'P', 'K', 0x07, 0x08}},
+		{FormatRar, []byte{'R', 'a', 'r', '!', 0x1A, 0x07}},
+		{FormatXz, []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}},
+		{FormatZstd, []byte{0x28, 0xB5, 0x2F, 0xFD}},
+		{FormatGzip, []byte{0x1F, 0x8B}},
+		{FormatBzip2, []byte{'B', 'Z', 'h'}},
+		{FormatStream, streamMagic},
+	}
+}
+
+// DetectFormat - detects format of the archive by its magic bytes, returning FormatUnknown if it isn't recognized.
+func DetectFormat(r io.Reader) (Format, error) {
+	header, err := peek(r)
+	if err != nil {
+		return FormatUnknown, err
+	}
+
+	if isTarHeader(header) {
+		return FormatTar, nil
+	}
+
+	for _, m := range magicNumbers() {
+		if !bytes.HasPrefix(header, m.magic) {
+			continue
+		}
+
+		switch m.format {
+		case FormatGzip:
+			return compressedTar(header, FormatGzip, FormatTarGzip, func(r io.Reader) (io.Reader, error) {
+				return gzip.NewReader(r)
+			}), nil
+		case FormatBzip2:
+			return compressedTar(header, FormatBzip2, FormatTarBzip2, func(r io.Reader) (io.Reader, error) {
+				return bzip2.NewReader(r), nil
+			}), nil
+		default:
+			return m.format, nil
+		}
+	}
+
+	return FormatUnknown, nil
+}
+
+// peek - reads up to magicPeekSize bytes, which is less only if the input is shorter.
+func peek(r io.Reader) ([]byte, error) {
+	header := make([]byte, magicPeekSize)
+
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed reading archive header: %w", err)
+	}
+
+	return header[:n], nil
+}
+
+func isTarHeader(header []byte) bool {
+	return len(header) >= tarMagicOffset+len(tarMagic) &&
+		string(header[tarMagicOffset:tarMagicOffset+len(tarMagic)]) == tarMagic
+}
+
+// compressedTar - returns tarFormat if the decompressed header is a tar one, or else the plain compressed format.
+// Only the header is decompressed, so if the stream is shorter (or corrupted) it's reported as the plain one.
+func compressedTar(header []byte, plain, tarFormat Format, decompress func(io.Reader) (io.Reader, error)) Format {
+	dr, err := decompress(bytes.NewReader(header))
+	if err != nil {
+		return plain
+	}
+
+	inner, _ := peek(dr)
+	if isTarHeader(inner) {
+		return tarFormat
+	}
+
+	return plain
+}
+
+// ArchiveSet - represents a single archive, which may be split into volumes.
+type ArchiveSet struct {
+	// Volumes - paths of the volumes in order, or just the path of the archive if it isn't split.
+	Volumes []string
+
+	// Format - format of the archive, as detected from its (first) volume.
+	Format Format
+}
+
+// Path - returns path of the archive, i.e. its first volume.
+func (as *ArchiveSet) Path() string {
+	return as.Volumes[0]
+}
+
+// native - reports whether the archive is handled by ax itself, rather than by 7zip.
+func (as *ArchiveSet) native() bool {
+	switch as.Format {
+	case FormatTar, FormatTarGzip, FormatTarBzip2, FormatStream:
+		return true
+	default:
+		return false
+	}
+}
+
+// FindArchives - finds the archive(s) at the path, which is either an archive (or its first volume), or a directory
+// holding any number of archives. Split volumes, i.e. 'name.7z.001', 'name.7z.002', are grouped into a single
+// ArchiveSet, while files in an unknown format (i.e. encrypted volumes) are skipped.
+func FindArchives(path string) ([]ArchiveSet, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting path stat: %w", err)
+	}
+
+	if !stat.IsDir() {
+		set, err := archiveSetOf(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if set.Format == FormatUnknown {
+			return nil, fmt.Errorf("%w: unknown format of %s", ErrNoArchives, path)
+		}
+
+		return []ArchiveSet{set}, nil
+	}
+
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading dir: %s: %w", path, err)
+	}
+
+	sets := make([]ArchiveSet, 0)
+
+	for _, de := range dirEntries {
+		if de.IsDir() || isContinuationVolume(de.Name()) {
+			continue
+		}
+
+		set, err := archiveSetOf(filepath.Join(path, de.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		if set.Format != FormatUnknown {
+			sets = append(sets, set)
+		}
+	}
+
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("%w in %s", ErrNoArchives, path)
+	}
+
+	return sets, nil
+}
+
+// isContinuationVolume - reports whether the file is a volume other than the first one, i.e. 'name.7z.002'.
+func isContinuationVolume(name string) bool {
+	m := volumeSuffixRe.FindStringSubmatch(name)
+
+	return m != nil && strings.TrimLeft(m[1], "0") != "1"
+}
+
+// archiveSetOf - collects the rest of the volumes if the path is the first one, and detects format of the archive.
+// Volumes are read as a single stream, as the first one may be too small to hold a whole (decompressed) tar header.
+func archiveSetOf(path string) (ArchiveSet, error) {
+	set := ArchiveSet{Volumes: []string{path}}
+
+	if m := volumeSuffixRe.FindStringSubmatch(path); m != nil {
+		base, width := strings.TrimSuffix(path, m[0]), len(m[1])
+
+		for i := 2; ; i++ {
+			next := fmt.Sprintf("%s.%0*d", base, width, i)
+			if _, err := os.Stat(next); err != nil {
+				break
+			}
+
+			set.Volumes = append(set.Volumes, next)
+		}
+	}
+
+	r, closeAll, err := openVolumes(set)
+	if err != nil {
+		return set, err
+	}
+
+	defer closeAll()
+
+	set.Format, err = DetectFormat(r)
+	if err != nil {
+		return set, fmt.Errorf("failed detecting format of %s: %w", path, err)
+	}
+
+	return set, nil
+}
@@ -0,0 +1,250 @@
+package ax
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTarball - builds tarball of the files, gzip compressed if requested. Content of each file is its own name, while
+// a trailing '/' marks a directory, 'name -> target' a symlink and 'name => target' a hard link.
+func (s *Suite) testTarball(compress bool, files ...string) []byte {
+	var (
+		buf bytes.Buffer
+		w   io.Writer = &buf
+		gw            = gzip.NewWriter(&buf)
+	)
+
+	if compress {
+		w = gw
+	}
+
+	tw := tar.NewWriter(w)
+
+	for _, name := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Typeflag: tar.TypeReg, Size: int64(len(name))}
+
+		switch {
+		case strings.HasSuffix(name, "/"):
+			hdr = &tar.Header{Name: name, Mode: 0o755, Typeflag: tar.TypeDir}
+		case strings.Contains(name, " -> "):
+			parts := strings.SplitN(name, " -> ", 2)
+			hdr = &tar.Header{Name: parts[0], Linkname: parts[1], Mode: 0o777, Typeflag: tar.TypeSymlink}
+		case strings.Contains(name, " => "):
+			parts := strings.SplitN(name, " => ", 2)
+			hdr = &tar.Header{Name: parts[0], Linkname: parts[1], Mode: 0o644, Typeflag: tar.TypeLink}
+		}
+
+		s.Require().Nil(tw.WriteHeader(hdr))
+
+		_, err := tw.Write([]byte(name)[:hdr.Size])
+		s.Require().Nil(err)
+	}
+
+	s.Require().Nil(tw.Close())
+
+	if compress {
+		s.Require().Nil(gw.Close())
+	}
+
+	return buf.Bytes()
+}
+
+// writeVolumes - splits data into the given number of volumes, named 'name.001', 'name.002'...
+func (s *Suite) writeVolumes(dir, name string, data []byte, volumes int) {
+	size := len(data)/volumes + 1
+
+	for i := 0; i < volumes; i++ {
+		start, end := i*size, (i+1)*size
+		if end > len(data) {
+			end = len(data)
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("%s.%03d", name, i+1))
+		s.Require().Nil(os.WriteFile(path, data[start:end], 0o600))
+	}
+}
+
+func (s *Suite) TestUnitDetectFormat() {
+	testCases := []TestCase{
+		{
+			Name: "success magic bytes",
+			Assert: func() {
+				var gz bytes.Buffer
+
+				gw := gzip.NewWriter(&gz)
+				_, _ = gw.Write([]byte("not a tarball"))
+				_ = gw.Close()
+
+				for data, expected := range map[string]Format{
+					"7z\xBC\xAF\x27\x1C\x00\x04":          Format7z,
+					"PK\x03\x04\x14\x00":                  FormatZip,
+					"Rar!\x1A\x07\x01\x00":                FormatRar,
+					"\xFD7zXZ\x00\x00":                    FormatXz,
+					"BZh91AY&SY":                          FormatBzip2,
+					"\x28\xB5\x2F\xFD":                    FormatZstd,
+					gz.String():                           FormatGzip,
+					string(s.testTarball(false, "a.txt")): FormatTar,
+					string(s.testTarball(true, "a.txt")):  FormatTarGzip,
+					"Salted__encrypted volume":            FormatUnknown,
+					"":                                    FormatUnknown,
+				} {
+					format, err := DetectFormat(bytes.NewReader([]byte(data)))
+
+					assert.Nil(s.T(), err)
+					assert.Equal(s.T(), expected, format)
+				}
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitFindArchives() {
+	testCases := []TestCase{
+		{
+			Name: "success volumes grouped and unknown files skipped",
+			Assert: func() {
+				dir := s.T().TempDir()
+				s.writeVolumes(dir, "etc.tar.gz", s.testTarball(true, "etc/hosts", "etc/fstab"), 3)
+				s.Require().Nil(os.WriteFile(filepath.Join(dir, "b.zip"), []byte("PK\x05\x06"), 0o600))
+				s.Require().Nil(os.WriteFile(filepath.Join(dir, "b.7z.001.enc.0"), []byte("Salted__"), 0o600))
+				s.Require().Nil(os.Mkdir(filepath.Join(dir, "extracted"), 0o700))
+
+				sets, err := FindArchives(dir)
+
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), []ArchiveSet{
+					{Volumes: []string{filepath.Join(dir, "b.zip")}, Format: FormatZip},
+					{Volumes: []string{
+						filepath.Join(dir, "etc.tar.gz.001"),
+						filepath.Join(dir, "etc.tar.gz.002"),
+						filepath.Join(dir, "etc.tar.gz.003"),
+					}, Format: FormatTarGzip},
+				}, sets)
+
+				sets, err = FindArchives(filepath.Join(dir, "b.zip"))
+
+				assert.Nil(s.T(), err)
+				assert.Len(s.T(), sets, 1)
+			},
+		},
+		{
+			Name: "err no archives",
+			Assert: func() {
+				dir := s.T().TempDir()
+				s.Require().Nil(os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o600))
+
+				_, err := FindArchives(dir)
+				assert.ErrorIs(s.T(), err, ErrNoArchives)
+
+				_, err = FindArchives(filepath.Join(dir, "notes.txt"))
+				assert.ErrorIs(s.T(), err, ErrNoArchives)
+			},
+		},
+		{
+			Name: "err path does not exist",
+			Assert: func() {
+				_, err := FindArchives(filepath.Join(s.T().TempDir(), "missing"))
+
+				assert.NotNil(s.T(), err)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitNativeBackend() {
+	var archiveDir, outDir string
+
+	prepare := func() {
+		archiveDir, outDir = s.T().TempDir(), s.T().TempDir()
+		s.writeVolumes(archiveDir, "etc.tar.gz", s.testTarball(true, "etc/", "etc/hosts", "etc/nginx/nginx.conf"), 2)
+		s.Require().Nil(os.WriteFile(filepath.Join(archiveDir, "logs.tar"), s.testTarball(false, "app.log"), 0o600))
+	}
+
+	testCases := []TestCase{
+		{
+			Name:          "success list split and single tarballs",
+			PreRequisites: prepare,
+			Assert: func() {
+				entries, err := List(&ExtractConfig{ArchivePath: archiveDir})
+
+				assert.Nil(s.T(), err)
+				assert.Len(s.T(), entries, 4)
+				assert.Equal(s.T(), "etc", entries[0].Path)
+				assert.True(s.T(), entries[0].IsDir)
+				assert.Equal(s.T(), uint64(len("etc/hosts")), entries[1].Size)
+			},
+		},
+		{
+			Name:          "success extract everything",
+			PreRequisites: prepare,
+			Assert: func() {
+				err := Extract(&ExtractConfig{ArchivePath: archiveDir, OutputDir: outDir})
+
+				assert.Nil(s.T(), err)
+				assert.FileExists(s.T(), filepath.Join(outDir, "etc", "nginx", "nginx.conf"))
+				assert.FileExists(s.T(), filepath.Join(outDir, "app.log"))
+
+				content, err := os.ReadFile(filepath.Join(outDir, "etc", "hosts"))
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), "etc/hosts", string(content))
+			},
+		},
+		{
+			Name:          "success extract selected files of a single archive",
+			PreRequisites: prepare,
+			Assert: func() {
+				err := Extract(&ExtractConfig{
+					ArchivePath: filepath.Join(archiveDir, "etc.tar.gz.001"),
+					OutputDir:   outDir,
+					Files:       []string{"*.conf"},
+				})
+
+				assert.Nil(s.T(), err)
+				assert.FileExists(s.T(), filepath.Join(outDir, "etc", "nginx", "nginx.conf"))
+				assert.NoFileExists(s.T(), filepath.Join(outDir, "etc", "hosts"))
+			},
+		},
+		{
+			Name:          "err no matching files",
+			PreRequisites: prepare,
+			Assert: func() {
+				err := Extract(&ExtractConfig{ArchivePath: archiveDir, OutputDir: outDir, Files: []string{"*.pem"}})
+
+				assert.ErrorIs(s.T(), err, ErrNoMatchingEntries)
+			},
+		},
+		{
+			Name: "success test and err corrupted volume",
+			PreRequisites: func() {
+				prepare()
+				s.Require().Nil(os.Remove(filepath.Join(archiveDir, "logs.tar")))
+			},
+			Assert: func() {
+				assert.Nil(s.T(), TestArchive(&ExtractConfig{ArchivePath: archiveDir}))
+
+				last := filepath.Join(archiveDir, "etc.tar.gz.002")
+				data, err := os.ReadFile(last)
+				s.Require().Nil(err)
+
+				data[len(data)-5] ^= 0xFF
+				s.Require().Nil(os.WriteFile(last, data, 0o600))
+
+				assert.ErrorIs(s.T(), TestArchive(&ExtractConfig{ArchivePath: archiveDir}), ErrIntegrity)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
@@ -0,0 +1,459 @@
+package ax
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Names of the external commands, on top of cmd7z and cmdGit.
+const (
+	// cmd7zz - 7-Zip 21+ for Linux and macOS.
+	cmd7zz = "7zz"
+	// cmd7za - standalone 7zip, supporting only the 7z, zip and a few other formats.
+	cmd7za = "7za"
+
+	cmdGitLFS = "git-lfs"
+)
+
+// Flavors of 7zip.
+const (
+	// FlavorP7zip - p7zip, the unofficial port of 7-Zip 16.02 and older, which is no longer maintained.
+	FlavorP7zip = "p7zip"
+
+	// Flavor7Zip - 7-Zip by Igor Pavlov, i.e. 7-Zip 21+ (7zz) for Linux and macOS.
+	Flavor7Zip = "7-Zip"
+)
+
+// Statuses of the environment checks.
+const (
+	CheckOK   CheckStatus = "ok"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+// Names of the environment checks.
+const (
+	CheckSevenZip  = "7z"
+	CheckGit       = "git"
+	CheckGitLFS    = "git-lfs"
+	CheckSSHAgent  = "ssh-agent"
+	CheckFreeSpace = "free space"
+	CheckWritable  = "write permissions"
+)
+
+const (
+	envSSHAuthSock  = "SSH_AUTH_SOCK"
+	sevenZipInfoArg = "i"
+	min7zipVersion  = "16.02"
+
+	// minFreeSpace - free space at the output path below which a warning is reported.
+	minFreeSpace = uint64(1 << 30)
+)
+
+// ErrEnvironment - environment check failed, other than due to a missing dependency.
+var ErrEnvironment = errors.New("environment check failed")
+
+// CheckStatus - represents the outcome of an environment check.
+type CheckStatus string
+
+// Check - represents the outcome of a single environment check, with the fix if it hasn't passed.
+type Check struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail"`
+	Fix    string      `json:"fix,omitempty"`
+}
+
+// SevenZip - represents the detected 7zip binary.
+type SevenZip struct {
+	// Binary - name of the binary, i.e. '7z' or '7zz', which ax executes.
+	Binary string `json:"binary"`
+
+	// Path - where the binary has been found in PATH.
+	Path string `json:"path"`
+
+	// Flavor - either FlavorP7zip or Flavor7Zip.
+	Flavor string `json:"flavor"`
+
+	// Version - i.e. '23.01'.
+	Version string `json:"version"`
+
+	// Methods - which of MethodLZMA2, MethodPPMd, MethodBZip2 and MethodZstd the binary supports.
+	Methods []string `json:"methods"`
+}
+
+// Tool - represents a detected external command.
+type Tool struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+// EnvironmentConfig - represents what CheckEnvironment checks the environment for.
+type EnvironmentConfig struct {
+	// OutputPath - where the archive(s) are going to be stored, checked for free space and write permissions.
+	// Defaults to the current working dir.
+	OutputPath string
+
+	// Repo - remote GIT Repository, for which the SSH agent is checked, unless it's accessed over HTTPS.
+	Repo string
+}
+
+// Environment - represents the outcome of CheckEnvironment.
+type Environment struct {
+	SevenZip *SevenZip `json:"7z,omitempty"`
+	Git      *Tool     `json:"git,omitempty"`
+	GitLFS   *Tool     `json:"git_lfs,omitempty"`
+
+	// FreeSpace - free space at the OutputPath in bytes, or 0 if it couldn't be determined.
+	FreeSpace uint64 `json:"free_space"`
+
+	// Checks - outcome of each check, in the order they've been run.
+	Checks []Check `json:"checks"`
+}
+
+// Err - returns an error listing the failed checks, or nil if none has failed. It matches ErrMissingDependency if 7zip
+// or git is missing, and ErrEnvironment otherwise.
+func (e *Environment) Err() error {
+	failed := make([]string, 0)
+	kind := ErrEnvironment
+
+	for _, c := range e.Checks {
+		if c.Status != CheckFail {
+			continue
+		}
+
+		failed = append(failed, c.Name)
+
+		if c.Name == CheckSevenZip || c.Name == CheckGit {
+			kind = ErrMissingDependency
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", kind, strings.Join(failed, ", "))
+}
+
+// CheckEnvironment - checks that 7zip and git are installed and recent enough, and that the archive(s) can be written
+// to the output path. Every check is run, so that all of the problems are reported at once.
+func CheckEnvironment(conf *EnvironmentConfig) *Environment {
+	env := &Environment{}
+
+	env.check7zip()
+	env.checkGit()
+	env.checkGitLFS()
+
+	if conf.Repo == "" || isSSHRemote(conf.Repo) {
+		env.checkSSHAgent()
+	}
+
+	out := conf.OutputPath
+	if out == "" {
+		out = "."
+	}
+
+	env.checkOutputPath(out)
+
+	return env
+}
+
+func (e *Environment) add(name string, status CheckStatus, detail, fix string) {
+	e.Checks = append(e.Checks, Check{Name: name, Status: status, Detail: detail, Fix: fix})
+}
+
+func (e *Environment) check7zip() {
+	sz, err := DetectSevenZip()
+	if err != nil {
+		e.add(CheckSevenZip, CheckFail, err.Error(),
+			"Install 7-Zip, i.e. 'apt install 7zip', 'dnf install p7zip p7zip-plugins' or 'brew install sevenzip'")
+
+		return
+	}
+
+	e.SevenZip = sz
+	detail := fmt.Sprintf("%s %s (%s), methods: %s", sz.Flavor, sz.Version, sz.Path, strings.Join(sz.Methods, ", "))
+
+	if compareVersions(sz.Version, min7zipVersion) < 0 {
+		e.add(CheckSevenZip, CheckWarn, detail,
+			fmt.Sprintf("Upgrade to 7-Zip %s or newer, older versions fail on some of the switches ax uses", min7zipVersion))
+
+		return
+	}
+
+	e.add(CheckSevenZip, CheckOK, detail, "")
+}
+
+func (e *Environment) checkGit() {
+	tool, err := detectTool(cmdGit, "--version")
+	if err != nil {
+		e.add(CheckGit, CheckFail, err.Error(), "Install git, i.e. 'apt install git' or 'brew install git'")
+
+		return
+	}
+
+	e.Git = tool
+	e.add(CheckGit, CheckOK, fmt.Sprintf("%s (%s)", tool.Version, tool.Path), "")
+}
+
+func (e *Environment) checkGitLFS() {
+	tool, err := detectTool(cmdGitLFS, "version")
+	if err != nil {
+		e.add(CheckGitLFS, CheckWarn, err.Error(),
+			"Install git-lfs if the remote limits the file size below the volume size (i.e. 100MB on GitHub)")
+
+		return
+	}
+
+	e.GitLFS = tool
+	e.add(CheckGitLFS, CheckOK, fmt.Sprintf("%s (%s)", tool.Version, tool.Path), "")
+}
+
+func (e *Environment) checkSSHAgent() {
+	fix := "Start the agent and add the key of the remote: eval \"$(ssh-agent)\" && ssh-add"
+
+	sock := os.Getenv(envSSHAuthSock)
+	if sock == "" {
+		e.add(CheckSSHAgent, CheckWarn, envSSHAuthSock+" isn't set", fix)
+
+		return
+	}
+
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		e.add(CheckSSHAgent, CheckWarn, fmt.Sprintf("can't connect to %s: %v", sock, err), fix)
+
+		return
+	}
+
+	_ = conn.Close()
+
+	e.add(CheckSSHAgent, CheckOK, "running at "+sock, "")
+}
+
+func (e *Environment) checkOutputPath(out string) {
+	dir, err := existingDir(out)
+	if err != nil {
+		e.add(CheckWritable, CheckFail, err.Error(), "Choose another output path with -out")
+
+		return
+	}
+
+	f, err := os.CreateTemp(dir, ".ax-doctor-*")
+	if err != nil {
+		e.add(CheckWritable, CheckFail, err.Error(),
+			fmt.Sprintf("Choose another output path with -out, or grant write permissions on %s", dir))
+	} else {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		e.add(CheckWritable, CheckOK, "can write to "+dir, "")
+	}
+
+	free, err := freeSpace(dir)
+	if err != nil {
+		e.add(CheckFreeSpace, CheckWarn, err.Error(), "")
+
+		return
+	}
+
+	e.FreeSpace = free
+	detail := fmt.Sprintf("%s free at %s", HumanSize(free), dir)
+
+	if free < minFreeSpace {
+		e.add(CheckFreeSpace, CheckWarn, detail,
+			"Free up space, or choose another output path with -out, as it holds both the archive(s) and their "+
+				"encrypted copies")
+
+		return
+	}
+
+	e.add(CheckFreeSpace, CheckOK, detail, "")
+}
+
+// existingDir - returns the path, or its deepest parent which exists, as the missing directories are created by ax.
+func existingDir(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", fmt.Errorf("failed resolving %s: %w", p, err)
+	}
+
+	for {
+		info, err := os.Stat(abs)
+		if err == nil {
+			if !info.IsDir() {
+				return "", fmt.Errorf("%s isn't a directory", abs)
+			}
+
+			return abs, nil
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", fmt.Errorf("failed getting stat of %s: %w", p, err)
+		}
+
+		abs = parent
+	}
+}
+
+// DetectSevenZip - returns the first 7zip binary found in PATH ('7z', '7zz' or '7za'), along with its version and
+// supported methods, or ErrMissingDependency if there's none.
+func DetectSevenZip() (*SevenZip, error) {
+	for _, name := range sevenZipBinaries() {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+
+		out, err := exec.Command(path, sevenZipInfoArg).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed getting info of %s: %w", path, err)
+		}
+
+		sz := parseSevenZipInfo(string(out))
+		sz.Binary, sz.Path = name, path
+
+		return sz, nil
+	}
+
+	return nil, fmt.Errorf("%w: none of %s found in PATH", ErrMissingDependency, strings.Join(sevenZipBinaries(), ", "))
+}
+
+func sevenZipBinaries() []string {
+	return []string{cmd7z, cmd7zz, cmd7za}
+}
+
+// isSevenZip - reports whether the command is one of the 7zip binaries.
+func isSevenZip(cmd string) bool {
+	for _, name := range sevenZipBinaries() {
+		if cmd == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+//nolint:gochecknoglobals // PATH doesn't change while ax is running, so the lookup is done once.
+var sevenZipLookup struct {
+	once sync.Once
+	name string
+}
+
+// sevenZipCmd - returns name of the 7zip binary which ax executes: the first one found in PATH, or '7z' if there's
+// none, so that executing it fails with ErrMissingDependency.
+func sevenZipCmd() string {
+	sevenZipLookup.once.Do(func() {
+		sevenZipLookup.name = cmd7z
+
+		for _, name := range sevenZipBinaries() {
+			if _, err := exec.LookPath(name); err == nil {
+				sevenZipLookup.name = name
+
+				break
+			}
+		}
+	})
+
+	return sevenZipLookup.name
+}
+
+// versionRe - matches the version, i.e. '16.02' in '7-Zip [64] 16.02' or '2.39.2' in 'git version 2.39.2'.
+var versionRe = regexp.MustCompile(`\d+(\.\d+)+`) //nolint:gochecknoglobals // Compiled once.
+
+// parseSevenZipInfo - parses the output of '7z i', which starts with the version and lists the supported codecs.
+func parseSevenZipInfo(out string) *SevenZip {
+	sz := &SevenZip{Flavor: Flavor7Zip, Methods: make([]string, 0)}
+
+	if strings.Contains(out, FlavorP7zip) {
+		sz.Flavor = FlavorP7zip
+	}
+
+	codecs := make(map[string]bool)
+	inCodecs := false
+
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case sz.Version == "" && strings.HasPrefix(line, "7-Zip"):
+			sz.Version = versionRe.FindString(line)
+		case strings.HasSuffix(line, ":"):
+			inCodecs = line == "Codecs:"
+		case inCodecs && line != "":
+			fields := strings.Fields(line)
+			codecs[strings.ToUpper(fields[len(fields)-1])] = true
+		}
+	}
+
+	for _, m := range []string{MethodLZMA2, MethodPPMd, MethodBZip2, MethodZstd} {
+		if codecs[strings.ToUpper(m)] {
+			sz.Methods = append(sz.Methods, m)
+		}
+	}
+
+	return sz
+}
+
+// detectTool - returns the path and version of the command, as printed by it with the version args.
+func detectTool(cmd string, versionArgs ...string) (*Tool, error) {
+	path, err := exec.LookPath(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s not found in PATH", ErrMissingDependency, cmd)
+	}
+
+	out, err := exec.Command(path, versionArgs...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed getting version of %s: %w", path, err)
+	}
+
+	return &Tool{Path: path, Version: versionRe.FindString(string(out))}, nil
+}
+
+// compareVersions - compares dot separated versions numerically, returning -1, 0 or 1. Unknown version is the oldest.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// isSSHRemote - reports whether the GIT Repository is accessed over SSH, i.e. 'git@github.com:USER/REPO.git' or
+// 'ssh://git@host/repo.git'.
+func isSSHRemote(repo string) bool {
+	if strings.Contains(repo, "://") {
+		return strings.HasPrefix(repo, "ssh://") || strings.HasPrefix(repo, "git+ssh://")
+	}
+
+	colon, slash := strings.Index(repo, ":"), strings.Index(repo, "/")
+
+	return colon > 0 && (slash < 0 || colon < slash)
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package ax
+
+import "errors"
+
+// freeSpace - returns an error, as free space is determined only on Linux and macOS.
+func freeSpace(string) (uint64, error) {
+	return 0, errors.New("free space can't be determined on this platform")
+}
@@ -0,0 +1,193 @@
+package ax
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testP7zipInfo = `
+7-Zip [64] 16.02 : Copyright (c) 1999-2016 Igor Pavlov : 2016-05-21
+p7zip Version 16.02 (locale=C,Utf16=off,HugeFiles=on,64 bits,8 CPUs x64)
+
+Formats:
+ ...   7z       7z            7z '7z..'
+
+Codecs:
+ 0 ED   40202 BZip2
+ 0 ED   30101 LZMA
+ 0 ED      21 LZMA2
+ 0 ED   30401 PPMD
+
+Hashers:
+   4        1 CRC32
+`
+	test7zzInfo = `
+7-Zip (z) 23.01 (x64) : Copyright (c) 1999-2023 Igor Pavlov : 2023-06-20
+ 64-bit locale=C.UTF-8 Threads:8 OPEN_MAX:1024
+
+Codecs:
+ 0 ED   40202 BZip2
+ 0 ED      21 LZMA2
+ 0 ED 4F71101 ZSTD
+`
+)
+
+// fakeCommands - replaces PATH with a directory holding scripts, which print the output under their names.
+func (s *Suite) fakeCommands(outputs map[string]string) {
+	dir := s.T().TempDir()
+
+	for name, out := range outputs {
+		script := "#!/bin/sh\n/bin/cat <<'EOF'\n" + out + "\nEOF\n"
+		s.Require().Nil(os.WriteFile(filepath.Join(dir, name), []byte(script), 0o700)) //nolint:gosec // Executable.
+	}
+
+	path := os.Getenv("PATH")
+	s.Require().Nil(os.Setenv("PATH", dir))
+	s.T().Cleanup(func() { _ = os.Setenv("PATH", path) })
+}
+
+func (s *Suite) TestUnitDetectSevenZip() {
+	testCases := []TestCase{
+		{
+			Name: "success p7zip",
+			Assert: func() {
+				sz := parseSevenZipInfo(testP7zipInfo)
+
+				assert.Equal(s.T(), FlavorP7zip, sz.Flavor)
+				assert.Equal(s.T(), "16.02", sz.Version)
+				assert.Equal(s.T(), []string{MethodLZMA2, MethodPPMd, MethodBZip2}, sz.Methods)
+			},
+		},
+		{
+			Name:          "success 7zz is picked, if 7z is missing",
+			PreRequisites: func() { s.fakeCommands(map[string]string{cmd7zz: test7zzInfo, cmd7za: testP7zipInfo}) },
+			Assert: func() {
+				sz, err := DetectSevenZip()
+
+				s.Require().Nil(err)
+				assert.Equal(s.T(), cmd7zz, sz.Binary)
+				assert.Equal(s.T(), Flavor7Zip, sz.Flavor)
+				assert.Equal(s.T(), "23.01", sz.Version)
+				assert.Equal(s.T(), []string{MethodLZMA2, MethodBZip2, MethodZstd}, sz.Methods)
+			},
+		},
+		{
+			Name:          "err none found",
+			PreRequisites: func() { s.fakeCommands(nil) },
+			Assert: func() {
+				_, err := DetectSevenZip()
+
+				assert.ErrorIs(s.T(), err, ErrMissingDependency)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitCheckEnvironment() {
+	statusOf := func(env *Environment) map[string]CheckStatus {
+		statuses := make(map[string]CheckStatus)
+		for _, c := range env.Checks {
+			statuses[c.Name] = c.Status
+		}
+
+		return statuses
+	}
+
+	testCases := []TestCase{
+		{
+			Name: "success everything installed",
+			PreRequisites: func() {
+				s.fakeCommands(map[string]string{
+					cmd7z:     testP7zipInfo,
+					cmdGit:    "git version 2.39.2",
+					cmdGitLFS: "git-lfs/3.3.0 (GitHub; linux amd64; go 1.19.8)",
+				})
+			},
+			Assert: func() {
+				out := filepath.Join(s.T().TempDir(), "not", "created", "yet")
+				env := CheckEnvironment(&EnvironmentConfig{OutputPath: out, Repo: "https://github.com/USER/backup.git"})
+
+				assert.Nil(s.T(), env.Err())
+				assert.Equal(s.T(), "2.39.2", env.Git.Version)
+				assert.Equal(s.T(), "3.3.0", env.GitLFS.Version)
+				assert.Equal(s.T(), "16.02", env.SevenZip.Version)
+				assert.NotContains(s.T(), statusOf(env), CheckSSHAgent)
+				assert.Equal(s.T(), CheckOK, statusOf(env)[CheckWritable])
+				assert.NotZero(s.T(), env.FreeSpace)
+				assert.NoDirExists(s.T(), out)
+			},
+		},
+		{
+			Name:          "err missing dependencies and output path",
+			PreRequisites: func() { s.fakeCommands(map[string]string{cmd7za: "7-Zip (a) 9.20"}) },
+			Assert: func() {
+				file := filepath.Join(s.T().TempDir(), "file")
+				s.Require().Nil(os.WriteFile(file, nil, 0o600))
+
+				env := CheckEnvironment(&EnvironmentConfig{OutputPath: filepath.Join(file, "out")})
+				statuses := statusOf(env)
+
+				assert.ErrorIs(s.T(), env.Err(), ErrMissingDependency)
+				assert.Equal(s.T(), CheckWarn, statuses[CheckSevenZip])
+				assert.Equal(s.T(), CheckFail, statuses[CheckGit])
+				assert.Equal(s.T(), CheckWarn, statuses[CheckGitLFS])
+				assert.Equal(s.T(), CheckFail, statuses[CheckWritable])
+				assert.Contains(s.T(), statuses, CheckSSHAgent)
+
+				for _, c := range env.Checks {
+					if c.Status != CheckOK {
+						assert.NotEmpty(s.T(), c.Fix, c.Name)
+					}
+				}
+
+				assert.True(s.T(), strings.HasSuffix(env.Err().Error(), "git, write permissions"))
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitEnvironmentHelpers() {
+	testCases := []TestCase{
+		{
+			Name: "success versions",
+			Assert: func() {
+				assert.Equal(s.T(), -1, compareVersions("9.20", "16.02"))
+				assert.Equal(s.T(), 1, compareVersions("23.01", "16.02"))
+				assert.Equal(s.T(), 0, compareVersions("16.02", "16.02"))
+				assert.Equal(s.T(), -1, compareVersions("", "16.02"))
+			},
+		},
+		{
+			Name: "success ssh remotes",
+			Assert: func() {
+				for repo, expected := range map[string]bool{
+					"git@github.com:USER/backup.git":       true,
+					"ssh://git@github.com/USER/backup.git": true,
+					"https://github.com/USER/backup.git":   false,
+					"/srv/git/backup.git":                  false,
+					"../backup.git":                        false,
+				} {
+					assert.Equal(s.T(), expected, isSSHRemote(repo), repo)
+				}
+			},
+		},
+		{
+			Name: "success human sizes",
+			Assert: func() {
+				assert.Equal(s.T(), "512 B", HumanSize(512))
+				assert.Equal(s.T(), "1.5 GiB", HumanSize(3<<29))
+				assert.Equal(s.T(), "64.0 MiB", HumanSize(64<<20))
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
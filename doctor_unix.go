@@ -0,0 +1,22 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package ax
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// freeSpace - returns the number of bytes available to unprivileged users on the file system holding the dir.
+func freeSpace(dir string) (uint64, error) {
+	var st unix.Statfs_t
+
+	err := unix.Statfs(dir, &st)
+	if err != nil {
+		return 0, fmt.Errorf("failed getting free space of %s: %w", dir, err)
+	}
+
+	return uint64(st.Bavail) * uint64(st.Bsize), nil //nolint:unconvert // Types differ between the platforms.
+}
@@ -1,64 +1,126 @@
 package ax
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 const (
 	encFilePerm = 0o600
+
+	// encryptedFileMarker - marks names of the encrypted files, i.e. 'name.7z.001.enc.0'.
+	encryptedFileMarker = ".enc."
 )
 
 // FileEncryption - encrypt a file.
+//
+// It panics on failure, DefaultFileEncryption should be preferred as it returns the error instead.
 func FileEncryption(bytKey []byte, inFileName, encFileName string) {
-	inFile, err := os.Open(inFileName)
+	err := encryptFile(bytKey, inFileName, encFileName)
 	if err != nil {
 		panic(err)
 	}
+}
 
-	defer inFile.Close()
+func encryptFile(bytKey []byte, inFileName, encFileName string) error {
+	return encryptFileContext(context.Background(), bytKey, inFileName, encFileName)
+}
 
-	block, err := aes.NewCipher(bytKey)
+// encryptFileContext - encrypts the file, stopping once the context is done. The encrypted file is written under its
+// name only once it's complete, see writePartFile.
+func encryptFileContext(ctx context.Context, bytKey []byte, inFileName, encFileName string) error {
+	inFile, err := os.Open(inFileName)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed opening file for encryption: %w", err)
 	}
 
-	iv := make([]byte, aes.BlockSize)
-	stream := cipher.NewOFB(block, iv)
+	defer inFile.Close()
 
-	outFile, err := os.OpenFile(encFileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, encFilePerm)
+	block, err := aes.NewCipher(bytKey)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed creating cipher: %w", err)
 	}
 
-	defer outFile.Close()
+	iv := make([]byte, aes.BlockSize)
+	stream := cipher.NewOFB(block, iv)
 
-	writer := &cipher.StreamWriter{S: stream, W: outFile}
+	err = writePartFile(encFileName, encFilePerm, func(w io.Writer) error {
+		_, err := io.Copy(&cipher.StreamWriter{S: stream, W: w}, &contextReader{ctx: ctx, r: inFile})
 
-	_, err = io.Copy(writer, inFile)
+		return err
+	})
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed encrypting file [%s]: %w", inFileName, err)
 	}
+
+	return nil
 }
 
 // DefaultFileEncryption - represents basic usage of the FileEncryption func.
+// Progress is reported to the DefaultLogger.
 func DefaultFileEncryption(passwd []byte, fileList []string) error {
+	return DefaultFileEncryptionWithContext(context.Background(), passwd, fileList)
+}
+
+// DefaultFileEncryptionWithContext - same as DefaultFileEncryption, but it stops once the context is done, returning
+// its error.
+//
+// Each file is replaced by its encrypted counterpart only once that one is complete, so an interrupted run leaves every
+// file either as it was, or encrypted. Files which are already encrypted (or left unfinished by a killed run) are
+// skipped, so running it again over the same files resumes the encryption.
+func DefaultFileEncryptionWithContext(ctx context.Context, passwd []byte, fileList []string) error {
+	return DefaultFileEncryptionWithLogger(ctx, nil, passwd, fileList)
+}
+
+// DefaultFileEncryptionWithLogger - same as DefaultFileEncryptionWithContext, but progress is reported to the log,
+// or to the DefaultLogger if it's nil.
+func DefaultFileEncryptionWithLogger(ctx context.Context, log Logger, passwd []byte, fileList []string) error {
+	started := time.Now()
+	log = loggerOr(log)
 	key := sha256.Sum256(passwd)
+	encrypted := 0
+
+	var bytesOut uint64
 
 	for i, file := range fileList {
-		FileEncryption(key[:], file, fmt.Sprintf("%s.enc.%d", file, i))
+		if strings.Contains(filepath.Base(file), encryptedFileMarker) || isPartFile(file) {
+			log.Debug("skipped already encrypted file", "file", file)
+
+			continue
+		}
 
-		err := os.Remove(file)
+		err := ctx.Err()
+		if err != nil {
+			return fmt.Errorf("encryption interrupted: %w", err)
+		}
+
+		out := fmt.Sprintf("%s%s%d", file, encryptedFileMarker, i)
+
+		err = encryptFileContext(ctx, key[:], file, out)
+		if err != nil {
+			return err
+		}
+
+		bytesOut += filesSize([]string{out})
+
+		// Plaintext is overwritten before it's removed, so that it's not simply left on the disk.
+		err = removeWiped(file)
 		if err != nil {
 			return fmt.Errorf("failed removing previous file: %w", err)
 		}
+
+		encrypted++
 	}
 
-	printStdoutLn("\nArchive(s) encrypted!")
+	log.Info(EventEncrypted, "count", encrypted, AttrDuration, time.Since(started), AttrBytesOut, bytesOut)
 
 	return nil
 }
@@ -0,0 +1,180 @@
+package ax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var (
+	// ErrMissingDependency - external command (7z, git) required by ax couldn't be found.
+	ErrMissingDependency = errors.New("missing dependency")
+
+	// ErrWrongPassword - archive couldn't be opened with the provided password.
+	ErrWrongPassword = errors.New("wrong password")
+
+	// ErrIntegrity - archive is damaged, incomplete or isn't an archive at all.
+	ErrIntegrity = errors.New("archive integrity failure")
+
+	// ErrRemote - communication with the remote GIT Repository failed.
+	ErrRemote = errors.New("remote repository failure")
+)
+
+const (
+	passwordArgPrefix = "-p"
+	redactedSecret    = "***"
+	redactedPassword  = passwordArgPrefix + redactedSecret
+)
+
+// CmdError - represents failure of an external command executed by ax.
+//
+// errors.Is can be used to check its Kind against ErrMissingDependency, ErrWrongPassword and ErrIntegrity, or against
+// context.Canceled and context.DeadlineExceeded, if the command has been killed as its context was done.
+type CmdError struct {
+	// Cmd - name of the executed command.
+	Cmd string
+
+	// Args - arguments of the executed command, with passwords redacted.
+	Args []string
+
+	// Stderr - last line which the command printed to its stderr, if any.
+	Stderr string
+
+	// Kind - one of the sentinel errors the failure has been classified as, or nil.
+	Kind error
+
+	// Err - underlying error returned by exec.
+	Err error
+}
+
+func (e *CmdError) Error() string {
+	msg := fmt.Sprintf("%s: failed executing the command with args%v: %v", e.Cmd, e.Args, e.Err)
+	if e.Stderr != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Stderr)
+	}
+
+	return msg
+}
+
+// Unwrap - returns the underlying exec error.
+func (e *CmdError) Unwrap() error {
+	return e.Err
+}
+
+// Is - reports whether the failure has been classified as the target sentinel error.
+func (e *CmdError) Is(target error) bool {
+	return e.Kind != nil && errors.Is(e.Kind, target)
+}
+
+// newCmdError - builds CmdError, classifying the failure based on the exec error and stderr of the command.
+func newCmdError(cmd string, args []string, err error) *CmdError {
+	ce := &CmdError{
+		Cmd:  cmd,
+		Args: redactArgs(args),
+		Err:  err,
+	}
+
+	var exitErr *exec.ExitError
+
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		ce.Kind = ErrMissingDependency
+	case errors.As(err, &exitErr):
+		stderr := string(exitErr.Stderr)
+		ce.Stderr = lastLine(stderr)
+
+		if isSevenZip(cmd) {
+			ce.Kind = classify7zStderr(stderr)
+		}
+	}
+
+	return ce
+}
+
+// newCmdErrorContext - same as newCmdError, but the failure is classified as the error of the context, if it's done,
+// as the command has been killed then.
+func newCmdErrorContext(ctx context.Context, cmd string, args []string, err error) *CmdError {
+	ce := newCmdError(cmd, args, err)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		ce.Kind = ctxErr
+	}
+
+	return ce
+}
+
+// remoteError - returns the error of the failed git command communicating with the remote, prefixed with the message.
+// Unless it's been classified already (i.e. as ErrMissingDependency), *CmdError is classified as ErrRemote, so that
+// both errors.Is and errors.As can be used on the returned error.
+func remoteError(msg string, err error) error {
+	var ce *CmdError
+	if !errors.As(err, &ce) {
+		return fmt.Errorf("%w: %s: %v", ErrRemote, msg, err)
+	}
+
+	if ce.Kind == nil {
+		ce.Kind = ErrRemote
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// interruptedError - returns the error of the push which has failed as the context is done. *CmdError of the killed
+// command is classified as the error of the context, so that it can be inspected with errors.As as well.
+func interruptedError(ctx context.Context, err error) error {
+	var ce *CmdError
+	if !errors.As(err, &ce) {
+		return fmt.Errorf("push interrupted: %w: %v", ctx.Err(), err)
+	}
+
+	ce.Kind = ctx.Err()
+
+	return fmt.Errorf("push interrupted: %w", err)
+}
+
+// classify7zStderr - returns the sentinel error matching the 7zip failure, or nil if it isn't recognized.
+//
+// Integrity markers are checked only once a wrong password has been ruled out, as 7zip reports i.e. a CRC failure
+// of an encrypted file as "CRC Failed in encrypted file. Wrong password?".
+func classify7zStderr(stderr string) error {
+	if strings.Contains(stderr, "Wrong password") {
+		return ErrWrongPassword
+	}
+
+	integrityMarkers := []string{
+		"Data Error", "CRC Failed", "Headers Error", "Unexpected end of archive",
+		"Can not open the file as archive", "Is not archive",
+	}
+
+	for _, marker := range integrityMarkers {
+		if strings.Contains(stderr, marker) {
+			return ErrIntegrity
+		}
+	}
+
+	return nil
+}
+
+// redactArgs - returns a copy of command args, in which 7zip password switch (-pSECRET) and credentials of repo URLs
+// (i.e. of `git clone`) are redacted.
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+
+	for i, arg := range args {
+		if strings.HasPrefix(arg, passwordArgPrefix) && len(arg) > len(passwordArgPrefix) {
+			arg = redactedPassword
+		}
+
+		redacted[i] = redactURL(arg)
+	}
+
+	return redacted
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+
+	return strings.TrimSpace(lines[len(lines)-1])
+}
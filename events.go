@@ -0,0 +1,43 @@
+package ax
+
+import "os"
+
+// Progress events, which are logged once a stage of a backup has finished, along with the attributes below which apply
+// to the stage. Messages and attribute keys are part of the API, so that the progress can be observed by a Logger,
+// i.e. to record metrics of the backups.
+const (
+	EventArchived  = "Finished Archiving!"
+	EventStreamed  = "Finished Streaming!"
+	EventVerified  = "Archive(s) verified!"
+	EventEncrypted = "Archive(s) encrypted!"
+	EventPushed    = "Pushed all volume(s) to origin/master"
+)
+
+// Attributes of the progress events.
+const (
+	// AttrDuration - how long the stage has taken, as time.Duration.
+	AttrDuration = "duration"
+
+	// AttrBytesIn - total size of the archived files, as uint64.
+	AttrBytesIn = "bytes_in"
+
+	// AttrBytesOut - total size of the files written (or pushed) by the stage, as uint64.
+	AttrBytesOut = "bytes_out"
+
+	// AttrVolumes - number of the volume(s) written (or pushed) by the stage, as int.
+	AttrVolumes = "volumes"
+)
+
+// filesSize - returns total size of the files, leaving out the ones which can't be read.
+func filesSize(paths []string) uint64 {
+	var size uint64
+
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err == nil {
+			size += uint64(info.Size())
+		}
+	}
+
+	return size
+}
@@ -1,13 +1,16 @@
 package ax
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 )
 
-const (
-	archiveWildcard001 = "*.001"
-)
+// ErrNoMatchingEntries - none of the archived entries matches the selected files.
+var ErrNoMatchingEntries = errors.New("no archived entries match the selected files")
 
 // ExtractConfig - represents configuration which is required for 7zip extraction process.
 type ExtractConfig struct {
@@ -15,35 +18,334 @@ type ExtractConfig struct {
 	Password []byte
 
 	// ExtractPath - path which points to the directory to archive(s) location (for extraction).
+	//
+	// Deprecated: use ArchivePath and OutputDir, which both default to ExtractPath.
 	ExtractPath string
+
+	// ArchivePath - path of the archive (or of its first volume), or of the directory holding the archive(s).
+	ArchivePath string
+
+	// OutputDir - directory the archive(s) are extracted into, which is created if missing. If empty, the archive(s)
+	// are extracted into the directory holding them.
+	OutputDir string
+
+	// Overwrite - what happens with files which already exist in OutputDir, OverwriteFail if empty.
+	Overwrite OverwritePolicy
+
+	// AllowUnsafePaths - if set, entries with absolute paths or references to parent directories, and links pointing
+	// outside of OutputDir, are extracted too. Otherwise, nothing is extracted from an archive holding any of them,
+	// and UnsafePathsError is returned.
+	AllowUnsafePaths bool
+
+	// PreserveMetadata - if set, exact modes (including setuid, setgid and sticky bits), modification times and
+	// extended attributes of the extracted entries are restored, as well as their ownership when running as root.
+	// Only tarballs hold all of it, including the archive(s) created with ArchiveConfig.PreserveMetadata.
+	PreserveMetadata bool
+
+	// NumericOwner - if set, ownership is restored by the archived uid and gid, instead of the user and group names.
+	NumericOwner bool
+
+	// EncryptionPassword - if set, it will be used to decrypt the archive(s) written by StreamArchive.
+	EncryptionPassword []byte
+
+	// Logger - if set, progress of the call is reported to it instead of the DefaultLogger.
+	Logger Logger
+
+	// Files - if set, only entries matching one of these gitignore-style patterns (or being within a matching
+	// directory) are extracted, i.e. 'nginx/nginx.conf' or '*.conf'.
+	Files []string
 }
 
 // Extract - used to extract the archive(s).
+//
+// Archives are found at ArchivePath (see FindArchives), and their format is detected by the magic bytes, so 7z, zip
+// or any other format 7zip supports can be extracted, whether it's split into volumes or not. Tarballs (optionally
+// gzip or bzip2 compressed) are extracted natively, without 7zip.
+//
+// Archives pulled from a remote may have been tampered with, so they're rejected if any entry would end up outside of
+// OutputDir, unless AllowUnsafePaths is set.
+//
+// If Files is set, only the matching entries are extracted. Note that 7zip still needs every volume to be present (and
+// decrypted), as the headers are stored in the last one, while entries of a solid block can only be decompressed
+// starting with the first volume holding it.
 func Extract(conf *ExtractConfig) error {
-	err := executeCommand(cmd7z, cmdArgsArchiveExtract(conf))
+	return ExtractWithContext(context.Background(), conf)
+}
+
+// ExtractWithContext - same as Extract, but it stops once the context is done (killing 7zip, if it's running), in
+// which case the returned error wraps the error of the context.
+//
+// Files are extracted natively under a part name first, see writePartFile. As 7zip may still leave partially extracted
+// files behind, the output directory is marked by IncompleteMarker while extracting, and the marker is left in place if
+// the extraction is canceled.
+func ExtractWithContext(ctx context.Context, conf *ExtractConfig) (err error) {
+	log := loggerOr(conf.Logger)
+
+	_, err = ParseOverwritePolicy(string(conf.Overwrite))
 	if err != nil {
-		return fmt.Errorf("failed executing 7zip: %w", err)
+		return err
 	}
 
+	sets, err := FindArchives(conf.archivePath())
+	if err != nil {
+		return err
+	}
+
+	unmark, err := markIncomplete(log, conf.outputDir(), conf.archivePath())
+	if err != nil {
+		return err
+	}
+
+	defer func() { unmark(err, ctx.Err() != nil) }()
+
+	extracted := 0
+
+	for _, set := range sets {
+		n, err := extractSet(ctx, log, conf, set)
+		if err != nil {
+			return fmt.Errorf("failed extracting %s: %w", set.Path(), err)
+		}
+
+		extracted += n
+	}
+
+	if len(conf.Files) > 0 && extracted == 0 {
+		return fmt.Errorf("%w: %v", ErrNoMatchingEntries, conf.Files)
+	}
+
+	log.Info("Finished Extracting!", "path", conf.outputDir(), "archives", len(sets))
+
 	return nil
 }
 
-// cmdArgsArchiveExtract - used to build command arguments for Archive Extraction process.
-// Returned string will be transformed into arguments slice which is later used for Output() func on [exec.Command].
-func cmdArgsArchiveExtract(ec *ExtractConfig) string {
-	cmdString := "x"
+// archivePath - returns where the archive(s) are located.
+func (ec *ExtractConfig) archivePath() string {
+	if ec.ArchivePath != "" {
+		return ec.ArchivePath
+	}
+
+	return ec.ExtractPath
+}
+
+// outputDir - returns where the archive(s) are extracted to.
+func (ec *ExtractConfig) outputDir() string {
+	switch {
+	case ec.OutputDir != "":
+		return ec.OutputDir
+	case ec.ExtractPath != "":
+		return ec.ExtractPath
+	}
+
+	path := ec.archivePath()
+	if stat, err := os.Stat(path); err == nil && stat.IsDir() {
+		return path
+	}
+
+	return filepath.Dir(path)
+}
+
+// overwrite - returns the overwrite policy in effect, which has been validated by Extract.
+func (ec *ExtractConfig) overwrite() OverwritePolicy {
+	p, _ := ParseOverwritePolicy(string(ec.Overwrite))
+
+	return p
+}
+
+// extractSet - extracts the archive set, returning the number of files which have been selected for extraction.
+func extractSet(ctx context.Context, log Logger, conf *ExtractConfig, set ArchiveSet) (int, error) {
+	selected, err := newSelector(conf.Files)
+	if err != nil {
+		return 0, err
+	}
+
+	if set.native() {
+		return extractTarSet(log, conf, nativeWalker(ctx, set, conf.EncryptionPassword), selected)
+	}
+
+	entries, err := list7z(ctx, log, conf, set)
+	if err != nil {
+		return 0, err
+	}
+
+	if isMetadataArchive(entries) {
+		return extractTarSet(log, conf, sevenZipTarWalker(ctx, log, conf, set), selected)
+	}
+
+	if conf.PreserveMetadata {
+		log.Warn("archive holds no metadata, it's restored only as far as 7zip does", "archive", set.Path())
+	}
+
+	files, err := planExtraction(log, conf, entries, selected)
+	if err != nil || (len(conf.Files) > 0 && len(files) == 0) {
+		// Selected files may be held by other archive(s).
+		return 0, err
+	}
+
+	if !conf.AllowUnsafePaths {
+		err = ensureEntriesWithin(log, conf.outputDir(), conf.overwrite(), entries, selected)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	args := cmdArgsArchiveExtract(conf, set.Path())
+
+	if len(conf.Files) > 0 {
+		log.Debug("selected entries to extract", "archive", set.Path(), "count", len(files))
+
+		listFile, err := writeListFile(files)
+		if err != nil {
+			return 0, err
+		}
+
+		defer os.Remove(listFile)
+
+		args = append(args, "-scsUTF-8", "@"+listFile)
+	}
+
+	err = executeCommandIn(ctx, log, "", sevenZipCmd(), args)
+	if err != nil {
+		return 0, fmt.Errorf("failed executing 7zip: %w", err)
+	}
+
+	return len(files), nil
+}
+
+// extractTarSet - extracts the tarball, read by the walker, with the native backend. The tarball is read twice, so
+// that nothing is extracted if any of the entries gets rejected.
+func extractTarSet(log Logger, conf *ExtractConfig, walk tarWalker, selected func(e *Entry) bool) (int, error) {
+	entries, err := listTar(walk)
+	if err != nil {
+		return 0, err
+	}
+
+	files, err := planExtraction(log, conf, entries, selected)
+	if err != nil {
+		return 0, err
+	}
+
+	if !conf.AllowUnsafePaths {
+		err = ensureEntriesWithin(log, conf.outputDir(), conf.overwrite(), entries, selected)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	opts := nativeOptions{
+		outDir:      conf.outputDir(),
+		overwrite:   conf.overwrite(),
+		allowUnsafe: conf.AllowUnsafePaths,
+		selected:    selected,
+	}
+
+	if conf.PreserveMetadata {
+		opts.metadata = newMetadataRestorer(log, conf.NumericOwner)
+	}
+
+	return len(files), extractTar(log, walk, opts)
+}
+
+// sevenZipTarWalker - returns tarWalker reading the tarball of the archive created with PreserveMetadata, which 7zip
+// decompresses to its stdout, so that it's never written to disk.
+func sevenZipTarWalker(ctx context.Context, log Logger, conf *ExtractConfig, set ArchiveSet) tarWalker {
+	return func(fn func(e *nativeEntry, r io.Reader) error) error {
+		err := pipeCommandIn(ctx, log, "", sevenZipCmd(), cmdArgsArchiveStream(conf, set.Path()), func(r io.Reader) error {
+			return walkTar(r, set.Path(), fn)
+		})
+		if err != nil {
+			return fmt.Errorf("failed reading tarball: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// planExtraction - returns paths of the files which are going to be extracted, unless any of the selected entries is
+// unsafe (see checkEntries), or already exists while OverwriteFail is in effect.
+func planExtraction(log Logger, conf *ExtractConfig, entries []Entry, selected func(e *Entry) bool) ([]string, error) {
+	planned := make([]Entry, 0, len(entries))
+
+	for i := range entries {
+		if selected(&entries[i]) {
+			planned = append(planned, entries[i])
+		}
+	}
+
+	if !conf.AllowUnsafePaths {
+		err := checkEntries(log, planned)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files := filePaths(planned, func(*Entry) bool { return true })
+
+	if conf.overwrite() == OverwriteFail {
+		err := checkConflicts(conf.outputDir(), files)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// newSelector - returns func accepting entries which match one of the patterns, or are within a matching directory.
+// Directories themselves aren't accepted, unless there are no patterns at all, in which case everything is.
+func newSelector(patterns []string) (func(e *Entry) bool, error) {
+	selection, err := parsePatterns(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing file patterns: %w", err)
+	}
+
+	if len(selection) == 0 {
+		return func(*Entry) bool { return true }, nil
+	}
+
+	f := &Filter{include: selection}
+
+	return func(e *Entry) bool {
+		return !e.IsDir && !f.Excluded(e.Path, false)
+	}, nil
+}
+
+// filePaths - returns paths of the archived files (not directories) accepted by the selector.
+func filePaths(entries []Entry, selected func(e *Entry) bool) []string {
+	paths := make([]string, 0)
+
+	for i := range entries {
+		if !entries[i].IsDir && selected(&entries[i]) {
+			paths = append(paths, entries[i].Path)
+		}
+	}
+
+	return paths
+}
+
+// cmdArgsArchiveStream - used to build command arguments for extraction of the tarball, archived with PreserveMetadata,
+// to stdout.
+func cmdArgsArchiveStream(ec *ExtractConfig, archive string) []string {
+	args := []string{"x", "-so"}
+
+	if len(ec.Password) != 0 {
+		args = append(args, fmt.Sprintf("-p%s", ec.Password))
+	}
+
+	return append(args, archive, metadataTarName)
+}
+
+// cmdArgsArchiveExtract - used to build command arguments for extraction of the archive.
+func cmdArgsArchiveExtract(ec *ExtractConfig, archive string) []string {
+	args := []string{"x"}
 
 	// Append password, if defined.
 	if ec.Password != nil || string(ec.Password) != "" {
-		appendToString(&cmdString, fmt.Sprintf("-p%s", ec.Password))
+		args = append(args, fmt.Sprintf("-p%s", ec.Password))
 	}
 
-	// TODO: Here a check for volumes should be applied. If the archive wasn't previously split into volumes, then exact
-	// Append path to the archive which has to be extracted.
-	appendToString(&cmdString, fmt.Sprintf("%s%c%s", ec.ExtractPath, os.PathSeparator, archiveWildcard001))
-
-	// Append path where we want files to be extracted.
-	appendToString(&cmdString, fmt.Sprintf("-o%s", ec.ExtractPath))
+	// Append path to the archive which has to be extracted, 7zip finds the rest of the volumes on its own.
+	args = append(args, archive)
 
-	return cmdString
+	// Append path where we want files to be extracted, and what to do with the existing ones.
+	return append(args, fmt.Sprintf("-o%s", ec.outputDir()), ec.overwrite().switch7z())
 }
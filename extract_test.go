@@ -62,3 +62,56 @@ func (s *Suite) TestUnitExtract() {
 
 	RunTestCases(s, testCases)
 }
+
+func (s *Suite) TestUnitSelectFiles() {
+	entries := []Entry{
+		{Path: "nginx", IsDir: true},
+		{Path: "nginx/nginx.conf"},
+		{Path: "nginx/sites/default.conf"},
+		{Path: "nginx/mime.types"},
+		{Path: "dump.sql"},
+	}
+
+	testCases := []TestCase{
+		{
+			Name: "success select by glob and directory",
+			Assert: func() {
+				selected, err := newSelector([]string{"*.conf"})
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), []string{"nginx/nginx.conf", "nginx/sites/default.conf"}, filePaths(entries, selected))
+
+				selected, err = newSelector([]string{"nginx/sites/", "dump.sql"})
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), []string{"nginx/sites/default.conf", "dump.sql"}, filePaths(entries, selected))
+			},
+		},
+		{
+			Name: "success everything selected without patterns",
+			Assert: func() {
+				selected, err := newSelector(nil)
+				assert.Nil(s.T(), err)
+				assert.True(s.T(), selected(&entries[0]))
+				assert.Len(s.T(), filePaths(entries, selected), 4)
+			},
+		},
+		{
+			Name: "success nothing matches",
+			Assert: func() {
+				selected, err := newSelector([]string{"*.pem"})
+
+				assert.Nil(s.T(), err)
+				assert.Empty(s.T(), filePaths(entries, selected))
+			},
+		},
+		{
+			Name: "err invalid pattern",
+			Assert: func() {
+				_, err := newSelector([]string{"[a-"})
+
+				assert.ErrorIs(s.T(), err, ErrInvalidPattern)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
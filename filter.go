@@ -0,0 +1,266 @@
+package ax
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName - name of the file, in the root of the archived directory, holding gitignore-style exclude patterns.
+const IgnoreFileName = ".axignore"
+
+const (
+	patternComment   = "#"
+	patternNegate    = "!"
+	patternSeparator = "/"
+	patternAnyDepth  = "**"
+)
+
+// ErrInvalidPattern - include/exclude pattern can not be parsed.
+var ErrInvalidPattern = errors.New("invalid pattern")
+
+// pattern - represents a single gitignore-style pattern.
+type pattern struct {
+	// segments - pattern split on the separator, where "**" matches any number of path segments.
+	segments []string
+	// negate - pattern started with "!", so it re-includes what a previous pattern excluded.
+	negate bool
+	// dirOnly - pattern ended with "/", so it matches only directories.
+	dirOnly bool
+}
+
+// parsePattern - parses the pattern, using the gitignore syntax.
+// Comments and blank lines yield no pattern, which is reported with false.
+func parsePattern(raw string) (pattern, bool, error) {
+	p := pattern{}
+
+	raw = strings.TrimRight(raw, " \t\r")
+	if raw == "" || strings.HasPrefix(raw, patternComment) {
+		return p, false, nil
+	}
+
+	if strings.HasPrefix(raw, patternNegate) {
+		p.negate = true
+		raw = raw[len(patternNegate):]
+	}
+
+	if strings.HasSuffix(raw, patternSeparator) {
+		p.dirOnly = true
+		raw = strings.TrimRight(raw, patternSeparator)
+	}
+
+	// Pattern without a separator (other than the trailing one) matches at any depth, while others are anchored to
+	// the root of the walked directory.
+	if !strings.Contains(raw, patternSeparator) {
+		raw = patternAnyDepth + patternSeparator + raw
+	}
+
+	raw = strings.TrimPrefix(raw, patternSeparator)
+	if raw == "" {
+		return p, false, fmt.Errorf("%w: pattern matches nothing", ErrInvalidPattern)
+	}
+
+	p.segments = strings.Split(raw, patternSeparator)
+
+	for _, seg := range p.segments {
+		if _, err := path.Match(seg, ""); err != nil {
+			return p, false, fmt.Errorf("%w: %q: %v", ErrInvalidPattern, raw, err)
+		}
+	}
+
+	return p, true, nil
+}
+
+// match - reports whether the slash-separated path, relative to the walked directory, matches the pattern.
+func (p pattern) match(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	return matchSegments(p.segments, strings.Split(rel, patternSeparator))
+}
+
+// matchSegments - matches path segments against pattern segments, where "**" matches zero or more path segments.
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == patternAnyDepth {
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(segs) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+
+		pat, segs = pat[1:], segs[1:]
+	}
+
+	return len(segs) == 0
+}
+
+// lastMatch - returns whether the path is matched by the patterns. As in gitignore, the last matching pattern decides.
+func lastMatch(patterns []pattern, rel string, isDir bool) bool {
+	matched := false
+
+	for _, p := range patterns {
+		if p.match(rel, isDir) {
+			matched = !p.negate
+		}
+	}
+
+	return matched
+}
+
+// Filter - decides which files under a directory are archived or listed.
+//
+// Both include and exclude patterns use the gitignore syntax, and are matched against paths relative to the
+// directory. Exclude patterns are read from IgnoreFileName first, if the directory holds one, followed by the ones
+// provided by the caller, so that the latter take precedence. If any include pattern is set, only files matching one of
+// them (or being within a matching directory) are kept.
+type Filter struct {
+	root    string
+	include []pattern
+	exclude []pattern
+}
+
+// NewFilter - returns Filter for the directory at root, reading its IgnoreFileName, if there is one.
+func NewFilter(root string, include, exclude []string) (*Filter, error) {
+	f := &Filter{root: root}
+
+	var err error
+
+	f.include, err = parsePatterns(include)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing include patterns: %w", err)
+	}
+
+	ignored, err := readIgnoreFile(filepath.Join(root, IgnoreFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	f.exclude, err = parsePatterns(append(ignored, exclude...))
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing exclude patterns: %w", err)
+	}
+
+	return f, nil
+}
+
+// Active - reports whether the Filter leaves out anything at all.
+func (f *Filter) Active() bool {
+	return len(f.include) > 0 || len(f.exclude) > 0
+}
+
+// Excluded - reports whether the path, relative to the root of the Filter, is left out.
+// Parent directories of the path are expected to have been checked already, as it's done while walking.
+func (f *Filter) Excluded(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+
+	if lastMatch(f.exclude, rel, isDir) {
+		return true
+	}
+
+	if isDir || len(f.include) == 0 {
+		return false
+	}
+
+	// A file is included if it, or any of its parent directories, matches an include pattern.
+	for dir, isParent := rel, false; dir != "." && dir != patternSeparator; dir, isParent = path.Dir(dir), true {
+		if lastMatch(f.include, dir, isParent) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PathWalkerFunc - returns filepath.WalkFunc, which lists the files left in by the Filter. It can be passed to ListFiles
+// in place of DefaultPathWalkerFunc, while walking the root of the Filter.
+func (f *Filter) PathWalkerFunc(fileList *[]string) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk initiated with an error: %w", err)
+		}
+
+		rel, err := filepath.Rel(f.root, path)
+		if err != nil {
+			return fmt.Errorf("failed resolving path: %s: %w", path, err)
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		if f.Excluded(rel, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !info.IsDir() {
+			*fileList = append(*fileList, path)
+		}
+
+		return nil
+	}
+}
+
+func parsePatterns(raw []string) ([]pattern, error) {
+	patterns := make([]pattern, 0, len(raw))
+
+	for _, r := range raw {
+		p, ok, err := parsePattern(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			patterns = append(patterns, p)
+		}
+	}
+
+	return patterns, nil
+}
+
+// readIgnoreFile - returns lines of the ignore file, or none if it doesn't exist.
+func readIgnoreFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed opening %s: %w", IgnoreFileName, err)
+	}
+
+	defer f.Close()
+
+	lines := make([]string, 0)
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+
+	if err = s.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading %s: %w", IgnoreFileName, err)
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,196 @@
+package ax
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTree - creates files (with their parent dirs) under a new temporary dir, and returns its path.
+func (s *Suite) writeTree(files ...string) string {
+	root := filepath.Join(s.T().TempDir(), "src")
+
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+
+		err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			s.T().Fatal(err)
+		}
+
+		err = os.WriteFile(path, []byte(file), 0o600)
+		if err != nil {
+			s.T().Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func (s *Suite) TestUnitParsePattern() {
+	testCases := []TestCase{
+		{
+			Name: "success comments and blank lines are skipped",
+			Assert: func() {
+				for _, raw := range []string{"", "   ", "# node_modules"} {
+					_, ok, err := parsePattern(raw)
+
+					assert.Nil(s.T(), err)
+					assert.False(s.T(), ok, raw)
+				}
+			},
+		},
+		{
+			Name: "err malformed pattern",
+			Assert: func() {
+				_, _, err := parsePattern("[a-")
+
+				assert.ErrorIs(s.T(), err, ErrInvalidPattern)
+			},
+		},
+		{
+			Name: "success gitignore matching",
+			Assert: func() {
+				cases := []struct {
+					pattern string
+					path    string
+					isDir   bool
+					matched bool
+				}{
+					{"*.log", "debug.log", false, true},
+					{"*.log", "a/b/debug.log", false, true},
+					{"/*.log", "a/debug.log", false, false},
+					{"build/", "build", true, true},
+					{"build/", "build", false, false},
+					{"build/", "web/build", true, true},
+					{"docs/*.md", "docs/a.md", false, true},
+					{"docs/*.md", "x/docs/a.md", false, false},
+					{"a/**/z", "a/z", true, true},
+					{"a/**/z", "a/b/c/z", false, true},
+					{"**/secrets", "x/y/secrets", true, true},
+				}
+
+				for _, c := range cases {
+					p, ok, err := parsePattern(c.pattern)
+					assert.Nil(s.T(), err)
+					assert.True(s.T(), ok)
+
+					assert.Equal(s.T(), c.matched, p.match(c.path, c.isDir), c.pattern+" ~ "+c.path)
+				}
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitFilter() {
+	var root string
+
+	listRel := func(f *Filter) []string {
+		fileList, err := ListFiles(root, f.PathWalkerFunc)
+		assert.Nil(s.T(), err)
+
+		for i, file := range fileList {
+			rel, _ := filepath.Rel(root, file)
+			fileList[i] = filepath.ToSlash(rel)
+		}
+
+		sort.Strings(fileList)
+
+		return fileList
+	}
+
+	testCases := []TestCase{
+		{
+			Name: "success no patterns keep everything",
+			PreRequisites: func() {
+				root = s.writeTree("main.go", "node_modules/x/index.js", ".git/HEAD")
+			},
+			Assert: func() {
+				f, err := NewFilter(root, nil, nil)
+				assert.Nil(s.T(), err)
+				assert.False(s.T(), f.Active())
+
+				assert.Equal(s.T(), []string{".git/HEAD", "main.go", "node_modules/x/index.js"}, listRel(f))
+			},
+		},
+		{
+			Name: "success axignore with negation and exclude patterns",
+			PreRequisites: func() {
+				root = s.writeTree(
+					"main.go", "secret.env", "keep.env", "node_modules/x/index.js", "build/out.bin", ".git/HEAD",
+				)
+
+				err := os.WriteFile(
+					filepath.Join(root, IgnoreFileName), []byte("# deps\nnode_modules/\n*.env\n!keep.env\n"), 0o600,
+				)
+				assert.Nil(s.T(), err)
+			},
+			Assert: func() {
+				f, err := NewFilter(root, nil, []string{".git/", "build"})
+				assert.Nil(s.T(), err)
+				assert.True(s.T(), f.Active())
+
+				assert.Equal(s.T(), []string{IgnoreFileName, "keep.env", "main.go"}, listRel(f))
+			},
+		},
+		{
+			Name: "success include patterns",
+			PreRequisites: func() {
+				root = s.writeTree("main.go", "README.md", "docs/a.md", "docs/img/logo.png", "pkg/x/x.go")
+			},
+			Assert: func() {
+				f, err := NewFilter(root, []string{"*.go", "docs/"}, []string{"*.png"})
+				assert.Nil(s.T(), err)
+
+				assert.Equal(s.T(), []string{"docs/a.md", "main.go", "pkg/x/x.go"}, listRel(f))
+			},
+		},
+		{
+			Name: "err invalid pattern",
+			PreRequisites: func() {
+				root = s.writeTree("main.go")
+			},
+			Assert: func() {
+				_, err := NewFilter(root, nil, []string{"[a-"})
+
+				assert.ErrorIs(s.T(), err, ErrInvalidPattern)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitListArchiveFiles() {
+	testCases := []TestCase{
+		{
+			Name: "err path validation",
+			Assert: func() {
+				_, err := ListArchiveFiles(&ArchiveConfig{})
+
+				assert.ErrorIs(s.T(), err, ErrPathEmpty)
+			},
+		},
+		{
+			Name: "success paths as stored in the archive",
+			PreRequisites: func() {
+				s.ac = &ArchiveConfig{
+					PathConfig: PathConfig{PathToArchive: s.writeTree("main.go", "vendor/x.go")},
+					Exclude:    []string{"vendor/"},
+				}
+			},
+			Assert: func() {
+				fileList, err := ListArchiveFiles(s.ac)
+
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), []string{filepath.Join("src", "main.go")}, fileList)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
@@ -0,0 +1,94 @@
+package ax
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const fingerprintDirName = "fingerprints"
+
+// SourcesFingerprint - returns a digest of the files archived with the config: their paths, sizes, modes and
+// modification times. It changes whenever any of the files is added, removed or modified, without reading any of them.
+func SourcesFingerprint(conf *ArchiveConfig) (string, error) {
+	err := validatePathToArchive(conf)
+	if err != nil {
+		return "", fmt.Errorf("path validation issue: %w", err)
+	}
+
+	root, entries, err := listArchiveEntries(conf)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+
+	for _, rel := range entries {
+		info, err := os.Lstat(filepath.Join(root, rel))
+		if err != nil {
+			return "", fmt.Errorf("failed fingerprinting %s: %w", rel, err)
+		}
+
+		_, _ = fmt.Fprintf(h, "%s\x00%d\x00%o\x00%d\n", rel, info.Size(), info.Mode(), info.ModTime().UnixNano())
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FingerprintStore - remembers the fingerprint of the sources of each backup, as of its latest successful push, so
+// that pushes of sources which haven't changed since can be told apart.
+type FingerprintStore struct {
+	dir string
+}
+
+// OpenFingerprintStore - returns FingerprintStore keeping the fingerprints in the dir, or in 'ax/fingerprints' within
+// the user cache dir if it's empty.
+func OpenFingerprintStore(dir string) (*FingerprintStore, error) {
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed resolving user cache dir: %w", err)
+		}
+
+		dir = filepath.Join(cache, "ax", fingerprintDirName)
+	}
+
+	return &FingerprintStore{dir: dir}, nil
+}
+
+// Pushed - returns the fingerprint of the sources of the backup with the job key, as of its latest successful push to
+// the repo, or an empty string if there hasn't been one.
+func (s *FingerprintStore) Pushed(jobKey, repo string) string {
+	raw, err := os.ReadFile(s.path(jobKey, repo))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(raw))
+}
+
+// SetPushed - records the fingerprint of the sources of the backup with the job key, which has been pushed to the repo.
+func (s *FingerprintStore) SetPushed(jobKey, repo, fingerprint string) error {
+	err := os.MkdirAll(s.dir, workDirPerm)
+	if err != nil {
+		return fmt.Errorf("failed creating fingerprint dir: %w", err)
+	}
+
+	return writePartFile(s.path(jobKey, repo), encFilePerm, func(w io.Writer) error {
+		_, err := io.WriteString(w, fingerprint+"\n")
+
+		return err
+	})
+}
+
+// path - returns the file holding the fingerprint. The repo is hashed along with the job key, so that credentials it
+// may hold are never written.
+func (s *FingerprintStore) path(jobKey, repo string) string {
+	sum := sha256.Sum256([]byte(jobKey + "\x00" + repo))
+
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
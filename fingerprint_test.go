@@ -0,0 +1,89 @@
+package ax
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitFingerprint() {
+	var (
+		dir   string
+		conf  *ArchiveConfig
+		store *FingerprintStore
+	)
+
+	prepare := func() {
+		dir = s.T().TempDir()
+
+		s.Require().Nil(os.MkdirAll(filepath.Join(dir, "src", "sub"), 0o700))
+		s.Require().Nil(os.WriteFile(filepath.Join(dir, "src", "a.txt"), []byte("a"), 0o600))
+		s.Require().Nil(os.WriteFile(filepath.Join(dir, "src", "sub", "b.txt"), []byte("b"), 0o600))
+
+		ac := NewDefaultArchiveConfig()
+		ac.PathToArchive = filepath.Join(dir, "src")
+		conf = &ac
+
+		var err error
+
+		store, err = OpenFingerprintStore(filepath.Join(dir, "fingerprints"))
+		s.Require().Nil(err)
+	}
+
+	fingerprint := func() string {
+		fp, err := SourcesFingerprint(conf)
+		s.Require().Nil(err)
+
+		return fp
+	}
+
+	testCases := []TestCase{
+		{
+			Name:          "success fingerprint changes only along with the sources",
+			PreRequisites: prepare,
+			Assert: func() {
+				fp := fingerprint()
+				assert.Equal(s.T(), fp, fingerprint())
+
+				later := time.Now().Add(time.Hour)
+				s.Require().Nil(os.Chtimes(filepath.Join(dir, "src", "sub", "b.txt"), later, later))
+				assert.NotEqual(s.T(), fp, fingerprint(), "modified file")
+
+				fp = fingerprint()
+				s.Require().Nil(os.WriteFile(filepath.Join(dir, "src", "c.txt"), nil, 0o600))
+				assert.NotEqual(s.T(), fp, fingerprint(), "added file")
+
+				fp = fingerprint()
+				s.Require().Nil(os.Remove(filepath.Join(dir, "src", "a.txt")))
+				assert.NotEqual(s.T(), fp, fingerprint(), "removed file")
+			},
+		},
+		{
+			Name:          "success fingerprints are kept per backup and repo",
+			PreRequisites: prepare,
+			Assert: func() {
+				key, repo := NewJobKey(conf, false), "git@example.com:backups.git"
+				assert.Empty(s.T(), store.Pushed(key, repo))
+
+				s.Require().Nil(store.SetPushed(key, repo, fingerprint()))
+				assert.Equal(s.T(), fingerprint(), store.Pushed(key, repo))
+				assert.Empty(s.T(), store.Pushed(key, "git@example.com:other.git"))
+				assert.Empty(s.T(), store.Pushed(NewJobKey(conf, true), repo))
+			},
+		},
+		{
+			Name:          "err sources don't exist",
+			PreRequisites: prepare,
+			Assert: func() {
+				conf.PathToArchive = filepath.Join(dir, "missing")
+
+				_, err := SourcesFingerprint(conf)
+				assert.NotNil(s.T(), err)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
@@ -1,7 +1,12 @@
 package ax
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"strings"
@@ -11,9 +16,10 @@ const (
 	cmdGit                  = "git"
 	cmdGitInit              = "init"
 	cmdGitRemoteAddOrigin   = "remote add origin"
-	cmdGitAddDot            = "add ."
+	cmdGitAddDot            = "add . :(exclude)" + WorkDirMarker + "*" // Marker (and lock) of the WorkDir isn't pushed.
 	cmdGitCommitDashM       = "commit -m"
 	cmdGitForcePushToMaster = "push -u origin master --force"
+	cmdGitClone             = "clone"
 
 	commitMessageStatic = ":art:Test_Commit_Message"
 	zeroInt             = int(0)
@@ -26,27 +32,137 @@ var ErrCmdWrapFn = func(cmd, cmdArgs string, err error) error {
 	return fmt.Errorf("%s: failed executing the command with args[%v]: %w", cmd, cmdSlc, err)
 }
 
-func executeCommand(cmd, cmdArgs string) error {
-	cmdSliceInit := strings.Fields(cmdArgs)
+// executeCommand - executes the command, returning *CmdError if it fails.
+func executeCommand(ctx context.Context, log Logger, cmd, cmdArgs string) error {
+	return executeCommandIn(ctx, log, "", cmd, strings.Fields(cmdArgs))
+}
+
+// executeCommandIn - executes the command with the args as they are (so they may hold spaces) in the dir, or in the
+// current working dir if it's empty, returning *CmdError if it fails.
+//
+// The command is killed once the context is done, in which case the returned *CmdError is classified as the error
+// of the context (i.e. context.Canceled).
+func executeCommandIn(ctx context.Context, log Logger, dir, cmd string, args []string) error {
+	_, err := outputCommandIn(ctx, log, dir, cmd, args)
+
+	return err
+}
+
+// outputCommandIn - same as executeCommandIn, but returns what the command printed to its stdout.
+func outputCommandIn(ctx context.Context, log Logger, dir, cmd string, args []string) ([]byte, error) {
+	log.Debug("executing command", "cmd", cmd, "args", redactArgs(args), "dir", dir)
 
-	_, err := exec.Command(cmd, cmdSliceInit...).Output()
+	c := exec.CommandContext(ctx, cmd, args...)
+	c.Dir = dir
+
+	out, err := c.Output()
 	if err != nil {
-		return fmt.Errorf("%s: failed executing the command with args[%v]: %w", cmd, cmdSliceInit, err)
+		return nil, newCmdErrorContext(ctx, cmd, args, err)
+	}
+
+	return out, nil
+}
+
+// inputCommandIn - same as executeCommandIn, but the command reads its stdin from r.
+func inputCommandIn(ctx context.Context, log Logger, dir, cmd string, args []string, r io.Reader) error {
+	log.Debug("executing command", "cmd", cmd, "args", redactArgs(args), "dir", dir, "stdin", true)
+
+	c := exec.CommandContext(ctx, cmd, args...)
+	c.Dir = dir
+	c.Stdin = r
+
+	_, err := c.Output()
+	if err != nil {
+		return newCmdErrorContext(ctx, cmd, args, err)
 	}
 
 	return nil
 }
 
+// pipeCommandIn - same as executeCommandIn, but stdout of the command is passed to fn while it's running.
+//
+// If fn fails, its error is returned, unless the command has failed in a recognized way (i.e. due to a wrong password),
+// as it's the cause of the failure then.
+func pipeCommandIn(
+	ctx context.Context, log Logger, dir, cmd string, args []string, fn func(stdout io.Reader) error,
+) error {
+	log.Debug("executing command", "cmd", cmd, "args", redactArgs(args), "dir", dir, "stdout", "piped")
+
+	var stderr bytes.Buffer
+
+	c := exec.CommandContext(ctx, cmd, args...)
+	c.Dir = dir
+	c.Stderr = &stderr
+
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed piping stdout: %w", err)
+	}
+
+	err = c.Start()
+	if err != nil {
+		return newCmdErrorContext(ctx, cmd, args, err)
+	}
+
+	fnErr := fn(stdout)
+
+	// Closing stdout ends the command early, if fn hasn't read all of it.
+	_ = stdout.Close()
+
+	err = c.Wait()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitErr.Stderr = stderr.Bytes()
+		}
+
+		cmdErr := newCmdErrorContext(ctx, cmd, args, err)
+		if fnErr == nil || cmdErr.Kind != nil {
+			return cmdErr
+		}
+	}
+
+	return fnErr
+}
+
 // PushToGIT - used to commit&push created archive(s) to the remote GIT Repository.
+// Progress is reported to the DefaultLogger.
 func PushToGIT(gitRepo string, args ...gitChain) error {
+	return PushToGITWithContext(context.Background(), gitRepo, args...)
+}
+
+// PushToGITWithContext - same as PushToGIT, but git is killed once the context is done, in which case the returned
+// error wraps the error of the context.
+//
+// If the repository has been initialized by the push, it's removed when the push fails (or it's canceled), so that a
+// half-initialized repository is never left behind for the next push to trip over.
+func PushToGITWithContext(ctx context.Context, gitRepo string, args ...gitChain) (err error) {
 	gc := gitChain{}
 
 	if args == nil {
-		gc = buildDefaultForcePushGitChain()
+		gc = buildDefaultForcePushGitChain(ctx)
 	} else {
 		gc = args[zeroInt]
 	}
 
+	if _, statErr := os.Lstat(gitDir); os.IsNotExist(statErr) {
+		defer func() {
+			if err != nil {
+				_ = os.RemoveAll(gitDir)
+			}
+		}()
+	}
+
+	err = pushChain(gc, gitRepo)
+	if err != nil && ctx.Err() != nil {
+		return interruptedError(ctx, err)
+	}
+
+	return err
+}
+
+// pushChain - executes the git chain in order, stopping at the first failure.
+func pushChain(gc gitChain, gitRepo string) error {
 	err := gc.gitInit()
 	if err != nil {
 		return ErrCmdWrapFn(cmdGit, cmdGitInit, err)
@@ -69,9 +185,78 @@ func PushToGIT(gitRepo string, args ...gitChain) error {
 
 	err = gc.gitForcePushMaster()
 	if err != nil {
-		return fmt.Errorf("failed pushing changes: %w", err)
+		return remoteError("failed pushing changes", err)
+	}
+
+	return nil
+}
+
+// PushCommands - returns the git commands PushToGIT executes with the default chain, in order. Credentials which the
+// repo URL may hold are redacted, so that they can be reported.
+func PushCommands(gitRepo string) []string {
+	return []string{
+		fmt.Sprintf("%s %s", cmdGit, cmdGitInit),
+		fmt.Sprintf("%s %s %s", cmdGit, cmdGitRemoteAddOrigin, redactURL(gitRepo)),
+		fmt.Sprintf("%s %s", cmdGit, cmdGitAddDot),
+		fmt.Sprintf("%s %s %s", cmdGit, cmdGitCommitDashM, commitMessageStatic),
+		fmt.Sprintf("%s %s", cmdGit, cmdGitForcePushToMaster),
+	}
+}
+
+// redactURL - returns the repo URL with its password redacted, or its user if it's HTTP(S), as that's where access
+// tokens are passed, i.e. 'https://<token>@github.com/user/repo.git'. SCP-like SSH remotes are returned as they are.
+func redactURL(repo string) string {
+	u, err := url.Parse(repo)
+	if err != nil || u.User == nil {
+		return repo
+	}
+
+	userinfo := redactedSecret
+
+	switch _, hasPassword := u.User.Password(); {
+	case hasPassword:
+		userinfo = url.User(u.User.Username()).String() + ":" + redactedSecret
+	case u.Scheme != "http" && u.Scheme != "https":
+		return repo
+	}
+
+	// Built by hand, as the URL would escape the redacted secret.
+	u.User = nil
+
+	return fmt.Sprintf("%s://%s@%s", u.Scheme, userinfo, strings.TrimPrefix(u.String(), u.Scheme+"://"))
+}
+
+// PullFromGIT - used to clone the remote GIT Repository, holding the archive(s), into the chosen directory.
+// Progress is reported to the DefaultLogger.
+func PullFromGIT(gitRepo, dir string) error {
+	return PullFromGITWithContext(context.Background(), gitRepo, dir)
+}
+
+// PullFromGITWithContext - same as PullFromGIT, but git is killed once the context is done, in which case the
+// partially cloned directory is removed, unless it has existed before.
+func PullFromGITWithContext(ctx context.Context, gitRepo, dir string) error {
+	_, statErr := os.Lstat(dir)
+	existed := !os.IsNotExist(statErr)
+
+	err := executeCommand(ctx, DefaultLogger(), cmdGit, fmt.Sprintf("%s %s %s", cmdGitClone, gitRepo, dir))
+	if err != nil && ctx.Err() != nil {
+		if !existed {
+			_ = os.RemoveAll(dir)
+		}
+
+		return fmt.Errorf("pull interrupted: %w", err)
+	}
+
+	if errors.Is(err, ErrMissingDependency) {
+		return fmt.Errorf("failed cloning repo: %w", err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: failed cloning repo: %v", ErrRemote, err)
 	}
 
+	DefaultLogger().Info("Cloned the Repo", "repo", redactURL(gitRepo), "dir", dir)
+
 	return nil
 }
 
@@ -90,75 +275,72 @@ type gitChain struct {
 
 // buildDefaultForcePushGitChain - used to generate defaults for gitChain which will be used to force push
 // to the initialized Git Repo.
-func buildDefaultForcePushGitChain() gitChain {
+// Commands of the chain are killed once the context is done.
+func buildDefaultForcePushGitChain(ctx context.Context) gitChain {
 	return gitChain{
-		gitInit:            gitInit,
-		gitAddRemote:       gitAddRemote,
-		gitStageDot:        gitStageDot,
-		gitCommitM:         gitCommitM,
-		gitForcePushMaster: gitForcePushMaster,
+		gitInit:            func() error { return gitInit(ctx) },
+		gitAddRemote:       func(gitRepo string) error { return gitAddRemote(ctx, gitRepo) },
+		gitStageDot:        func() error { return gitStageDot(ctx) },
+		gitCommitM:         func(commitMsg string) error { return gitCommitM(ctx, commitMsg) },
+		gitForcePushMaster: func() error { return gitForcePushMaster(ctx) },
 	}
 }
 
-func gitInit() error {
-	err := executeCommand(cmdGit, cmdGitInit)
+func gitInit(ctx context.Context) error {
+	err := executeCommand(ctx, DefaultLogger(), cmdGit, cmdGitInit)
 	if err != nil {
 		return err
 	}
 
-	printStdoutLn("Initialized Repo")
+	DefaultLogger().Info("Initialized Repo")
 
 	return nil
 }
 
-func gitAddRemote(gitRepo string) error {
-	err := executeCommand(cmdGit, fmt.Sprintf("%s %s", cmdGitRemoteAddOrigin, gitRepo))
+func gitAddRemote(ctx context.Context, gitRepo string) error {
+	err := executeCommand(ctx, DefaultLogger(), cmdGit, fmt.Sprintf("%s %s", cmdGitRemoteAddOrigin, gitRepo))
 	if err != nil {
 		return err
 	}
 
-	printStdoutLn("Added Git Remote")
+	DefaultLogger().Info("Added Git Remote", "repo", redactURL(gitRepo))
 
 	return nil
 }
 
-func gitStageDot() error {
-	err := executeCommand(cmdGit, cmdGitAddDot)
+func gitStageDot(ctx context.Context) error {
+	err := executeCommand(ctx, DefaultLogger(), cmdGit, cmdGitAddDot)
 	if err != nil {
 		return err
 	}
 
-	printStdoutLn("Added all Archives to the Repo")
+	DefaultLogger().Info("Added all Archives to the Repo")
 
 	return nil
 }
 
 // TODO: Make commit message dynamic - based on metadata
 // [GH Issue #2](https://github.com/kaynetik/ax/issues/2)
-func gitCommitM(commitMsg string) error {
-	err := executeCommand(cmdGit, fmt.Sprintf(`%s %s`, cmdGitCommitDashM, commitMsg))
+func gitCommitM(ctx context.Context, commitMsg string) error {
+	err := executeCommand(ctx, DefaultLogger(), cmdGit, fmt.Sprintf(`%s %s`, cmdGitCommitDashM, commitMsg))
 	if err != nil {
 		return err
 	}
 
-	printStdoutLn("Made a Commit")
+	DefaultLogger().Info("Made a Commit")
 
 	return nil
 }
 
 // TODO: Improve this flow
 // [GH Issue #1(https://github.com/kaynetik/ax/issues/1)
-func gitForcePushMaster() error {
-	err := executeCommand(cmdGit, cmdGitForcePushToMaster)
+func gitForcePushMaster(ctx context.Context) error {
+	err := executeCommand(ctx, DefaultLogger(), cmdGit, cmdGitForcePushToMaster)
 	if err != nil {
 		return fmt.Errorf(": %w", err)
 	}
 
-	printStdoutLn("Pushed a Commit to origin/master")
+	DefaultLogger().Info("Pushed a Commit to origin/master")
 
 	return nil
 }
-
-func printStdoutLn(args ...interface{}) {
-	_, _ = fmt.Fprintln(os.Stdout, args...)
-}
@@ -33,6 +33,22 @@ func (s *Suite) TestUnitPushToGIT() {
 	RunTestCases(s, testCases)
 }
 
+func (s *Suite) TestUnitPullFromGIT() {
+	testCases := []TestCase{
+		{
+			Name: "err git clone",
+			Assert: func() {
+				err := PullFromGIT("./tests/does-not-exist.git", "./tests/git_pull_test")
+
+				assert.NotNil(s.T(), err)
+				assert.NoDirExists(s.T(), "./tests/git_pull_test")
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
 func (s *Suite) TestUnitCmdErrWrapper() {
 	testCases := []TestCase{
 		{
@@ -4,5 +4,7 @@ go 1.16
 
 require (
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68
 	golang.org/x/term v0.0.0-20210317153231-de623e64d2a6
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,475 @@
+package ax
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// JobStateFile - file within the work directory, holding state of the backup pushed from it. It shares the prefix
+	// of the WorkDirMarker, so it's neither pushed nor cleaned along with the Archive(s).
+	JobStateFile = WorkDirMarker + ".job"
+
+	cmdGitAddVolume       = "add --"
+	cmdGitDiffCachedQuiet = "diff --cached --quiet"
+	cmdGitPushToMaster    = "push -u origin master"
+
+	// gitIndexLock - lock git leaves behind if it's killed while changing the index.
+	gitIndexLock = "index.lock"
+)
+
+// JobStage - stage of a backup, which is recorded in the job state once it's completed.
+type JobStage string
+
+// Stages of a backup, in the order in which they are completed.
+const (
+	JobArchived  JobStage = "archived"
+	JobVerified  JobStage = "verified"
+	JobEncrypted JobStage = "encrypted"
+	JobPushed    JobStage = "pushed"
+)
+
+var (
+	// ErrNothingToResume - work directory holds no backup which could be resumed.
+	ErrNothingToResume = errors.New("nothing to resume")
+
+	// ErrRedactedRepo - repo of the job can't be pushed to, as its credentials have been redacted from the job state.
+	ErrRedactedRepo = errors.New("credentials of the repository have been redacted")
+)
+
+// Job - state of a backup pushed from a work directory, which is saved into its JobStateFile after every step, so that
+// a backup interrupted once its volume(s) have been encrypted is resumed, instead of being started over.
+//
+// Plaintext Archive(s) are never kept for later, so archiving and encrypting are resumed only as a whole: either the
+// encrypted volume(s) are complete, and both are skipped, or they're both done again. Pushing is resumed per volume,
+// as every volume is committed and pushed on its own (see PushJobWithContext).
+type Job struct {
+	// Key - fingerprint of the settings the backup has been started with (see NewJobKey), so that a backup is never
+	// resumed with volume(s) archived from other sources, or with other settings.
+	Key string `json:"key"`
+
+	// Repo - remote GIT Repository the volume(s) are pushed to, with credentials redacted.
+	Repo string `json:"repo,omitempty"`
+
+	// Stages - completed stages, in order.
+	Stages []JobStage `json:"stages"`
+
+	// Volumes - encrypted volume(s), relative to the work directory, in the order in which they're pushed.
+	Volumes []JobVolume `json:"volumes,omitempty"`
+
+	// Started & Updated - when the backup has been started, and when its state has last been saved.
+	Started time.Time `json:"started"`
+	Updated time.Time `json:"updated"`
+
+	dir string
+
+	// log - Logger the progress of the job is reported to, the DefaultLogger if it's nil.
+	log Logger
+}
+
+// JobVolume - represents an encrypted volume of a backup.
+type JobVolume struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+
+	// Committed & Pushed - if the volume has been committed to the repository within the work directory, and if
+	// the commit has reached the remote.
+	Committed bool `json:"committed,omitempty"`
+	Pushed    bool `json:"pushed,omitempty"`
+}
+
+// NewJobKey - returns fingerprint of the settings which the volume(s) of a backup depend on. Passwords are left out,
+// while sources are taken by their absolute paths.
+func NewJobKey(conf *ArchiveConfig, stream bool) string {
+	ac := *conf
+	ac.Password, ac.Logger, ac.OutputPath = nil, nil, ""
+	ac.PathToArchive = absOr(ac.PathToArchive)
+	ac.Root = absOr(ac.Root)
+	ac.Sources = make([]string, 0, len(conf.Sources))
+
+	for _, src := range conf.Sources {
+		ac.Sources = append(ac.Sources, absOr(src))
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%+v stream=%t", ac, stream)))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// absOr - returns absolute path of the path, or the path itself if it can't be resolved.
+func absOr(path string) string {
+	if path == "" {
+		return path
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return abs
+}
+
+// LoadJob - returns the job saved in the work directory, or ErrNothingToResume if there is none.
+func LoadJob(wd *WorkDir) (*Job, error) {
+	content, err := os.ReadFile(filepath.Join(wd.Path, JobStateFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s holds no backup", ErrNothingToResume, wd.Path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed reading job state: %w", err)
+	}
+
+	job := &Job{dir: wd.Path}
+
+	err = json.Unmarshal(content, job)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing job state: %w", err)
+	}
+
+	return job, nil
+}
+
+// OpenJob - returns the job saved in the work directory, if it has been started with the same key, and it's resumable
+// (see ResumeJob). Otherwise, the work directory is cleaned, and a new job is started.
+func OpenJob(log Logger, wd *WorkDir, key, repo string) (*Job, error) {
+	job, err := LoadJob(wd)
+	if err == nil && job.Key == key && job.Resumable() {
+		job, err = ResumeJob(log, wd, repo)
+		if err == nil {
+			return job, nil
+		}
+
+		log.Warn("interrupted backup can't be resumed, starting over", "dir", wd.Path, "reason", err)
+	}
+
+	err = wd.Clean()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job = &Job{Key: key, Repo: redactURL(repo), Stages: make([]JobStage, 0), Started: now, dir: wd.Path, log: log}
+
+	return job, job.save(now)
+}
+
+// ResumeJob - returns the job saved in the work directory, if its volume(s) have been encrypted, and they still match
+// their hashes, but they haven't all been pushed yet. Anything but the volume(s) and the repository holding them is
+// cleaned, and returned job is resumed by PushJobWithContext. Pushes start over, if the job has been pushing to
+// another repo, which is removed along with the repository.
+//
+// If the repo is empty, the job is resumed with the one it has been pushing to, which is returned in Job.Repo,
+// unless its credentials have been redacted (see ErrRedactedRepo).
+func ResumeJob(log Logger, wd *WorkDir, repo string) (*Job, error) {
+	job, err := LoadJob(wd)
+	if err != nil {
+		return nil, err
+	}
+
+	if !job.Resumable() {
+		return nil, fmt.Errorf("%w: backup in %s has been completed, or it hasn't reached encryption",
+			ErrNothingToResume, wd.Path)
+	}
+
+	if repo == "" {
+		if strings.Contains(job.Repo, redactedSecret) {
+			return nil, fmt.Errorf("%w: %s has to be given again", ErrRedactedRepo, job.Repo)
+		}
+
+		repo = job.Repo
+	}
+
+	err = job.verify()
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Repo != redactURL(repo) {
+		log.Warn("interrupted backup has been pushing to another repo, pushing it again", "repo", redactURL(repo))
+
+		err = os.RemoveAll(filepath.Join(wd.Path, gitDir))
+		if err != nil {
+			return nil, fmt.Errorf("failed removing repository of the interrupted backup: %w", err)
+		}
+
+		job.Repo = redactURL(repo)
+	}
+
+	err = job.CleanWorkDir(wd)
+	if err != nil {
+		return nil, err
+	}
+
+	job.log = log
+
+	log.Info("Resuming interrupted backup", "started", job.Started.Format(time.RFC3339),
+		"volumes", len(job.Volumes), "pending", len(job.Pending()))
+
+	return job, job.save(time.Now())
+}
+
+// CleanWorkDir - cleans the work directory (see WorkDir.Clean). The encrypted volume(s) and the repository holding them
+// are kept in place if the job is resumable, so that only plaintext is removed.
+func (j *Job) CleanWorkDir(wd *WorkDir) error {
+	if !j.Resumable() {
+		return wd.Clean()
+	}
+
+	keep := map[string]bool{gitDir: true}
+	for _, v := range j.Volumes {
+		keep[strings.SplitN(filepath.ToSlash(v.Name), "/", 2)[0]] = true
+	}
+
+	return wd.cleanExcept(keep)
+}
+
+// Resumable - reports whether the volume(s) of the job have been encrypted, while not all of them have been pushed.
+func (j *Job) Resumable() bool {
+	return j.Done(JobEncrypted) && !j.Done(JobPushed) && len(j.Volumes) != 0
+}
+
+// Done - reports whether the stage has been completed.
+func (j *Job) Done(stage JobStage) bool {
+	for _, s := range j.Stages {
+		if s == stage {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Complete - records the stage as completed, and saves the job.
+func (j *Job) Complete(stage JobStage) error {
+	if !j.Done(stage) {
+		j.Stages = append(j.Stages, stage)
+	}
+
+	return j.save(time.Now())
+}
+
+// SetVolumes - records the encrypted volume(s) along with their hashes, and saves the job.
+func (j *Job) SetVolumes(files []string) error {
+	volumes := make([]JobVolume, 0, len(files))
+
+	for _, file := range files {
+		rel, err := filepath.Rel(j.dir, file)
+		if err != nil {
+			return fmt.Errorf("failed resolving volume: %w", err)
+		}
+
+		size, sum, err := hashFile(file)
+		if err != nil {
+			return err
+		}
+
+		volumes = append(volumes, JobVolume{Name: rel, Size: size, SHA256: sum})
+	}
+
+	j.Volumes = volumes
+
+	return j.save(time.Now())
+}
+
+// Pending - returns names of the volume(s) which haven't been pushed yet.
+func (j *Job) Pending() []string {
+	pending := make([]string, 0, len(j.Volumes))
+
+	for _, v := range j.Volumes {
+		if !v.Pushed {
+			pending = append(pending, v.Name)
+		}
+	}
+
+	return pending
+}
+
+// verify - checks that every volume is still in place, with the recorded hash.
+func (j *Job) verify() error {
+	for _, v := range j.Volumes {
+		size, sum, err := hashFile(filepath.Join(j.dir, v.Name))
+		if err != nil {
+			return err
+		}
+
+		if size != v.Size || sum != v.SHA256 {
+			return fmt.Errorf("%w: %s doesn't match its hash", ErrIntegrity, v.Name)
+		}
+	}
+
+	return nil
+}
+
+// save - writes the job state into the work directory, replacing the previous one only once it's complete.
+func (j *Job) save(now time.Time) error {
+	j.Updated = now
+
+	content, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed encoding job state: %w", err)
+	}
+
+	return writePartFile(filepath.Join(j.dir, JobStateFile), encFilePerm, func(w io.Writer) error {
+		_, err := w.Write(content)
+
+		return err
+	})
+}
+
+// hashFile - returns size and hex encoded SHA-256 of the file.
+func hashFile(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed opening %s: %w", path, err)
+	}
+
+	defer f.Close()
+
+	h := sha256.New()
+
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed hashing %s: %w", path, err)
+	}
+
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// PushJobWithContext - pushes the volume(s) of the job to the remote GIT Repository, from the repository within the
+// work directory, which is initialized unless it already exists. Every volume is committed and pushed on its own, and
+// the job is saved after each step, so an interrupted push is resumed with the volume(s) which haven't reached the
+// remote yet. The first push of the job is forced, replacing history of master at the remote, just as by PushToGIT.
+// Progress is reported to the Logger the job has been opened with.
+func PushJobWithContext(ctx context.Context, j *Job, gitRepo string) error {
+	started := time.Now()
+	log := loggerOr(j.log)
+
+	// Work directory is locked by this run, so the lock can only be left by git, killed while the job was interrupted.
+	err := os.Remove(filepath.Join(j.dir, gitDir, gitIndexLock))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed removing stale git lock: %w", err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(j.dir, gitDir)); os.IsNotExist(err) {
+		err = j.git(ctx, cmdGitInit)
+		if err != nil {
+			return ErrCmdWrapFn(cmdGit, cmdGitInit, err)
+		}
+
+		err = j.git(ctx, cmdGitRemoteAddOrigin, gitRepo)
+		if err != nil {
+			return fmt.Errorf("failed adding git remote: %w", err)
+		}
+
+		log.Info("Initialized Repo", "repo", redactURL(gitRepo))
+
+		// Commits of the volume(s) went along with the repository, so they're all pushed again.
+		for i := range j.Volumes {
+			j.Volumes[i].Committed, j.Volumes[i].Pushed = false, false
+		}
+	}
+
+	force := len(j.Pending()) == len(j.Volumes)
+
+	for i := range j.Volumes {
+		err := j.pushVolume(ctx, &j.Volumes[i], force)
+		if err != nil {
+			if ctx.Err() != nil {
+				return interruptedError(ctx, err)
+			}
+
+			return err
+		}
+
+		force = force && !j.Volumes[i].Pushed
+	}
+
+	err = j.Complete(JobPushed)
+	if err != nil {
+		return err
+	}
+
+	var bytesOut uint64
+
+	for _, v := range j.Volumes {
+		bytesOut += uint64(v.Size)
+	}
+
+	log.Info(EventPushed, AttrVolumes, len(j.Volumes), AttrDuration, time.Since(started), AttrBytesOut, bytesOut)
+
+	return nil
+}
+
+// pushVolume - commits and pushes the volume, unless it has been already, forcing the push if it's the first one.
+func (j *Job) pushVolume(ctx context.Context, v *JobVolume, force bool) error {
+	if v.Pushed {
+		return nil
+	}
+
+	if !v.Committed {
+		err := j.git(ctx, cmdGitAddVolume, v.Name)
+		if err != nil {
+			return fmt.Errorf("failed staging %s: %w", v.Name, err)
+		}
+
+		// Nothing is staged if the commit has been made by a run interrupted before it has saved the job.
+		if j.git(ctx, cmdGitDiffCachedQuiet) != nil {
+			err = j.git(ctx, cmdGitCommitDashM, commitMessageStatic)
+			if err != nil {
+				return fmt.Errorf("failed committing %s: %w", v.Name, err)
+			}
+		}
+
+		v.Committed = true
+
+		err = j.save(time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	push := cmdGitPushToMaster
+	if force {
+		push = cmdGitForcePushToMaster
+	}
+
+	err := j.git(ctx, push)
+	if err != nil {
+		return remoteError("failed pushing "+v.Name, err)
+	}
+
+	v.Pushed = true
+
+	loggerOr(j.log).Info("Pushed a volume to origin/master", "volume", v.Name)
+
+	return j.save(time.Now())
+}
+
+// git - executes git with the subcommand (which may hold several words) and the args, within the work directory.
+func (j *Job) git(ctx context.Context, subcommand string, args ...string) error {
+	return executeCommandIn(ctx, loggerOr(j.log), j.dir, cmdGit, append(strings.Fields(subcommand), args...))
+}
+
+// JobPushCommands - returns the git commands PushJobWithContext executes, in order, with credentials which the repo
+// URL may hold redacted. The last three are executed for each volume, and only the first push is forced.
+func JobPushCommands(gitRepo string) []string {
+	return []string{
+		fmt.Sprintf("%s %s", cmdGit, cmdGitInit),
+		fmt.Sprintf("%s %s %s", cmdGit, cmdGitRemoteAddOrigin, redactURL(gitRepo)),
+		fmt.Sprintf("%s %s <volume>", cmdGit, cmdGitAddVolume),
+		fmt.Sprintf("%s %s %s", cmdGit, cmdGitCommitDashM, commitMessageStatic),
+		fmt.Sprintf("%s %s", cmdGit, cmdGitForcePushToMaster),
+	}
+}
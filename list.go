@@ -0,0 +1,232 @@
+package ax
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// sltEntriesSeparator - line of `7z l -slt` output, after which the archived entries are listed.
+	sltEntriesSeparator = "----------"
+	sltKeyValSeparator  = " ="
+	sltTimeLayout       = "2006-01-02 15:04:05"
+	sltFlagSet          = "+"
+	sltAttrDir          = "D"
+
+	sltKeyPath       = "Path"
+	sltKeySize       = "Size"
+	sltKeyPackedSize = "Packed Size"
+	sltKeyModified   = "Modified"
+	sltKeyAttributes = "Attributes"
+	sltKeyCRC        = "CRC"
+	sltKeyEncrypted  = "Encrypted"
+	sltKeyFolder     = "Folder"
+	sltKeySymlink    = "Symbolic Link"
+	sltKeyHardLink   = "Hard Link"
+)
+
+// Entry - represents a single file or directory stored in the archive(s).
+type Entry struct {
+	// Path - path of the entry within the archive(s).
+	Path string `json:"path"`
+
+	// Size - size of the entry once extracted, in bytes.
+	Size uint64 `json:"size"`
+
+	// PackedSize - compressed size of the entry, in bytes. It's 0 for all entries of a solid block except the first.
+	PackedSize uint64 `json:"packed_size"`
+
+	// Modified - last modification time of the entry, if the archive(s) hold one.
+	Modified time.Time `json:"modified"`
+
+	// Attributes - attributes of the entry as reported by 7zip, i.e. 'A -rw-r--r--' or 'D drwxr-xr-x'.
+	Attributes string `json:"attributes"`
+
+	// CRC - CRC32 checksum of the entry, in hex. It's empty for directories.
+	CRC string `json:"crc"`
+
+	// Encrypted - if the content of the entry is encrypted.
+	Encrypted bool `json:"encrypted"`
+
+	// IsDir - if the entry is a directory.
+	IsDir bool `json:"is_dir"`
+
+	// Symlink - target of the symbolic link, if the entry is one and the archive format stores it in the headers.
+	Symlink string `json:"symlink,omitempty"`
+
+	// HardLink - path of the archived entry the hard link points to, if the entry is one.
+	HardLink string `json:"hard_link,omitempty"`
+}
+
+// List - used to list the entries of the archive(s), without extracting them.
+// Password has to be set if the archive(s) have been created with HeadersEncryption.
+func List(conf *ExtractConfig) ([]Entry, error) {
+	return ListWithContext(context.Background(), conf)
+}
+
+// ListWithContext - same as List, but it stops once the context is done, killing 7zip if it's running.
+func ListWithContext(ctx context.Context, conf *ExtractConfig) ([]Entry, error) {
+	log := loggerOr(conf.Logger)
+
+	sets, err := FindArchives(conf.archivePath())
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0)
+
+	for _, set := range sets {
+		var listed []Entry
+
+		if set.native() {
+			listed, err = listTar(nativeWalker(ctx, set, conf.EncryptionPassword))
+		} else {
+			listed, err = list7z(ctx, log, conf, set)
+			if err == nil && isMetadataArchive(listed) {
+				listed, err = listTar(sevenZipTarWalker(ctx, log, conf, set))
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed listing %s: %w", set.Path(), err)
+		}
+
+		entries = append(entries, listed...)
+	}
+
+	log.Debug("listed archive(s)", "path", conf.archivePath(), "entries", len(entries))
+
+	return entries, nil
+}
+
+// list7z - lists entries of the archive set using 7zip.
+func list7z(ctx context.Context, log Logger, conf *ExtractConfig, set ArchiveSet) ([]Entry, error) {
+	out, err := outputCommandIn(ctx, log, "", sevenZipCmd(), cmdArgsArchiveList(conf, set.Path()))
+	if err != nil {
+		return nil, fmt.Errorf("failed executing 7zip: %w", err)
+	}
+
+	entries, err := parseSltListing(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing 7zip listing: %w", err)
+	}
+
+	return entries, nil
+}
+
+// cmdArgsArchiveList - used to build command arguments for listing the archive in technical (-slt) format.
+func cmdArgsArchiveList(ec *ExtractConfig, archive string) []string {
+	args := []string{"l", "-slt", "-sccUTF-8"}
+
+	if len(ec.Password) != 0 {
+		args = append(args, fmt.Sprintf("-p%s", ec.Password))
+	}
+
+	return append(args, archive)
+}
+
+// parseSltListing - parses entries listed by `7z l -slt`. Properties of the archive itself, which are printed before
+// the entries, are skipped.
+func parseSltListing(out []byte) ([]Entry, error) {
+	var (
+		entries   = make([]Entry, 0)
+		props     = make(map[string]string)
+		inEntries = false
+	)
+
+	flush := func() error {
+		if len(props) == 0 {
+			return nil
+		}
+
+		e, err := newEntry(props)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, e)
+		props = make(map[string]string)
+
+		return nil
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+
+		if !inEntries {
+			inEntries = line == sltEntriesSeparator
+
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		i := strings.Index(line, sltKeyValSeparator)
+		if i < 0 {
+			continue
+		}
+
+		props[line[:i]] = strings.TrimSpace(line[i+len(sltKeyValSeparator):])
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading listing: %w", err)
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// newEntry - builds Entry from the properties 7zip has listed for it. Missing properties are left blank.
+func newEntry(props map[string]string) (Entry, error) {
+	e := Entry{
+		Path:       props[sltKeyPath],
+		Attributes: props[sltKeyAttributes],
+		CRC:        props[sltKeyCRC],
+		Encrypted:  props[sltKeyEncrypted] == sltFlagSet,
+		IsDir:      props[sltKeyFolder] == sltFlagSet || strings.HasPrefix(props[sltKeyAttributes], sltAttrDir),
+		Symlink:    props[sltKeySymlink],
+		HardLink:   props[sltKeyHardLink],
+	}
+
+	var err error
+
+	if v := props[sltKeySize]; v != "" {
+		e.Size, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return e, fmt.Errorf("invalid size of %s: %w", e.Path, err)
+		}
+	}
+
+	if v := props[sltKeyPackedSize]; v != "" {
+		e.PackedSize, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return e, fmt.Errorf("invalid packed size of %s: %w", e.Path, err)
+		}
+	}
+
+	if v := props[sltKeyModified]; v != "" {
+		// 7zip prints the local time, with optional fractional seconds which Parse accepts as they are.
+		e.Modified, err = time.ParseInLocation(sltTimeLayout, v, time.Local)
+		if err != nil {
+			return e, fmt.Errorf("invalid modification time of %s: %w", e.Path, err)
+		}
+	}
+
+	return e, nil
+}
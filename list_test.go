@@ -0,0 +1,123 @@
+package ax
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSltListing = `
+7-Zip [64] 16.02 : Copyright (c) 1999-2016 Igor Pavlov : 2016-05-21
+
+Scanning the drive for archives:
+1 file, 7340 bytes (8 KiB)
+
+Listing archive: tests/lorem_data_out/test_new_name.7z.001
+
+--
+Path = tests/lorem_data_out/test_new_name.7z.001
+Type = Split
+Physical Size = 7340
+Volumes = 1
+----
+Path = tests/lorem_data_out/test_new_name.7z
+Type = 7z
+Physical Size = 7340
+Headers Size = 244
+Method = LZMA2:24 7zAES
+Solid = +
+Blocks = 1
+
+----------
+Path = lorem_data_in/lorem.md
+Size = 45425
+Packed Size = 7096
+Modified = 2021-03-26 10:11:12.3456789
+Attributes = A -rw-rw-r--
+CRC = 4B2C1D0E
+Encrypted = +
+Method = LZMA2:24 7zAES
+Block = 0
+
+Path = lorem_data_in
+Size = 0
+Packed Size = 0
+Modified = 2021-03-26 10:11:12
+Attributes = D drwxrwxr-x
+CRC =
+Encrypted = -
+Method =
+Block =
+
+`
+
+func (s *Suite) TestUnitParseSltListing() {
+	testCases := []TestCase{
+		{
+			Name: "success archive properties are skipped",
+			Assert: func() {
+				entries, err := parseSltListing([]byte(testSltListing))
+
+				assert.Nil(s.T(), err)
+				assert.Len(s.T(), entries, 2)
+
+				file := entries[0]
+				assert.Equal(s.T(), "lorem_data_in/lorem.md", file.Path)
+				assert.Equal(s.T(), uint64(45425), file.Size)
+				assert.Equal(s.T(), uint64(7096), file.PackedSize)
+				assert.Equal(s.T(), "4B2C1D0E", file.CRC)
+				assert.Equal(s.T(), "A -rw-rw-r--", file.Attributes)
+				assert.True(s.T(), file.Encrypted)
+				assert.False(s.T(), file.IsDir)
+				assert.Equal(s.T(), time.Date(2021, 3, 26, 10, 11, 12, 345678900, time.Local), file.Modified)
+
+				dir := entries[1]
+				assert.True(s.T(), dir.IsDir)
+				assert.False(s.T(), dir.Encrypted)
+				assert.Empty(s.T(), dir.CRC)
+			},
+		},
+		{
+			Name: "success nothing listed",
+			Assert: func() {
+				entries, err := parseSltListing([]byte("7-Zip [64] 16.02\n\nListing archive: x.7z\n"))
+
+				assert.Nil(s.T(), err)
+				assert.Empty(s.T(), entries)
+			},
+		},
+		{
+			Name: "err invalid size",
+			Assert: func() {
+				_, err := parseSltListing([]byte(sltEntriesSeparator + "\nPath = a\nSize = many\n"))
+
+				assert.NotNil(s.T(), err)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitList() {
+	testCases := []TestCase{
+		{
+			Name: "success args with password",
+			Assert: func() {
+				args := cmdArgsArchiveList(&ExtractConfig{Password: []byte("secret"), ArchivePath: "./out"}, "out/a.7z.001")
+
+				assert.Equal(s.T(), []string{"l", "-slt", "-sccUTF-8", "-psecret", "out/a.7z.001"}, args)
+			},
+		},
+		{
+			Name: "err listing archive which does not exist",
+			Assert: func() {
+				_, err := List(&ExtractConfig{ArchivePath: s.T().TempDir()})
+
+				assert.NotNil(s.T(), err)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
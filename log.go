@@ -0,0 +1,57 @@
+package ax
+
+import "sync/atomic"
+
+// Logger - represents the structured logger ax reports its progress to.
+//
+// Methods follow the key/value convention of log/slog, i.e. Info("pushed archive(s)", "repo", repo),
+// so a *slog.Logger can be used as is. By default nothing is logged, see SetDefaultLogger.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// nopLogger - Logger which discards everything, used unless another one has been set.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// loggerHolder - wraps Logger, as atomic.Value requires values of the same concrete type.
+type loggerHolder struct{ Logger }
+
+//nolint:gochecknoglobals // Package-wide default, same as the one in log/slog.
+var defaultLogger atomic.Value
+
+// SetDefaultLogger - sets the Logger used by ax, unless a Logger is set on the config of the call.
+// Passing nil disables logging, which is the default.
+func SetDefaultLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+
+	defaultLogger.Store(loggerHolder{l})
+}
+
+// DefaultLogger - returns the Logger set with SetDefaultLogger.
+func DefaultLogger() Logger {
+	h, ok := defaultLogger.Load().(loggerHolder)
+	if !ok {
+		return nopLogger{}
+	}
+
+	return h.Logger
+}
+
+// loggerOr - returns the Logger set for the call, or the default one if there is none.
+func loggerOr(l Logger) Logger {
+	if l != nil {
+		return l
+	}
+
+	return DefaultLogger()
+}
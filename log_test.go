@@ -0,0 +1,95 @@
+package ax
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingLogger - Logger which records every message, formatted as "LEVEL msg [args]".
+type recordingLogger struct {
+	records []string
+}
+
+func (l *recordingLogger) record(level, msg string, args []interface{}) {
+	l.records = append(l.records, fmt.Sprintf("%s %s %v", level, msg, args))
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.record("DEBUG", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.record("INFO", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.record("WARN", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.record("ERROR", msg, args) }
+
+func (s *Suite) TestUnitLogger() {
+	testCases := []TestCase{
+		{
+			Name: "success nothing is logged by default",
+			Assert: func() {
+				assert.Equal(s.T(), nopLogger{}, DefaultLogger())
+			},
+		},
+		{
+			Name: "success default logger is set and reset",
+			Assert: func() {
+				rec := &recordingLogger{}
+
+				SetDefaultLogger(rec)
+				assert.Equal(s.T(), rec, DefaultLogger())
+				assert.Equal(s.T(), rec, loggerOr(nil))
+
+				SetDefaultLogger(nil)
+				assert.Equal(s.T(), nopLogger{}, DefaultLogger())
+			},
+		},
+		{
+			Name: "success logger of the call takes precedence",
+			Assert: func() {
+				global, perCall := &recordingLogger{}, &recordingLogger{}
+
+				SetDefaultLogger(global)
+				defer SetDefaultLogger(nil)
+
+				dir := s.T().TempDir()
+				s.Require().Nil(os.WriteFile(filepath.Join(dir, "a.7z"), []byte("7z\xBC\xAF\x27\x1C"), 0o600))
+
+				_ = Extract(&ExtractConfig{
+					Password:    []byte("secret"),
+					ArchivePath: dir,
+					Logger:      perCall,
+				})
+
+				assert.Empty(s.T(), global.records)
+				assert.NotEmpty(s.T(), perCall.records)
+				assert.True(s.T(), strings.HasPrefix(perCall.records[0], "DEBUG executing command"))
+				assert.Contains(s.T(), perCall.records[0], redactedPassword)
+				assert.NotContains(s.T(), strings.Join(perCall.records, "\n"), "secret")
+			},
+		},
+		{
+			Name: "success progress reported to default logger",
+			Assert: func() {
+				rec := &recordingLogger{}
+
+				SetDefaultLogger(rec)
+				defer SetDefaultLogger(nil)
+
+				outFilePath := s.T().TempDir()
+				copyFileToEnc(s.T(), testLoremInFile, outFilePath+string(os.PathSeparator)+"lorem.md")
+
+				fl, err := ListFiles(outFilePath, DefaultPathWalkerFunc)
+				assert.Nil(s.T(), err)
+
+				err = DefaultFileEncryption([]byte("defaultPwdKey"), fl)
+				assert.Nil(s.T(), err)
+
+				s.Require().Len(rec.records, 1)
+				assert.Regexp(s.T(), `^INFO Archive\(s\) encrypted! \[count 1 duration \S+ bytes_out \d+\]$`, rec.records[0])
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
@@ -0,0 +1,351 @@
+package ax
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// metadataTarName - name of the tarball, which holds the sources within archive(s) created with PreserveMetadata.
+	metadataTarName = "ax-metadata.tar"
+
+	// paxXattrPrefix - prefix of PAX records holding extended attributes, as written by GNU tar and bsdtar.
+	paxXattrPrefix = "SCHILY.xattr."
+
+	metadataModeMask = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+)
+
+// archiveWithMetadata - archives the entries as a tarball, which is streamed to 7zip, so that their metadata which
+// 7zip doesn't store (ownership, exact modes, symlinks and extended attributes) is preserved.
+func archiveWithMetadata(ctx context.Context, log Logger, conf *ArchiveConfig, root string, entries []string) error {
+	args, err := cmdArgsArchive(conf, "-si"+metadataTarName)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	tarDone := make(chan error, 1)
+
+	go func() {
+		err := writeTar(log, pw, root, entries)
+		_ = pw.CloseWithError(err)
+		tarDone <- err
+	}()
+
+	err = inputCommandIn(ctx, log, root, sevenZipCmd(), args, pr)
+
+	// Unblocks writing of the tarball, if 7zip has exited before reading all of it.
+	_ = pr.Close()
+
+	tarErr := <-tarDone
+	if tarErr != nil && !errors.Is(tarErr, io.ErrClosedPipe) {
+		return fmt.Errorf("failed writing tarball: %w", tarErr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed executing 7zip: %w", err)
+	}
+
+	return nil
+}
+
+// writeTar - writes the entries, relative to root, as a PAX tarball. Parent directories of the entries are written
+// before them, so that their metadata is preserved too.
+func writeTar(log Logger, w io.Writer, root string, entries []string) error {
+	var (
+		tw      = tar.NewWriter(w)
+		written = make(map[string]bool)
+	)
+
+	for _, entry := range entries {
+		rel := path.Clean(filepath.ToSlash(entry))
+
+		for _, name := range append(parentDirs(rel), rel) {
+			if written[name] {
+				continue
+			}
+
+			err := writeTarEntry(log, tw, root, name)
+			if err != nil {
+				return err
+			}
+
+			written[name] = true
+		}
+	}
+
+	err := tw.Close()
+	if err != nil {
+		return fmt.Errorf("failed closing tarball: %w", err)
+	}
+
+	return nil
+}
+
+// parentDirs - returns parent directories of the slash separated path, i.e. 'a', 'a/b' for 'a/b/c'.
+func parentDirs(rel string) []string {
+	dirs := make([]string, 0)
+
+	for i, c := range rel {
+		if c == '/' {
+			dirs = append(dirs, rel[:i])
+		}
+	}
+
+	return dirs
+}
+
+// writeTarEntry - writes header of the entry, holding all of its metadata, followed by its content if it's a file.
+func writeTarEntry(log Logger, tw *tar.Writer, root, name string) error {
+	p := filepath.Join(root, filepath.FromSlash(name))
+
+	info, err := os.Lstat(p)
+	if err != nil {
+		return fmt.Errorf("failed getting stat of %s: %w", p, err)
+	}
+
+	var linkname string
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		linkname, err = os.Readlink(p)
+		if err != nil {
+			return fmt.Errorf("failed reading symlink: %w", err)
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, linkname)
+	if err != nil {
+		log.Warn("skipped unsupported file", "path", p, "err", err)
+
+		return nil
+	}
+
+	hdr.Name, hdr.Format = name, tar.FormatPAX
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	if info.Mode()&os.ModeSymlink == 0 {
+		err = addXattrs(hdr, p)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tw.WriteHeader(hdr)
+	if err != nil {
+		return fmt.Errorf("failed writing header of %s: %w", name, err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	return copyFileTo(tw, p)
+}
+
+// addXattrs - stores extended attributes of the file in PAX records of its header.
+func addXattrs(hdr *tar.Header, p string) error {
+	xattrs, err := readXattrs(p)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range xattrs {
+		if hdr.PAXRecords == nil {
+			hdr.PAXRecords = make(map[string]string)
+		}
+
+		hdr.PAXRecords[paxXattrPrefix+name] = value
+	}
+
+	return nil
+}
+
+func copyFileTo(w io.Writer, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("failed opening file: %w", err)
+	}
+
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	if err != nil {
+		return fmt.Errorf("failed writing %s: %w", p, err)
+	}
+
+	return nil
+}
+
+// isMetadataArchive - reports whether the 7zip archive has been created with PreserveMetadata.
+func isMetadataArchive(entries []Entry) bool {
+	return len(entries) == 1 && !entries[0].IsDir && entries[0].Path == metadataTarName
+}
+
+// deferredDir - represents an extracted directory, whose metadata is restored once all of its content is extracted.
+type deferredDir struct {
+	path   string
+	header *tar.Header
+}
+
+// metadataRestorer - restores metadata of the extracted entries from their tar headers.
+type metadataRestorer struct {
+	log Logger
+
+	// owner - if ownership is restored, which is possible only as root.
+	owner        bool
+	numericOwner bool
+
+	uids map[string]int
+	gids map[string]int
+	dirs []deferredDir
+}
+
+func newMetadataRestorer(log Logger, numericOwner bool) *metadataRestorer {
+	return &metadataRestorer{
+		log:          log,
+		owner:        os.Geteuid() == 0,
+		numericOwner: numericOwner,
+		uids:         make(map[string]int),
+		gids:         make(map[string]int),
+	}
+}
+
+// restore - restores ownership, mode, extended attributes and times of the extracted entry, in that order, as changing
+// the owner clears setuid and setgid bits, while setting anything changes the ctime.
+func (m *metadataRestorer) restore(target string, hdr *tar.Header) error {
+	isSymlink := hdr.Typeflag == tar.TypeSymlink
+
+	if m.owner {
+		uid, gid := m.ownerOf(hdr)
+
+		err := os.Lchown(target, uid, gid)
+		if err != nil {
+			return fmt.Errorf("failed restoring owner: %w", err)
+		}
+	}
+
+	if !isSymlink {
+		err := os.Chmod(target, hdr.FileInfo().Mode()&metadataModeMask)
+		if err != nil {
+			return fmt.Errorf("failed restoring mode: %w", err)
+		}
+
+		m.restoreXattrs(target, hdr)
+	}
+
+	atime := hdr.AccessTime
+	if atime.IsZero() {
+		atime = hdr.ModTime
+	}
+
+	err := lchtimes(target, atime, hdr.ModTime)
+	if err != nil {
+		return fmt.Errorf("failed restoring times: %w", err)
+	}
+
+	return nil
+}
+
+// restoreXattrs - restores extended attributes of the entry. Failures are only reported, as the file system may not
+// support them, or the attribute namespace (i.e. security, trusted) may require privileges.
+func (m *metadataRestorer) restoreXattrs(target string, hdr *tar.Header) {
+	for key, value := range hdr.PAXRecords {
+		if !strings.HasPrefix(key, paxXattrPrefix) {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, paxXattrPrefix)
+
+		err := writeXattr(target, name, value)
+		if err != nil {
+			m.log.Warn("failed restoring extended attribute", "path", target, "name", name, "err", err)
+		}
+	}
+}
+
+// deferDir - defers restoring metadata of the directory, until restoreDirs is called.
+func (m *metadataRestorer) deferDir(target string, hdr *tar.Header) {
+	m.dirs = append(m.dirs, deferredDir{path: target, header: hdr})
+}
+
+// restoreDirs - restores metadata of the deferred directories, the nested ones first, so that restoring a read-only
+// mode of a parent doesn't prevent restoring its children.
+func (m *metadataRestorer) restoreDirs() error {
+	for i := len(m.dirs) - 1; i >= 0; i-- {
+		err := m.restore(m.dirs[i].path, m.dirs[i].header)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.dirs = nil
+
+	return nil
+}
+
+// ownerOf - returns uid and gid the entry should be owned by. Unless numericOwner is set, user and group names are
+// looked up first, just as tar does, falling back to the archived ids if they don't exist on this system.
+func (m *metadataRestorer) ownerOf(hdr *tar.Header) (int, int) {
+	uid, gid := hdr.Uid, hdr.Gid
+
+	if m.numericOwner {
+		return uid, gid
+	}
+
+	if hdr.Uname != "" {
+		if id, ok := lookupID(m.uids, hdr.Uname, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err //nolint:wrapcheck // Only checked for presence.
+			}
+
+			return u.Uid, nil
+		}); ok {
+			uid = id
+		}
+	}
+
+	if hdr.Gname != "" {
+		if id, ok := lookupID(m.gids, hdr.Gname, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err //nolint:wrapcheck // Only checked for presence.
+			}
+
+			return g.Gid, nil
+		}); ok {
+			gid = id
+		}
+	}
+
+	return uid, gid
+}
+
+// lookupID - returns id of the user or group name, caching the result of the lookup, which is -1 if it's missing.
+func lookupID(cache map[string]int, name string, lookup func(name string) (string, error)) (int, bool) {
+	id, ok := cache[name]
+	if !ok {
+		id = -1
+
+		if s, err := lookup(name); err == nil {
+			if n, err := strconv.Atoi(s); err == nil {
+				id = n
+			}
+		}
+
+		cache[name] = id
+	}
+
+	return id, id >= 0
+}
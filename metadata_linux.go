@@ -0,0 +1,95 @@
+//go:build linux
+// +build linux
+
+package ax
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXattrs - returns extended attributes of the file, or none if the file system doesn't support them.
+func readXattrs(path string) (map[string]string, error) {
+	size, err := unix.Llistxattr(path, nil)
+	if errors.Is(err, unix.ENOTSUP) {
+		return nil, nil
+	}
+
+	if err != nil || size == 0 {
+		return nil, wrapXattrErr(path, err)
+	}
+
+	names := make([]byte, size)
+
+	size, err = unix.Llistxattr(path, names)
+	if err != nil {
+		return nil, wrapXattrErr(path, err)
+	}
+
+	xattrs := make(map[string]string)
+
+	for _, name := range strings.Split(string(names[:size]), "\x00") {
+		if name == "" {
+			continue
+		}
+
+		value, err := readXattr(path, name)
+		if err != nil {
+			return nil, err
+		}
+
+		xattrs[name] = value
+	}
+
+	return xattrs, nil
+}
+
+func readXattr(path, name string) (string, error) {
+	size, err := unix.Lgetxattr(path, name, nil)
+	if err != nil {
+		return "", wrapXattrErr(path, err)
+	}
+
+	value := make([]byte, size)
+
+	size, err = unix.Lgetxattr(path, name, value)
+	if err != nil {
+		return "", wrapXattrErr(path, err)
+	}
+
+	return string(value[:size]), nil
+}
+
+func wrapXattrErr(path string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("failed reading extended attributes of %s: %w", path, err)
+}
+
+// writeXattr - sets extended attribute of the file, without following symlinks.
+func writeXattr(path, name, value string) error {
+	err := unix.Lsetxattr(path, name, []byte(value), 0)
+	if err != nil {
+		return fmt.Errorf("failed setting extended attribute: %w", err)
+	}
+
+	return nil
+}
+
+// lchtimes - sets access and modification times of the file, without following symlinks.
+func lchtimes(path string, atime, mtime time.Time) error {
+	ts := []unix.Timespec{unix.NsecToTimespec(atime.UnixNano()), unix.NsecToTimespec(mtime.UnixNano())}
+
+	err := unix.UtimesNanoAt(unix.AT_FDCWD, path, ts, unix.AT_SYMLINK_NOFOLLOW)
+	if err != nil {
+		return fmt.Errorf("failed setting times: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package ax
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+// metadataSnapshot - represents metadata of a file, which should survive archiving and extraction.
+type metadataSnapshot struct {
+	Mode     os.FileMode
+	UID, GID uint32
+	ModTime  time.Time
+	Link     string
+	Xattrs   map[string]string
+}
+
+// snapshotTree - returns metadata of everything within the directory, by the relative paths.
+func (s *Suite) snapshotTree(root string) map[string]metadataSnapshot {
+	tree := make(map[string]metadataSnapshot)
+
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || p == root {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		s.Require().Nil(err)
+
+		stat, _ := info.Sys().(*syscall.Stat_t)
+		snap := metadataSnapshot{Mode: info.Mode(), UID: stat.Uid, GID: stat.Gid, ModTime: info.ModTime()}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			snap.Link, err = os.Readlink(p)
+			s.Require().Nil(err)
+		} else {
+			snap.Xattrs, err = readXattrs(p)
+			s.Require().Nil(err)
+		}
+
+		tree[rel] = snap
+
+		return nil
+	})
+	s.Require().Nil(err)
+
+	return tree
+}
+
+func (s *Suite) TestUnitPreserveMetadata() {
+	var (
+		srcDir, archiveDir, outDir string
+		xattrs                     bool
+		mtime                      = time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+		sources                    = []string{"bin/tool", "etc/app/secret", "etc/app/current", "ro/file"}
+	)
+
+	prepare := func() {
+		srcDir, archiveDir, outDir = s.T().TempDir(), s.T().TempDir(), s.T().TempDir()
+
+		src := func(p string) string { return filepath.Join(srcDir, filepath.FromSlash(p)) }
+
+		for _, dir := range []string{"bin", "etc/app", "ro"} {
+			s.Require().Nil(os.MkdirAll(src(dir), 0o755))
+		}
+
+		for name, mode := range map[string]os.FileMode{
+			"bin/tool":       0o755 | os.ModeSetuid,
+			"etc/app/secret": 0o600,
+			"ro/file":        0o444,
+		} {
+			s.Require().Nil(os.WriteFile(src(name), []byte(name), 0o600))
+
+			if os.Geteuid() == 0 {
+				s.Require().Nil(os.Lchown(src(name), 1234, 1234))
+			}
+
+			s.Require().Nil(os.Chmod(src(name), mode))
+		}
+
+		s.Require().Nil(os.Symlink("secret", src("etc/app/current")))
+
+		err := unix.Lsetxattr(src("etc/app/secret"), "user.ax.origin", []byte("backup"), 0)
+		xattrs = err == nil
+
+		for _, name := range append(sources, "etc/app", "etc", "bin", "ro") {
+			s.Require().Nil(lchtimes(src(name), mtime, mtime))
+		}
+
+		s.Require().Nil(os.Chmod(src("etc/app"), 0o750))
+		s.Require().Nil(os.Chmod(src("ro"), 0o555))
+		s.Require().Nil(lchtimes(src("etc/app"), mtime, mtime))
+		s.Require().Nil(lchtimes(src("ro"), mtime, mtime))
+
+		f, err := os.Create(filepath.Join(archiveDir, "backup.tar"))
+		s.Require().Nil(err)
+		s.Require().Nil(writeTar(nopLogger{}, f, srcDir, sources))
+		s.Require().Nil(f.Close())
+	}
+
+	testCases := []TestCase{
+		{
+			Name:          "success tree is the same after round-trip",
+			PreRequisites: prepare,
+			Assert: func() {
+				err := Extract(&ExtractConfig{ArchivePath: archiveDir, OutputDir: outDir, PreserveMetadata: true})
+				s.Require().Nil(err)
+
+				expected, actual := s.snapshotTree(srcDir), s.snapshotTree(outDir)
+
+				assert.Len(s.T(), actual, len(sources)+4)
+				assert.Equal(s.T(), expected, actual)
+				assert.Equal(s.T(), os.ModeSetuid, actual["bin/tool"].Mode&os.ModeSetuid)
+
+				if xattrs {
+					assert.Equal(s.T(), "backup", actual[filepath.Join("etc", "app", "secret")].Xattrs["user.ax.origin"])
+				}
+			},
+		},
+		{
+			Name:          "success metadata isn't restored by default",
+			PreRequisites: prepare,
+			Assert: func() {
+				s.Require().Nil(Extract(&ExtractConfig{ArchivePath: archiveDir, OutputDir: outDir}))
+
+				actual := s.snapshotTree(outDir)
+
+				assert.False(s.T(), actual["bin"].ModTime.Equal(mtime))
+				assert.Zero(s.T(), actual["bin/tool"].Mode&os.ModeSetuid)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitNumericOwner() {
+	var archiveDir, outDir string
+
+	prepare := func() {
+		if os.Geteuid() != 0 {
+			s.T().Skip("ownership can be restored only as root")
+		}
+
+		archiveDir, outDir = s.T().TempDir(), s.T().TempDir()
+
+		var buf bytes.Buffer
+
+		tw := tar.NewWriter(&buf)
+		s.Require().Nil(tw.WriteHeader(&tar.Header{
+			Name: "owned", Typeflag: tar.TypeReg, Mode: 0o600, Uid: 1234, Gid: 1234, Uname: "root", Gname: "root",
+		}))
+		s.Require().Nil(tw.Close())
+		s.Require().Nil(os.WriteFile(filepath.Join(archiveDir, "owned.tar"), buf.Bytes(), 0o600))
+	}
+
+	ownerOf := func() (uint32, uint32) {
+		info, err := os.Lstat(filepath.Join(outDir, "owned"))
+		s.Require().Nil(err)
+
+		stat, _ := info.Sys().(*syscall.Stat_t)
+
+		return stat.Uid, stat.Gid
+	}
+
+	testCases := []TestCase{
+		{
+			Name:          "success owner restored by names",
+			PreRequisites: prepare,
+			Assert: func() {
+				err := Extract(&ExtractConfig{ArchivePath: archiveDir, OutputDir: outDir, PreserveMetadata: true})
+				s.Require().Nil(err)
+
+				uid, gid := ownerOf()
+				assert.Zero(s.T(), uid)
+				assert.Zero(s.T(), gid)
+			},
+		},
+		{
+			Name:          "success owner restored by ids",
+			PreRequisites: prepare,
+			Assert: func() {
+				err := Extract(&ExtractConfig{
+					ArchivePath: archiveDir, OutputDir: outDir, PreserveMetadata: true, NumericOwner: true,
+				})
+				s.Require().Nil(err)
+
+				uid, gid := ownerOf()
+				assert.Equal(s.T(), uint32(1234), uid)
+				assert.Equal(s.T(), uint32(1234), gid)
+			},
+		},
+		{
+			Name: "success unknown names fall back to ids",
+			Assert: func() {
+				m := newMetadataRestorer(nopLogger{}, false)
+
+				uid, gid := m.ownerOf(&tar.Header{Uid: 42, Gid: 43, Uname: "ax-missing-user", Gname: "ax-missing-group"})
+				assert.Equal(s.T(), 42, uid)
+				assert.Equal(s.T(), 43, gid)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
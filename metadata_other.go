@@ -0,0 +1,39 @@
+//go:build !linux
+// +build !linux
+
+package ax
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// errXattrsUnsupported - extended attributes are supported only on Linux.
+var errXattrsUnsupported = errors.New("extended attributes are not supported on this platform")
+
+// readXattrs - returns no extended attributes, as they're supported only on Linux.
+func readXattrs(string) (map[string]string, error) {
+	return nil, nil
+}
+
+// writeXattr - returns errXattrsUnsupported, as extended attributes are supported only on Linux.
+func writeXattr(string, string, string) error {
+	return errXattrsUnsupported
+}
+
+// lchtimes - sets access and modification times of the file. Times of symlinks are left as they are.
+func lchtimes(path string, atime, mtime time.Time) error {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink != 0 {
+		return nil //nolint:nilerr // Symlinks, or files which are gone, are left as they are.
+	}
+
+	err = os.Chtimes(path, atime, mtime)
+	if err != nil {
+		return fmt.Errorf("failed setting times: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package ax
+
+import (
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitMetadataArchive() {
+	testCases := []TestCase{
+		{
+			Name: "success tarball is streamed to and from 7zip",
+			Assert: func() {
+				ac := NewDefaultArchiveConfig()
+				ac.OutputPath, ac.NewArchiveName = "/tmp/out", "backup"
+
+				args, err := cmdArgsArchive(&ac, "-si"+metadataTarName)
+
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), []string{filepath.Join("/tmp/out", "backup.7z"), "-siax-metadata.tar"}, args[len(args)-2:])
+				assert.Equal(s.T(), []string{"x", "-so", "-ppass", "/tmp/out/backup.7z.001", metadataTarName},
+					cmdArgsArchiveStream(&ExtractConfig{Password: []byte("pass")}, "/tmp/out/backup.7z.001"))
+			},
+		},
+		{
+			Name: "success metadata archive is recognized",
+			Assert: func() {
+				assert.True(s.T(), isMetadataArchive([]Entry{{Path: metadataTarName}}))
+				assert.False(s.T(), isMetadataArchive([]Entry{{Path: metadataTarName, IsDir: true}}))
+				assert.False(s.T(), isMetadataArchive([]Entry{{Path: metadataTarName}, {Path: "etc/hosts"}}))
+				assert.False(s.T(), isMetadataArchive([]Entry{{Path: "backup.tar"}}))
+			},
+		},
+		{
+			Name: "success parent directories",
+			Assert: func() {
+				assert.Equal(s.T(), []string{"etc", "etc/nginx"}, parentDirs("etc/nginx/nginx.conf"))
+				assert.Empty(s.T(), parentDirs("hosts"))
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
@@ -0,0 +1,367 @@
+package ax
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedFormat - archive format isn't handled by the native backend.
+var ErrUnsupportedFormat = errors.New("unsupported archive format")
+
+// nativeEntry - represents an entry of an archive read by the native backend.
+type nativeEntry struct {
+	Entry
+
+	header *tar.Header
+}
+
+// openVolumes - opens every volume of the set, returning a reader of their concatenated content and a func closing
+// all of them.
+func openVolumes(set ArchiveSet) (io.Reader, func(), error) {
+	var (
+		files   = make([]*os.File, 0, len(set.Volumes))
+		readers = make([]io.Reader, 0, len(set.Volumes))
+	)
+
+	closeAll := func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}
+
+	for _, vol := range set.Volumes {
+		f, err := os.Open(vol)
+		if err != nil {
+			closeAll()
+
+			return nil, nil, fmt.Errorf("failed opening volume: %w", err)
+		}
+
+		files = append(files, f)
+		readers = append(readers, f)
+	}
+
+	return io.MultiReader(readers...), closeAll, nil
+}
+
+// tarWalker - reads a tarball entry by entry, passing each one to fn along with the reader of its content.
+type tarWalker func(fn func(e *nativeEntry, r io.Reader) error) error
+
+// nativeWalker - returns tarWalker reading the archive set with the native backend, which decrypts the archive(s)
+// written by StreamArchive with the encryption password.
+// Unlike 7zip, the native backend reads split volumes as a single stream, so none of them can be missing.
+func nativeWalker(ctx context.Context, set ArchiveSet, encryptionPassword []byte) tarWalker {
+	return func(fn func(e *nativeEntry, r io.Reader) error) error {
+		err := walkNative(ctx, set, encryptionPassword, fn)
+
+		// Reading fails in whatever way once the context is done, while it's the cause of the failure.
+		if ctxErr := ctx.Err(); err != nil && ctxErr != nil && !errors.Is(err, ctxErr) {
+			return fmt.Errorf("%w: %v", ctxErr, err)
+		}
+
+		return err
+	}
+}
+
+// walkNative - reads the archive set with the native backend, see nativeWalker.
+func walkNative(
+	ctx context.Context, set ArchiveSet, encryptionPassword []byte, fn func(e *nativeEntry, r io.Reader) error,
+) error {
+	r, closeAll, err := openVolumes(set)
+	if err != nil {
+		return err
+	}
+
+	defer closeAll()
+
+	r = &contextReader{ctx: ctx, r: r}
+
+	switch set.Format {
+	case FormatTar:
+	case FormatTarGzip:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrIntegrity, err)
+		}
+
+		defer gr.Close()
+
+		r = gr
+	case FormatTarBzip2:
+		r = bzip2.NewReader(r)
+	case FormatStream:
+		r, err = decryptStream(r, encryptionPassword)
+		if err != nil {
+			return err
+		}
+
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrIntegrity, err)
+		}
+
+		defer gr.Close()
+
+		r = gr
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, set.Format)
+	}
+
+	return walkTar(r, set.Path(), fn)
+}
+
+// walkTar - reads the tarball from r entry by entry, passing each one to fn along with the reader of its content.
+func walkTar(r io.Reader, name string, fn func(e *nativeEntry, r io.Reader) error) error {
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return drain(name, r)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w: failed reading %s: %v", ErrIntegrity, name, err)
+		}
+
+		err = fn(newNativeEntry(hdr), tr)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// drain - reads the rest of the stream past the end of the tarball, so that the checksum of its compression, stored at
+// the very end, is verified too.
+func drain(name string, r io.Reader) error {
+	_, err := io.Copy(io.Discard, r)
+	if err != nil {
+		return fmt.Errorf("%w: failed reading %s: %v", ErrIntegrity, name, err)
+	}
+
+	return nil
+}
+
+func newNativeEntry(hdr *tar.Header) *nativeEntry {
+	var (
+		info              = hdr.FileInfo()
+		symlink, hardLink string
+	)
+
+	switch hdr.Typeflag {
+	case tar.TypeSymlink:
+		symlink = hdr.Linkname
+	case tar.TypeLink:
+		hardLink = hdr.Linkname
+	}
+
+	return &nativeEntry{
+		Entry: Entry{
+			Path:       strings.TrimSuffix(hdr.Name, "/"),
+			Size:       uint64(hdr.Size),
+			PackedSize: uint64(hdr.Size),
+			Modified:   hdr.ModTime,
+			Attributes: info.Mode().String(),
+			IsDir:      hdr.Typeflag == tar.TypeDir,
+			Symlink:    symlink,
+			HardLink:   hardLink,
+		},
+		header: hdr,
+	}
+}
+
+// listTar - lists entries of the tarball read by the walker.
+func listTar(walk tarWalker) ([]Entry, error) {
+	entries := make([]Entry, 0)
+
+	err := walk(func(e *nativeEntry, _ io.Reader) error {
+		entries = append(entries, e.Entry)
+
+		return nil
+	})
+
+	return entries, err
+}
+
+// testNative - reads the whole archive set, which verifies the checksums of its compression, if it has any.
+func testNative(ctx context.Context, set ArchiveSet, encryptionPassword []byte) error {
+	return nativeWalker(ctx, set, encryptionPassword)(func(e *nativeEntry, r io.Reader) error {
+		_, err := io.Copy(io.Discard, r)
+		if err != nil {
+			return fmt.Errorf("%w: failed reading %s: %v", ErrIntegrity, e.Path, err)
+		}
+
+		return nil
+	})
+}
+
+// nativeOptions - represents options of the extraction by the native backend.
+type nativeOptions struct {
+	outDir      string
+	overwrite   OverwritePolicy
+	allowUnsafe bool
+	selected    func(e *Entry) bool
+
+	// metadata - if set, metadata of the entries is restored by the restorer.
+	metadata *metadataRestorer
+}
+
+// extractTar - extracts entries of the tarball, accepted by `selected`, into outDir. Files which already exist are
+// handled according to the overwrite policy, while existing directories are merged into.
+//
+// Unless unsafe paths are allowed, every entry is checked again not to be extracted through a symlink resolving outside
+// of outDir, on top of the checks done before extraction, in case outDir has been changed since.
+func extractTar(log Logger, walk tarWalker, opts nativeOptions) error {
+	err := mkdirAll(opts.outDir)
+	if err != nil {
+		return err
+	}
+
+	root, err := filepath.EvalSymlinks(opts.outDir)
+	if err != nil {
+		return fmt.Errorf("failed resolving output dir: %w", err)
+	}
+
+	err = walk(func(e *nativeEntry, r io.Reader) error {
+		if !opts.selected(&e.Entry) {
+			return nil
+		}
+
+		return extractTarEntry(log, root, e, r, opts)
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.metadata != nil {
+		return opts.metadata.restoreDirs()
+	}
+
+	return nil
+}
+
+// extractTarEntry - extracts a single entry of the tarball, see extractTar.
+func extractTarEntry(log Logger, root string, e *nativeEntry, r io.Reader, opts nativeOptions) error {
+	target := filepath.Join(opts.outDir, filepath.FromSlash(e.Path))
+
+	if !opts.allowUnsafe {
+		err := ensureWithin(root, target, e.Path)
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.header.Typeflag == tar.TypeDir {
+		err := mkdirAll(target)
+		if err == nil && opts.metadata != nil {
+			// Directories are restored last, as extracting their content changes their modification time.
+			opts.metadata.deferDir(target, e.header)
+		}
+
+		return err
+	}
+
+	target, ok, err := resolveTarget(log, opts.overwrite, target)
+	if err != nil || !ok {
+		return err
+	}
+
+	switch e.header.Typeflag {
+	case tar.TypeReg:
+		err = writeNativeFile(target, e, r)
+	case tar.TypeSymlink:
+		if !opts.allowUnsafe {
+			err = ensureSymlinkWithin(root, target, e.header.Linkname, e.Path)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = symlink(e.header.Linkname, target)
+	case tar.TypeLink:
+		oldname := filepath.Join(opts.outDir, filepath.FromSlash(e.header.Linkname))
+
+		if !opts.allowUnsafe {
+			err = ensureWithin(root, oldname, e.Path)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Hard link shares metadata with the file it's linked to, which has been restored already.
+		return link(oldname, target)
+	default:
+		log.Warn("skipped unsupported entry", "path", e.Path, "type", string(e.header.Typeflag))
+
+		return nil
+	}
+
+	if err != nil || opts.metadata == nil {
+		return err
+	}
+
+	return opts.metadata.restore(target, e.header)
+}
+
+func mkdirAll(dir string) error {
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed creating dir: %w", err)
+	}
+
+	return nil
+}
+
+func writeNativeFile(target string, e *nativeEntry, r io.Reader) error {
+	err := mkdirAll(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+
+	err = writePartFile(target, e.header.FileInfo().Mode().Perm(), func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed writing %s: %w", e.Path, err)
+	}
+
+	return nil
+}
+
+func symlink(oldname, target string) error {
+	err := mkdirAll(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+
+	err = os.Symlink(oldname, target)
+	if err != nil {
+		return fmt.Errorf("failed creating symlink: %w", err)
+	}
+
+	return nil
+}
+
+func link(oldname, target string) error {
+	err := mkdirAll(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+
+	err = os.Link(oldname, target)
+	if err != nil {
+		return fmt.Errorf("failed creating hard link: %w", err)
+	}
+
+	return nil
+}
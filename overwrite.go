@@ -0,0 +1,127 @@
+package ax
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OverwritePolicy - represents what happens when an extracted file already exists in the output directory.
+type OverwritePolicy string
+
+// Overwrite policies. Directories which already exist are always merged into, regardless of the policy.
+const (
+	// OverwriteFail - nothing is extracted if any of the files already exists (default).
+	OverwriteFail OverwritePolicy = "fail"
+
+	// OverwriteSkip - existing files are kept as they are, and the archived ones are skipped.
+	OverwriteSkip OverwritePolicy = "skip"
+
+	// OverwriteAll - existing files are replaced by the archived ones.
+	OverwriteAll OverwritePolicy = "overwrite"
+
+	// OverwriteRename - archived files are extracted under a new name, i.e. 'nginx_1.conf', next to the existing ones.
+	OverwriteRename OverwritePolicy = "rename"
+)
+
+var (
+	// ErrInvalidOverwritePolicy - overwrite policy is none of the supported ones.
+	ErrInvalidOverwritePolicy = errors.New("invalid overwrite policy")
+
+	// ErrOutputExists - extracted file already exists in the output directory, while OverwriteFail is in effect.
+	ErrOutputExists = errors.New("extracted file already exists")
+)
+
+// ParseOverwritePolicy - parses the name of the overwrite policy, returning OverwriteFail if it's blank.
+func ParseOverwritePolicy(name string) (OverwritePolicy, error) {
+	p := OverwritePolicy(strings.ToLower(strings.TrimSpace(name)))
+
+	switch p {
+	case "":
+		return OverwriteFail, nil
+	case OverwriteFail, OverwriteSkip, OverwriteAll, OverwriteRename:
+		return p, nil
+	default:
+		return "", fmt.Errorf("%w: %q (use %s, %s, %s or %s)",
+			ErrInvalidOverwritePolicy, name, OverwriteFail, OverwriteSkip, OverwriteAll, OverwriteRename)
+	}
+}
+
+// switch7z - returns 7zip -ao switch matching the policy. OverwriteFail is enforced by checkConflicts before 7zip is
+// run, so it maps to skipping, which leaves existing files untouched either way.
+func (p OverwritePolicy) switch7z() string {
+	switch p {
+	case OverwriteAll:
+		return "-aoa"
+	case OverwriteRename:
+		return "-aou"
+	default:
+		return "-aos"
+	}
+}
+
+// checkConflicts - returns ErrOutputExists listing the (slash separated) paths which already exist within outDir.
+func checkConflicts(outDir string, paths []string) error {
+	existing := make([]string, 0)
+
+	for _, p := range paths {
+		if _, err := os.Lstat(filepath.Join(outDir, filepath.FromSlash(p))); err == nil {
+			existing = append(existing, p)
+		}
+	}
+
+	if len(existing) == 0 {
+		return nil
+	}
+
+	const maxListed = 5
+
+	listed := existing
+	if len(listed) > maxListed {
+		listed = listed[:maxListed]
+	}
+
+	return fmt.Errorf("%w in %s: %s (%d in total, choose another overwrite policy)",
+		ErrOutputExists, outDir, strings.Join(listed, ", "), len(existing))
+}
+
+// resolveTarget - applies the policy to the extracted file, returning path it should be written to, or false if it
+// should be skipped. Existing files which are overwritten are removed first, so that the new one doesn't inherit them.
+func resolveTarget(log Logger, policy OverwritePolicy, target string) (string, bool, error) {
+	if _, err := os.Lstat(target); err != nil {
+		return target, true, nil
+	}
+
+	switch policy {
+	case OverwriteSkip:
+		log.Debug("skipped existing file", "path", target)
+
+		return "", false, nil
+	case OverwriteAll:
+		err := os.Remove(target)
+		if err != nil {
+			return "", false, fmt.Errorf("failed removing existing file: %w", err)
+		}
+
+		return target, true, nil
+	case OverwriteRename:
+		return renamedPath(target), true, nil
+	default:
+		return "", false, fmt.Errorf("%w: %s", ErrOutputExists, target)
+	}
+}
+
+// renamedPath - returns the first free path in the 'name_N.ext' form, just as 7zip renames them.
+func renamedPath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		if _, err := os.Lstat(candidate); err != nil {
+			return candidate
+		}
+	}
+}
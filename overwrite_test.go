@@ -0,0 +1,148 @@
+package ax
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitParseOverwritePolicy() {
+	testCases := []TestCase{
+		{
+			Name: "success policies",
+			Assert: func() {
+				for name, expected := range map[string]OverwritePolicy{
+					"":          OverwriteFail,
+					"fail":      OverwriteFail,
+					"skip":      OverwriteSkip,
+					"Overwrite": OverwriteAll,
+					" rename ":  OverwriteRename,
+				} {
+					p, err := ParseOverwritePolicy(name)
+
+					assert.Nil(s.T(), err)
+					assert.Equal(s.T(), expected, p)
+				}
+			},
+		},
+		{
+			Name: "err unknown policy",
+			Assert: func() {
+				_, err := ParseOverwritePolicy("replace")
+
+				assert.ErrorIs(s.T(), err, ErrInvalidOverwritePolicy)
+			},
+		},
+		{
+			Name: "success 7zip switches",
+			Assert: func() {
+				ec := &ExtractConfig{ArchivePath: "./in", OutputDir: "./out"}
+
+				for p, expected := range map[OverwritePolicy]string{
+					"":              "-aos",
+					OverwriteFail:   "-aos",
+					OverwriteSkip:   "-aos",
+					OverwriteAll:    "-aoa",
+					OverwriteRename: "-aou",
+				} {
+					ec.Overwrite = p
+
+					assert.Equal(s.T(), []string{"x", "in/a.7z", "-o./out", expected},
+						cmdArgsArchiveExtract(ec, "in/a.7z"))
+				}
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitExtractOverwrite() {
+	var archiveDir, outDir string
+
+	prepare := func() {
+		archiveDir, outDir = s.T().TempDir(), s.T().TempDir()
+		s.Require().Nil(os.WriteFile(filepath.Join(archiveDir, "etc.tar"),
+			s.testTarball(false, "etc/", "etc/hosts", "etc/fstab"), 0o600))
+
+		s.Require().Nil(os.Mkdir(filepath.Join(outDir, "etc"), 0o700))
+		s.Require().Nil(os.WriteFile(filepath.Join(outDir, "etc", "hosts"), []byte("live"), 0o600))
+	}
+
+	readFile := func(path ...string) string {
+		content, err := os.ReadFile(filepath.Join(append([]string{outDir}, path...)...))
+		s.Require().Nil(err)
+
+		return string(content)
+	}
+
+	extract := func(p OverwritePolicy) error {
+		return Extract(&ExtractConfig{ArchivePath: archiveDir, OutputDir: outDir, Overwrite: p})
+	}
+
+	testCases := []TestCase{
+		{
+			Name:          "success archives are kept apart from the output",
+			PreRequisites: prepare,
+			Assert: func() {
+				assert.Nil(s.T(), extract(OverwriteSkip))
+
+				assert.NoDirExists(s.T(), filepath.Join(archiveDir, "etc"))
+				assert.FileExists(s.T(), filepath.Join(outDir, "etc", "fstab"))
+			},
+		},
+		{
+			Name:          "success skip",
+			PreRequisites: prepare,
+			Assert: func() {
+				assert.Nil(s.T(), extract(OverwriteSkip))
+
+				assert.Equal(s.T(), "live", readFile("etc", "hosts"))
+				assert.Equal(s.T(), "etc/fstab", readFile("etc", "fstab"))
+			},
+		},
+		{
+			Name:          "success overwrite",
+			PreRequisites: prepare,
+			Assert: func() {
+				assert.Nil(s.T(), extract(OverwriteAll))
+
+				assert.Equal(s.T(), "etc/hosts", readFile("etc", "hosts"))
+			},
+		},
+		{
+			Name:          "success rename",
+			PreRequisites: prepare,
+			Assert: func() {
+				assert.Nil(s.T(), extract(OverwriteRename))
+				assert.Nil(s.T(), extract(OverwriteRename))
+
+				assert.Equal(s.T(), "live", readFile("etc", "hosts"))
+				assert.Equal(s.T(), "etc/hosts", readFile("etc", "hosts_1"))
+				assert.Equal(s.T(), "etc/hosts", readFile("etc", "hosts_2"))
+				assert.Equal(s.T(), "etc/fstab", readFile("etc", "fstab_1"))
+			},
+		},
+		{
+			Name:          "err fail leaves output untouched",
+			PreRequisites: prepare,
+			Assert: func() {
+				err := extract(OverwriteFail)
+
+				assert.ErrorIs(s.T(), err, ErrOutputExists)
+				assert.Contains(s.T(), err.Error(), "etc/hosts")
+				assert.NoFileExists(s.T(), filepath.Join(outDir, "etc", "fstab"))
+			},
+		},
+		{
+			Name:          "err invalid policy",
+			PreRequisites: prepare,
+			Assert: func() {
+				assert.ErrorIs(s.T(), extract("replace"), ErrInvalidOverwritePolicy)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
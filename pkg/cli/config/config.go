@@ -0,0 +1,360 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configDirName  = "ax"
+	configFileName = "config.yaml"
+)
+
+var (
+	// ErrProfileNotFound - profile requested isn't defined in the config file.
+	ErrProfileNotFound = errors.New("profile not found")
+
+	// ErrNoProfiles - config file doesn't define a single profile.
+	ErrNoProfiles = errors.New("config file defines no profiles")
+)
+
+// Config - represents the declarative ax config file, holding named backup profiles.
+//
+// Example:
+//
+//	profiles:
+//	  nginx:
+//	    sources: [/etc/nginx]
+//	    output: /var/tmp/ax_nginx
+//	    name: nginx
+//	    repo: git@github.com:USER/nginx-backup.git
+//	    archive:
+//	      volume_size: 50
+//	      compression: 7
+//	    schedule: "0 3 * * *"
+//	daemon:
+//	  concurrency: 2
+//	  jitter: 10m
+type Config struct {
+	// Profiles - named backup profiles, one of which is selected with the -profile flag.
+	Profiles map[string]Profile `yaml:"profiles"`
+
+	// Daemon - settings of `ax daemon`, which pushes the profiles having a schedule.
+	Daemon DaemonSettings `yaml:"daemon"`
+}
+
+// DaemonSettings - represents settings of `ax daemon`, each of which is used unless its flag has been provided.
+type DaemonSettings struct {
+	// Socket - path of the Unix socket `ax status` queries the daemon on.
+	Socket string `yaml:"socket"`
+
+	// Concurrency - maximum number of profiles pushed at once (default 1, pushing them sequentially).
+	Concurrency uint `yaml:"concurrency"`
+
+	// Jitter - maximum random delay of each push, i.e. 10m, spreading pushes scheduled for the same time.
+	Jitter string `yaml:"jitter"`
+
+	// History - path of the file recording the most recent pushes, and the number of them it keeps.
+	History     string `yaml:"history"`
+	HistorySize int    `yaml:"history_size"`
+
+	// MetricsAddr - address the metrics of the pushes are served on for Prometheus, i.e. :9184 (not served if empty).
+	MetricsAddr string `yaml:"metrics_addr"`
+}
+
+// Profile - represents a single named backup profile.
+//
+// Every value set in a profile is used as the value of the matching flag, unless that flag has been provided on the
+// command line or through its AX_* environment variable.
+type Profile struct {
+	// Sources - paths which should be archived.
+	Sources []string `yaml:"sources"`
+
+	// Root - directory the sources are stored relative to, within the archive(s).
+	Root string `yaml:"root"`
+
+	// Include - gitignore-style patterns; if set, only files matching one of them are archived.
+	Include []string `yaml:"include"`
+
+	// Exclude - gitignore-style patterns of files which aren't archived, on top of the .axignore in the source.
+	Exclude []string `yaml:"exclude"`
+
+	// Output - path where the (temporary) archive(s) are stored, and where they are extracted from.
+	Output string `yaml:"output"`
+
+	// ExtractOut - directory the archive(s) are extracted into by `ax extract` and `ax restore`, instead of the one
+	// holding them.
+	ExtractOut string `yaml:"extract_out"`
+
+	// Name - base for the name of the output archive(s).
+	Name string `yaml:"name"`
+
+	// Repo - remote GIT Repository where the backup is persisted.
+	Repo string `yaml:"repo"`
+
+	// Protect - if the archive(s) should be protected with a password.
+	Protect *bool `yaml:"protect"`
+
+	// Verify - if integrity of the archive(s) is tested before they're encrypted and pushed (default true).
+	Verify *bool `yaml:"verify"`
+
+	// Stream - if the archive is streamed through the encryption into volumes, so it's never written unencrypted.
+	Stream *bool `yaml:"stream"`
+
+	// Overwrite - what happens with extracted files which already exist: fail (default), skip, overwrite or rename.
+	Overwrite string `yaml:"overwrite"`
+
+	// NumericOwner - if ownership of extracted entries is restored by the archived uid and gid, instead of names.
+	NumericOwner *bool `yaml:"numeric_owner"`
+
+	// Archive - settings forwarded to ax.ArchiveConfig.
+	Archive ArchiveSettings `yaml:"archive"`
+
+	// ArchivePassword - non-interactive source of the password protecting the archive(s).
+	ArchivePassword PasswordSource `yaml:"archive_password"`
+
+	// EncryptionPassword - non-interactive source of the password used for encryption of the archive volume(s).
+	EncryptionPassword PasswordSource `yaml:"encryption_password"`
+
+	// Schedule - cron expression (i.e. '0 3 * * *' or '@daily') of the pushes of the profile by `ax daemon`.
+	// Profiles without one are left out by the daemon.
+	Schedule string `yaml:"schedule"`
+
+	// Watch - settings of `ax watch`, which pushes the profile whenever its sources change.
+	Watch WatchSettings `yaml:"watch"`
+
+	// Hooks - what's run after each push of the profile, to report its outcome.
+	Hooks HookSettings `yaml:"hooks"`
+
+	// MetricsFile - file the metrics of each push are written into, in the node_exporter textfile collector format.
+	MetricsFile string `yaml:"metrics_file"`
+}
+
+// WatchSettings - represents the timing of `ax watch`, as durations, i.e. 2s or 5m.
+type WatchSettings struct {
+	Debounce     string `yaml:"debounce"`
+	MinInterval  string `yaml:"min_interval"`
+	MaxBackoff   string `yaml:"max_backoff"`
+	PollInterval string `yaml:"poll_interval"`
+}
+
+// HookSettings - represents the hooks reporting the outcome of a push. Each of them runs on every outcome (success,
+// failure or warning), unless it's limited to some of them.
+type HookSettings struct {
+	// Command - shell command, receiving the JSON result of the push on its stdin.
+	Command   string   `yaml:"command"`
+	CommandOn []string `yaml:"command_on"`
+
+	// Webhook - URL the JSON result of the push is POSTed to.
+	Webhook   string   `yaml:"webhook"`
+	WebhookOn []string `yaml:"webhook_on"`
+
+	// StatusFile - path of the file the JSON result of the latest push is written into.
+	StatusFile   string   `yaml:"status_file"`
+	StatusFileOn []string `yaml:"status_file_on"`
+}
+
+// PasswordSource - represents where a password is read from, so that ax can run without a terminal (i.e. from cron).
+type PasswordSource struct {
+	// File - path of the file holding the password.
+	File string `yaml:"file"`
+
+	// Command - command printing the password to its stdout, i.e. `pass show backups/ax`.
+	Command string `yaml:"command"`
+}
+
+// ArchiveSettings - represents ax.ArchiveConfig fields which can be set from a profile.
+type ArchiveSettings struct {
+	Type              string  `yaml:"type"`
+	BlockSize         string  `yaml:"block_size"`
+	VolumeSize        string  `yaml:"volume_size"`
+	FastBytes         *uint16 `yaml:"fast_bytes"`
+	DictSize          string  `yaml:"dict_size"`
+	Compression       *uint8  `yaml:"compression"`
+	HeadersEncryption *bool   `yaml:"headers_encryption"`
+	SolidArchive      *bool   `yaml:"solid"`
+	PreserveMetadata  *bool   `yaml:"preserve_metadata"`
+	SolidBlockSize    string  `yaml:"solid_block_size"`
+	Method            string  `yaml:"method"`
+	Threads           *uint16 `yaml:"threads"`
+}
+
+// Keys of the profile values. Each key is also the suffix of the environment variable overriding it, i.e. AX_OUTPUT.
+const (
+	KeySources           = "sources"
+	KeyRoot              = "root"
+	KeyInclude           = "include"
+	KeyExclude           = "exclude"
+	KeyOutput            = "output"
+	KeyExtractOut        = "extract_out"
+	KeyName              = "name"
+	KeyRepo              = "repo"
+	KeyProtect           = "protect"
+	KeyVerify            = "verify"
+	KeyStream            = "stream"
+	KeyOverwrite         = "overwrite"
+	KeyType              = "type"
+	KeyBlockSize         = "block_size"
+	KeyVolumeSize        = "volume_size"
+	KeyFastBytes         = "fast_bytes"
+	KeyDictSize          = "dict_size"
+	KeyCompression       = "compression"
+	KeyHeadersEncryption = "headers_encryption"
+	KeySolidArchive      = "solid"
+	KeyPreserveMetadata  = "preserve_metadata"
+	KeySolidBlockSize    = "solid_block_size"
+	KeyMethod            = "method"
+	KeyThreads           = "threads"
+	KeyNumericOwner      = "numeric_owner"
+	KeyDebounce          = "debounce"
+	KeyMinInterval       = "min_interval"
+	KeyMaxBackoff        = "max_backoff"
+	KeyPollInterval      = "poll_interval"
+	KeyHookCommand       = "hook_command"
+	KeyHookCommandOn     = "hook_command_on"
+	KeyHookWebhook       = "hook_webhook"
+	KeyHookWebhookOn     = "hook_webhook_on"
+	KeyStatusFile        = "status_file"
+	KeyStatusFileOn      = "status_file_on"
+	KeyMetricsFile       = "metrics_file"
+
+	KeyArchivePasswordFile       = "archive_password_file"
+	KeyArchivePasswordCommand    = "archive_password_command"
+	KeyEncryptionPasswordFile    = "encryption_password_file"
+	KeyEncryptionPasswordCommand = "encryption_password_command"
+)
+
+// DefaultPath - returns the default location of the config file, i.e. ~/.config/ax/config.yaml on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed resolving user config dir: %w", err)
+	}
+
+	return filepath.Join(dir, configDirName, configFileName), nil
+}
+
+// Load - reads and parses the config file at the chosen path. Unknown keys are rejected, to surface typos early.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening config file: %w", err)
+	}
+
+	defer f.Close()
+
+	var conf Config
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+
+	err = dec.Decode(&conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing config file [%s]: %w", path, err)
+	}
+
+	if len(conf.Profiles) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoProfiles, path)
+	}
+
+	return &conf, nil
+}
+
+// Profile - returns the profile with the chosen name.
+func (c *Config) Profile(name string) (*Profile, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+	}
+
+	return &p, nil
+}
+
+// Values - returns all values set in the profile, as strings keyed by their Key* constant.
+//
+// Values are formatted in the same way they would have been typed on the command line.
+func (p *Profile) Values() map[string][]string {
+	vals := make(map[string][]string)
+
+	setStr := func(key, v string) {
+		if v != "" {
+			vals[key] = []string{v}
+		}
+	}
+
+	setBool := func(key string, v *bool) {
+		if v != nil {
+			vals[key] = []string{strconv.FormatBool(*v)}
+		}
+	}
+
+	setUint := func(key string, v uint64) {
+		vals[key] = []string{strconv.FormatUint(v, 10)}
+	}
+
+	setList := func(key string, v []string) {
+		if len(v) > 0 {
+			vals[key] = v
+		}
+	}
+
+	setList(KeySources, p.Sources)
+	setStr(KeyRoot, p.Root)
+	setList(KeyInclude, p.Include)
+	setList(KeyExclude, p.Exclude)
+
+	setStr(KeyOutput, p.Output)
+	setStr(KeyExtractOut, p.ExtractOut)
+	setStr(KeyName, p.Name)
+	setStr(KeyRepo, p.Repo)
+	setBool(KeyProtect, p.Protect)
+	setBool(KeyVerify, p.Verify)
+	setBool(KeyStream, p.Stream)
+	setStr(KeyOverwrite, p.Overwrite)
+	setBool(KeyNumericOwner, p.NumericOwner)
+	setStr(KeyArchivePasswordFile, p.ArchivePassword.File)
+	setStr(KeyArchivePasswordCommand, p.ArchivePassword.Command)
+	setStr(KeyEncryptionPasswordFile, p.EncryptionPassword.File)
+	setStr(KeyEncryptionPasswordCommand, p.EncryptionPassword.Command)
+	setStr(KeyDebounce, p.Watch.Debounce)
+	setStr(KeyMinInterval, p.Watch.MinInterval)
+	setStr(KeyMaxBackoff, p.Watch.MaxBackoff)
+	setStr(KeyPollInterval, p.Watch.PollInterval)
+	setStr(KeyHookCommand, p.Hooks.Command)
+	setList(KeyHookCommandOn, p.Hooks.CommandOn)
+	setStr(KeyHookWebhook, p.Hooks.Webhook)
+	setList(KeyHookWebhookOn, p.Hooks.WebhookOn)
+	setStr(KeyStatusFile, p.Hooks.StatusFile)
+	setList(KeyStatusFileOn, p.Hooks.StatusFileOn)
+	setStr(KeyMetricsFile, p.MetricsFile)
+
+	a := p.Archive
+	setStr(KeyType, a.Type)
+	setStr(KeyBlockSize, a.BlockSize)
+	setStr(KeyVolumeSize, a.VolumeSize)
+	setStr(KeyDictSize, a.DictSize)
+	setStr(KeySolidBlockSize, a.SolidBlockSize)
+	setStr(KeyMethod, a.Method)
+	setBool(KeyHeadersEncryption, a.HeadersEncryption)
+	setBool(KeySolidArchive, a.SolidArchive)
+	setBool(KeyPreserveMetadata, a.PreserveMetadata)
+
+	if a.FastBytes != nil {
+		setUint(KeyFastBytes, uint64(*a.FastBytes))
+	}
+
+	if a.Threads != nil {
+		setUint(KeyThreads, uint64(*a.Threads))
+	}
+
+	if a.Compression != nil {
+		setUint(KeyCompression, uint64(*a.Compression))
+	}
+
+	return vals
+}
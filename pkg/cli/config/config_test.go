@@ -0,0 +1,182 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaynetik/ax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const testConfigYAML = `
+profiles:
+  nginx:
+    sources: [/etc/nginx, /etc/ssl]
+    exclude: ["*.bak", "cache/"]
+    output: /var/tmp/ax_nginx
+    extract_out: /srv/nginx
+    name: nginx
+    repo: git@github.com:USER/nginx-backup.git
+    protect: false
+    archive:
+      volume_size: 50
+      block_size: m
+      compression: 7
+      dict_size: 256
+      solid: false
+      solid_block_size: 1.5g
+      threads: 4
+    watch:
+      debounce: 5s
+    hooks:
+      webhook: https://hooks.example.com/ax
+      webhook_on: [failure, warning]
+    metrics_file: /var/lib/node_exporter/textfile/ax.prom
+  notes:
+    sources: [/home/user/notes]
+    schedule: "@daily"
+daemon:
+  concurrency: 2
+  jitter: 10m
+  metrics_addr: ":9184"
+`
+
+type Suite struct {
+	suite.Suite
+
+	// configPath - path of the config file written for the test case.
+	configPath string
+}
+
+func TestSuite(t *testing.T) {
+	t.Parallel()
+
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) writeConfig(content string) {
+	s.configPath = filepath.Join(s.T().TempDir(), configFileName)
+
+	err := os.WriteFile(s.configPath, []byte(content), 0o600)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+func (s *Suite) TestUnitLoad() {
+	testCases := []ax.TestCase{
+		{
+			Name: "err config file does not exist",
+			Assert: func() {
+				_, err := Load("./does-not-exist.yaml")
+
+				assert.NotNil(s.T(), err)
+			},
+		},
+		{
+			Name: "err unknown key",
+			PreRequisites: func() {
+				s.writeConfig("profiles:\n  nginx:\n    sourcez: [/etc/nginx]\n")
+			},
+			Assert: func() {
+				_, err := Load(s.configPath)
+
+				assert.NotNil(s.T(), err)
+			},
+		},
+		{
+			Name: "err no profiles",
+			PreRequisites: func() {
+				s.writeConfig("profiles: {}\n")
+			},
+			Assert: func() {
+				_, err := Load(s.configPath)
+
+				assert.ErrorIs(s.T(), err, ErrNoProfiles)
+			},
+		},
+		{
+			Name: "success loading profiles",
+			PreRequisites: func() {
+				s.writeConfig(testConfigYAML)
+			},
+			Assert: func() {
+				conf, err := Load(s.configPath)
+
+				assert.Nil(s.T(), err)
+				assert.Len(s.T(), conf.Profiles, 2)
+
+				_, err = conf.Profile("does-not-exist")
+				assert.ErrorIs(s.T(), err, ErrProfileNotFound)
+
+				p, err := conf.Profile("nginx")
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), []string{"/etc/nginx", "/etc/ssl"}, p.Sources)
+				assert.Equal(s.T(), "50", p.Archive.VolumeSize)
+				assert.Equal(s.T(), "@daily", conf.Profiles["notes"].Schedule)
+				assert.Equal(s.T(), DaemonSettings{Concurrency: 2, Jitter: "10m", MetricsAddr: ":9184"}, conf.Daemon)
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitProfileValues() {
+	testCases := []ax.TestCase{
+		{
+			Name: "success only set values are returned",
+			PreRequisites: func() {
+				s.writeConfig(testConfigYAML)
+			},
+			Assert: func() {
+				conf, err := Load(s.configPath)
+				assert.Nil(s.T(), err)
+
+				p, err := conf.Profile("notes")
+				assert.Nil(s.T(), err)
+
+				assert.Equal(s.T(), map[string][]string{KeySources: {"/home/user/notes"}}, p.Values())
+			},
+		},
+		{
+			Name: "success values formatted as flags",
+			PreRequisites: func() {
+				s.writeConfig(testConfigYAML)
+			},
+			Assert: func() {
+				conf, err := Load(s.configPath)
+				assert.Nil(s.T(), err)
+
+				p, err := conf.Profile("nginx")
+				assert.Nil(s.T(), err)
+
+				vals := p.Values()
+				assert.Equal(s.T(), []string{"/var/tmp/ax_nginx"}, vals[KeyOutput])
+				assert.Equal(s.T(), []string{"/srv/nginx"}, vals[KeyExtractOut])
+				assert.Equal(s.T(), []string{"*.bak", "cache/"}, vals[KeyExclude])
+				assert.NotContains(s.T(), vals, KeyInclude)
+				assert.Equal(s.T(), []string{"false"}, vals[KeyProtect])
+				assert.Equal(s.T(), []string{"50"}, vals[KeyVolumeSize])
+				assert.Equal(s.T(), []string{"7"}, vals[KeyCompression])
+				assert.Equal(s.T(), []string{"256"}, vals[KeyDictSize])
+				assert.Equal(s.T(), []string{"false"}, vals[KeySolidArchive])
+				assert.Equal(s.T(), []string{"1.5g"}, vals[KeySolidBlockSize])
+				assert.Equal(s.T(), []string{"4"}, vals[KeyThreads])
+				assert.NotContains(s.T(), vals, KeyMethod)
+				assert.NotContains(s.T(), vals, KeyFastBytes)
+				assert.NotContains(s.T(), vals, KeyHeadersEncryption)
+				assert.Equal(s.T(), []string{"5s"}, vals[KeyDebounce])
+				assert.NotContains(s.T(), vals, KeyMinInterval)
+				assert.Equal(s.T(), []string{"https://hooks.example.com/ax"}, vals[KeyHookWebhook])
+				assert.Equal(s.T(), []string{"failure", "warning"}, vals[KeyHookWebhookOn])
+				assert.NotContains(s.T(), vals, KeyHookCommand)
+				assert.Equal(s.T(), []string{"/var/lib/node_exporter/textfile/ax.prom"}, vals[KeyMetricsFile])
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
@@ -0,0 +1,628 @@
+package flags
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/cli/config"
+	"github.com/kaynetik/ax/pkg/cli/output"
+	"github.com/kaynetik/ax/pkg/watch"
+)
+
+var (
+	// ErrUnknownCommand - subcommand provided is not supported.
+	ErrUnknownCommand = errors.New("unknown command")
+
+	// ErrUnexpectedArgs - subcommand received positional arguments which it does not accept.
+	ErrUnexpectedArgs = errors.New("unexpected arguments")
+
+	// ErrFlagRequired - required flag has been left out.
+	ErrFlagRequired = errors.New("required flag is missing")
+)
+
+// passwordNeeds - bitmask of passwords which a subcommand has to prompt for.
+type passwordNeeds uint8
+
+const (
+	needArchivePassword passwordNeeds = 1 << iota
+	needEncryptPassword
+	needDecryptPassword
+)
+
+// Command - represents a single ax subcommand, with its own flag set, validation and help text.
+type Command struct {
+	// Name - name of the subcommand, as typed on the command line.
+	Name string
+
+	// Synopsis - one line description of the subcommand, shown in the general help.
+	Synopsis string
+
+	// Scan - holds values scanned for the subcommand, once ParseCommand succeeds.
+	Scan CmdScan
+
+	fs       *flag.FlagSet
+	keys     map[string]string
+	validate func(cs *CmdScan) error
+	needs    passwordNeeds
+}
+
+// newCommand - returns Command with an empty flag set, which prints the subcommand help on usage errors.
+func newCommand(name, synopsis string, needs passwordNeeds) *Command {
+	c := &Command{
+		Name:     name,
+		Synopsis: synopsis,
+		fs:       flag.NewFlagSet(name, flag.ContinueOnError),
+		keys:     make(map[string]string),
+		needs:    needs,
+	}
+
+	c.fs.Usage = func() { c.PrintUsage(c.fs.Output()) }
+	c.registerCommonFlags()
+
+	if needs != 0 {
+		c.fs.BoolVar(&c.Scan.NonInteractive, flagNameNonInteractive, false, flagUsageNonInteractive)
+		c.bind(flagNameNonInteractive, keyNonInteractive)
+	}
+
+	if needs&needArchivePassword != 0 {
+		c.registerPasswordSource(&c.Scan.ArchivePasswordSource, passwordKindArchive, envArchivePassword,
+			config.KeyArchivePasswordFile, config.KeyArchivePasswordCommand)
+	}
+
+	if needs&(needEncryptPassword|needDecryptPassword) != 0 {
+		c.registerPasswordSource(&c.Scan.EncryptionPasswordSource, passwordKindEncryption, envEncryptionPassword,
+			config.KeyEncryptionPasswordFile, config.KeyEncryptionPasswordCommand)
+	}
+
+	return c
+}
+
+// PrintUsage - prints the help text of the subcommand, including all of its flags.
+func (c *Command) PrintUsage(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "Usage: ax %s [flags]\n\n%s.\n\nFlags:\n", c.Name, c.Synopsis)
+
+	c.fs.SetOutput(w)
+	c.fs.PrintDefaults()
+}
+
+// commands - returns all supported subcommands, in the order in which they are listed in the help.
+func commands() []*Command {
+	return []*Command{
+		archiveCommand(),
+		extractCommand(),
+		encryptCommand(),
+		decryptCommand(),
+		pushCommand(),
+		watchCommand(),
+		pullCommand(),
+		restoreCommand(),
+		verifyCommand(),
+		listCommand(),
+		doctorCommand(),
+		resumeCommand(),
+		daemonCommand(),
+		statusCommand(),
+	}
+}
+
+func archiveCommand() *Command {
+	c := newCommand(cmdNameArchive, cmdSynopsisArchive, needArchivePassword)
+
+	c.fs.Var((*stringList)(&c.Scan.Sources), flagNameIn, flagUsageIn)
+	c.fs.StringVar(&c.Scan.Root, flagNameRoot, "", flagUsageRoot)
+	c.fs.StringVar(&c.Scan.ArchiveOutPath, flagNameOut, flagValArchiveOutPath, flagUsageOut)
+	c.fs.StringVar(&c.Scan.NewArchiveName, flagNameName, flagValNewArchiveName, flagUsageNewArchiveName)
+	c.fs.BoolVar(&c.Scan.ProtectArchiveWithPasswd, flagNameProtect, true, flagUsageProtect)
+
+	c.registerArchiveSettings()
+	c.registerSourceFilters()
+	c.bind(flagNameIn, config.KeySources)
+	c.bind(flagNameRoot, config.KeyRoot)
+	c.bind(flagNameOut, config.KeyOutput)
+	c.bind(flagNameName, config.KeyName)
+	c.bind(flagNameProtect, config.KeyProtect)
+
+	c.validate = func(cs *CmdScan) error {
+		cs.defaultSources()
+
+		err := cs.parseArchiveSizes()
+		if err != nil {
+			return err
+		}
+
+		return requireFlags(
+			requiredFlag{flagNameIn, strings.Join(cs.Sources, "")},
+			requiredFlag{flagNameOut, cs.ArchiveOutPath},
+			requiredFlag{flagNameName, cs.NewArchiveName},
+		)
+	}
+
+	return c
+}
+
+func extractCommand() *Command {
+	c := newCommand(cmdNameExtract, cmdSynopsisExtract, needArchivePassword)
+
+	c.fs.StringVar(&c.Scan.ArchiveExtract, flagNameIn, flagValArchiveExtract, flagUsageInArchive)
+	c.fs.StringVar(&c.Scan.ExtractOut, flagNameExtractOut, "", flagUsageOutExtract)
+	c.fs.BoolVar(&c.Scan.ProtectArchiveWithPasswd, flagNameProtect, true, flagUsageProtect)
+	c.fs.Var((*stringList)(&c.Scan.Files), flagNameFile, flagUsageFile)
+
+	c.registerExtractSafety()
+	c.registerMetadataRestore()
+	c.bind(flagNameIn, config.KeyOutput)
+	c.bind(flagNameExtractOut, config.KeyExtractOut)
+	c.bind(flagNameProtect, config.KeyProtect)
+
+	c.validate = func(cs *CmdScan) error {
+		err := cs.validateOverwrite()
+		if err != nil {
+			return err
+		}
+
+		return requireFlags(requiredFlag{flagNameIn, cs.ArchiveExtract})
+	}
+
+	return c
+}
+
+func encryptCommand() *Command {
+	c := newCommand(cmdNameEncrypt, cmdSynopsisEncrypt, needEncryptPassword)
+
+	c.fs.StringVar(&c.Scan.EncryptPath, flagNameIn, flagValEncryptIn, flagUsageEncryptIn)
+
+	c.bind(flagNameIn, config.KeyOutput)
+
+	c.validate = func(cs *CmdScan) error {
+		return requireFlags(requiredFlag{flagNameIn, cs.EncryptPath})
+	}
+
+	return c
+}
+
+func decryptCommand() *Command {
+	c := newCommand(cmdNameDecrypt, cmdSynopsisDecrypt, needDecryptPassword)
+
+	c.fs.StringVar(&c.Scan.DecryptPath, flagNameIn, flagValDecryptIn, flagUsageDecryptIn)
+
+	c.bind(flagNameIn, config.KeyOutput)
+
+	c.validate = func(cs *CmdScan) error {
+		return requireFlags(requiredFlag{flagNameIn, cs.DecryptPath})
+	}
+
+	return c
+}
+
+func pushCommand() *Command {
+	c := newCommand(cmdNamePush, cmdSynopsisPush, needArchivePassword|needEncryptPassword)
+
+	c.registerPush()
+
+	return c
+}
+
+// registerPush - registers flags of the archive, encrypt & push pipeline, along with their validation.
+func (c *Command) registerPush() {
+	c.fs.Var((*stringList)(&c.Scan.Sources), flagNameIn, flagUsageIn)
+	c.fs.StringVar(&c.Scan.Root, flagNameRoot, "", flagUsageRoot)
+	c.fs.StringVar(&c.Scan.ArchiveOutPath, flagNameOut, "", flagUsageWorkDir)
+	c.fs.StringVar(&c.Scan.NewArchiveName, flagNameName, flagValNewArchiveName, flagUsageNewArchiveName)
+	c.fs.StringVar(&c.Scan.GitRepo, flagNameRepo, "", flagUsageGitRepo)
+	c.fs.BoolVar(&c.Scan.ProtectArchiveWithPasswd, flagNameProtect, true, flagUsageProtect)
+	c.fs.BoolVar(&c.Scan.Verify, flagNameVerify, true, flagUsageVerify)
+	c.fs.BoolVar(&c.Scan.Stream, flagNameStream, false, flagUsageStream)
+
+	c.registerArchiveSettings()
+	c.registerSourceFilters()
+	c.bind(flagNameIn, config.KeySources)
+	c.bind(flagNameRoot, config.KeyRoot)
+	c.bind(flagNameOut, config.KeyOutput)
+	c.bind(flagNameName, config.KeyName)
+	c.bind(flagNameRepo, config.KeyRepo)
+	c.bind(flagNameProtect, config.KeyProtect)
+	c.bind(flagNameVerify, config.KeyVerify)
+	c.bind(flagNameStream, config.KeyStream)
+	c.registerHooks()
+
+	c.fs.StringVar(&c.Scan.MetricsFile, flagNameMetricsFile, "", flagUsageMetricsFile)
+	c.bind(flagNameMetricsFile, config.KeyMetricsFile)
+
+	c.validate = func(cs *CmdScan) error {
+		cs.defaultSources()
+
+		err := cs.parseArchiveSizes()
+		if err != nil {
+			return err
+		}
+
+		return requireFlags(
+			requiredFlag{flagNameIn, strings.Join(cs.Sources, "")},
+			requiredFlag{flagNameName, cs.NewArchiveName},
+			requiredFlag{flagNameRepo, cs.GitRepo},
+		)
+	}
+}
+
+// registerHooks - registers flags of the hooks reporting the outcome of each push.
+func (c *Command) registerHooks() {
+	h := &c.Scan.Hooks
+
+	c.fs.StringVar(&h.Command, flagNameHookCommand, "", flagUsageHookCommand)
+	c.fs.Var((*outcomeList)(&h.CommandOn), flagNameHookCommandOn, flagUsageHookOn)
+	c.fs.StringVar(&h.Webhook, flagNameHookWebhook, "", flagUsageHookWebhook)
+	c.fs.Var((*outcomeList)(&h.WebhookOn), flagNameHookWebhookOn, flagUsageHookOn)
+	c.fs.StringVar(&h.StatusFile, flagNameStatusFile, "", flagUsageStatusFile)
+	c.fs.Var((*outcomeList)(&h.StatusFileOn), flagNameStatusFileOn, flagUsageHookOn)
+
+	c.bind(flagNameHookCommand, config.KeyHookCommand)
+	c.bind(flagNameHookCommandOn, config.KeyHookCommandOn)
+	c.bind(flagNameHookWebhook, config.KeyHookWebhook)
+	c.bind(flagNameHookWebhookOn, config.KeyHookWebhookOn)
+	c.bind(flagNameStatusFile, config.KeyStatusFile)
+	c.bind(flagNameStatusFileOn, config.KeyStatusFileOn)
+}
+
+func watchCommand() *Command {
+	c := newCommand(cmdNameWatch, cmdSynopsisWatch, needArchivePassword|needEncryptPassword)
+
+	c.registerPush()
+
+	c.fs.DurationVar(&c.Scan.Debounce, flagNameDebounce, watch.DefaultDebounce, flagUsageDebounce)
+	c.fs.DurationVar(&c.Scan.MinInterval, flagNameMinInterval, watch.DefaultMinInterval, flagUsageMinInterval)
+	c.fs.DurationVar(&c.Scan.MaxBackoff, flagNameMaxBackoff, watch.DefaultMaxBackoff, flagUsageMaxBackoff)
+	c.fs.DurationVar(&c.Scan.PollInterval, flagNamePollInterval, 0, flagUsagePollInterval)
+
+	c.bind(flagNameDebounce, config.KeyDebounce)
+	c.bind(flagNameMinInterval, config.KeyMinInterval)
+	c.bind(flagNameMaxBackoff, config.KeyMaxBackoff)
+	c.bind(flagNamePollInterval, config.KeyPollInterval)
+
+	return c
+}
+
+func pullCommand() *Command {
+	c := newCommand(cmdNamePull, cmdSynopsisPull, 0)
+
+	c.fs.StringVar(&c.Scan.GitRepo, flagNameRepo, "", flagUsageRepo)
+	c.fs.StringVar(&c.Scan.ArchiveExtract, flagNameOut, flagValOut, flagUsageOutPull)
+
+	c.bind(flagNameRepo, config.KeyRepo)
+	c.bind(flagNameOut, config.KeyOutput)
+
+	c.validate = func(cs *CmdScan) error {
+		return requireFlags(
+			requiredFlag{flagNameRepo, cs.GitRepo},
+			requiredFlag{flagNameOut, cs.ArchiveExtract},
+		)
+	}
+
+	return c
+}
+
+func restoreCommand() *Command {
+	c := newCommand(cmdNameRestore, cmdSynopsisRestore, needArchivePassword|needDecryptPassword)
+
+	c.fs.StringVar(&c.Scan.GitRepo, flagNameRepo, "", flagUsageRepo)
+	c.fs.StringVar(&c.Scan.ArchiveExtract, flagNameOut, flagValOut, flagUsageOutPull)
+	c.fs.BoolVar(&c.Scan.ProtectArchiveWithPasswd, flagNameProtect, true, flagUsageProtect)
+	c.fs.BoolVar(&c.Scan.VerifyOnly, flagNameVerifyOnly, false, flagUsageVerifyOnly)
+	c.fs.Var((*stringList)(&c.Scan.Files), flagNameFile, flagUsageFile)
+	c.fs.StringVar(&c.Scan.ExtractOut, flagNameExtractOut, "", flagUsageOutExtract)
+
+	c.registerExtractSafety()
+	c.registerMetadataRestore()
+	c.bind(flagNameRepo, config.KeyRepo)
+	c.bind(flagNameOut, config.KeyOutput)
+	c.bind(flagNameProtect, config.KeyProtect)
+	c.bind(flagNameExtractOut, config.KeyExtractOut)
+
+	c.validate = func(cs *CmdScan) error {
+		cs.DecryptPath = cs.ArchiveExtract
+
+		err := cs.validateOverwrite()
+		if err != nil {
+			return err
+		}
+
+		return requireFlags(
+			requiredFlag{flagNameRepo, cs.GitRepo},
+			requiredFlag{flagNameOut, cs.ArchiveExtract},
+		)
+	}
+
+	return c
+}
+
+func verifyCommand() *Command {
+	c := newCommand(cmdNameVerify, cmdSynopsisVerify, needArchivePassword)
+
+	c.fs.StringVar(&c.Scan.ArchiveExtract, flagNameIn, flagValArchiveExtract, flagUsageInArchive)
+	c.fs.BoolVar(&c.Scan.ProtectArchiveWithPasswd, flagNameProtect, true, flagUsageProtect)
+
+	c.bind(flagNameIn, config.KeyOutput)
+	c.bind(flagNameProtect, config.KeyProtect)
+
+	c.validate = func(cs *CmdScan) error {
+		return requireFlags(requiredFlag{flagNameIn, cs.ArchiveExtract})
+	}
+
+	return c
+}
+
+func listCommand() *Command {
+	c := newCommand(cmdNameList, cmdSynopsisList, needArchivePassword)
+
+	c.fs.StringVar(&c.Scan.ArchiveExtract, flagNameIn, flagValArchiveExtract, flagUsageInArchive)
+	c.fs.BoolVar(&c.Scan.ProtectArchiveWithPasswd, flagNameProtect, true, flagUsageProtect)
+
+	c.bind(flagNameIn, config.KeyOutput)
+	c.bind(flagNameProtect, config.KeyProtect)
+
+	c.validate = func(cs *CmdScan) error {
+		return requireFlags(requiredFlag{flagNameIn, cs.ArchiveExtract})
+	}
+
+	return c
+}
+
+func doctorCommand() *Command {
+	c := newCommand(cmdNameDoctor, cmdSynopsisDoctor, 0)
+
+	c.fs.StringVar(&c.Scan.ArchiveOutPath, flagNameOut, flagValOut, flagUsageOutDoctor)
+	c.fs.StringVar(&c.Scan.GitRepo, flagNameRepo, "", flagUsageRepo)
+
+	c.bind(flagNameOut, config.KeyOutput)
+	c.bind(flagNameRepo, config.KeyRepo)
+
+	// Nothing is required, as the checks which depend on a flag are skipped without it.
+	c.validate = func(cs *CmdScan) error { return nil }
+
+	return c
+}
+
+// validateOverwrite - validates the overwrite policy, normalizing its name.
+func (cs *CmdScan) validateOverwrite() error {
+	p, err := ax.ParseOverwritePolicy(cs.Overwrite)
+	if err != nil {
+		return fmt.Errorf("-%s: %w", flagNameOverwrite, err)
+	}
+
+	cs.Overwrite = string(p)
+
+	return nil
+}
+
+// defaultSources - falls back to the default source, if none has been provided.
+func (cs *CmdScan) defaultSources() {
+	if len(cs.Sources) == 0 {
+		cs.Sources = []string{flagValArchiveIn}
+	}
+}
+
+// requiredFlag - pairs the name of a required flag with the value scanned for it.
+type requiredFlag struct{ name, val string }
+
+// requireFlags - returns ErrFlagRequired for the first required flag which has been left blank.
+func requireFlags(flags ...requiredFlag) error {
+	for _, f := range flags {
+		if strings.TrimSpace(f.val) == "" {
+			return fmt.Errorf("%w: -%s", ErrFlagRequired, f.name)
+		}
+	}
+
+	return nil
+}
+
+// ParseCommand - parses the subcommand and its flags from args (program name excluded), validates them,
+// and prompts for passwords the subcommand requires.
+//
+// Flags left out on the command line are taken from their AX_* environment variable, or else from the profile
+// selected with -profile, before falling back to their default values.
+//
+// flag.ErrHelp is returned when the help has been requested and printed, for the caller to exit gracefully.
+// Once flags have been parsed, the Command is returned even along with an error.
+func ParseCommand(args []string) (*Command, error) {
+	if len(args) == 0 || args[0] == cmdNameHelp || isHelpArg(args[0]) {
+		return nil, printHelpFor(args)
+	}
+
+	c := lookupCommand(args[0])
+	if c == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCommand, args[0])
+	}
+
+	err := c.fs.Parse(args[1:])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Name, err)
+	}
+
+	// From here on the Command is returned along with any error, so that the caller can report it in the chosen format.
+	if c.fs.NArg() > 0 {
+		c.fs.Usage()
+
+		return c, fmt.Errorf("%s: %w: %v", c.Name, ErrUnexpectedArgs, c.fs.Args())
+	}
+
+	err = c.applyOverrides(os.LookupEnv)
+	if err != nil {
+		return c, fmt.Errorf("%s: %w", c.Name, err)
+	}
+
+	err = output.ValidateFormat(c.Scan.Output)
+	if err != nil {
+		return c, fmt.Errorf("%s: %w", c.Name, err)
+	}
+
+	err = c.validate(&c.Scan)
+	if err != nil {
+		c.fs.Usage()
+
+		return c, fmt.Errorf("%s: %w", c.Name, err)
+	}
+
+	err = c.Scan.scanPasswords(c.needs)
+	if err != nil {
+		return c, fmt.Errorf("%s: %w", c.Name, err)
+	}
+
+	return c, nil
+}
+
+// lookupCommand - returns the subcommand matching the name, or nil if there is none.
+func lookupCommand(name string) *Command {
+	for _, c := range commands() {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// printHelpFor - prints either general help, or help of the subcommand named in `ax help <command>` (or `ax -h
+// <command>`).
+func printHelpFor(args []string) error {
+	if len(args) > 1 {
+		c := lookupCommand(args[1])
+		if c == nil {
+			return fmt.Errorf("%w: %q", ErrUnknownCommand, args[1])
+		}
+
+		c.PrintUsage(os.Stdout)
+
+		return flag.ErrHelp
+	}
+
+	PrintUsage(os.Stdout)
+
+	return flag.ErrHelp
+}
+
+// PrintUsage - prints general help, listing all supported subcommands.
+func PrintUsage(w io.Writer) {
+	_, _ = fmt.Fprint(w, "Usage: ax <command> [flags]\n\n")
+	_, _ = fmt.Fprint(w, "When called without any command, interactive mode will be initiated.\n\nCommands:\n")
+
+	for _, c := range commands() {
+		_, _ = fmt.Fprintf(w, "  %-10s%s\n", c.Name, c.Synopsis)
+	}
+
+	_, _ = fmt.Fprint(w, "\nRun 'ax help <command>' or 'ax <command> -help' for the flags of each command.\n")
+	_, _ = fmt.Fprint(w, "Flags left out are read from AX_* environment variables, then from the -profile in -config.\n")
+}
+
+// IsLegacyInvocation - reports whether ax has been called with the deprecated flag-style arguments,
+// i.e. `ax -arc-in ../tmp_to_archive`, instead of a subcommand. Asking for help, i.e. `ax -h`, isn't one of them.
+func IsLegacyInvocation(args []string) bool {
+	return len(args) > 0 && strings.HasPrefix(args[0], legacyFlagPrefix) && !isHelpArg(args[0])
+}
+
+// isHelpArg - reports whether the argument is one of the help flags: -h, -help, --h or --help.
+func isHelpArg(arg string) bool {
+	if !strings.HasPrefix(arg, legacyFlagPrefix) {
+		return false
+	}
+
+	switch strings.TrimPrefix(strings.TrimPrefix(arg, legacyFlagPrefix), legacyFlagPrefix) {
+	case "h", cmdNameHelp:
+		return true
+	default:
+		return false
+	}
+}
+
+// scanPasswords - prompts for all passwords the subcommand needs, unless it runs non-interactively.
+// Nothing is prompted for when only a preview of the archived files has been requested.
+func (cs *CmdScan) scanPasswords(needs passwordNeeds) error {
+	if cs.ListOnly || cs.DryRun {
+		return nil
+	}
+
+	var err error
+
+	// Streamed Archive(s) are protected by the encryption password alone.
+	if needs&needArchivePassword != 0 && cs.ProtectArchiveWithPasswd && !cs.Stream {
+		cs.PasswordByte, err = cs.readPassword(&cs.ArchivePasswordSource, promptEnterPasswordForArchiveEncryption)
+		if err != nil {
+			return err
+		}
+	}
+
+	if needs&needEncryptPassword != 0 {
+		cs.EncryptPassword, err = cs.readPassword(&cs.EncryptionPasswordSource, promptEnterPasswordForEncryption)
+		if err != nil {
+			return err
+		}
+	}
+
+	if needs&needDecryptPassword != 0 {
+		cs.DecryptPassword, err = cs.readPassword(&cs.EncryptionPasswordSource, promptEnterPasswordForDecryption)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readPassword - reads the password from its source, which mustn't prompt for it if ax runs non-interactively.
+func (cs *CmdScan) readPassword(ps *PasswordSource, prompt string) ([]byte, error) {
+	if cs.NonInteractive {
+		return ps.ReadNonInteractive()
+	}
+
+	return ps.Read(prompt)
+}
+
+func resumeCommand() *Command {
+	c := newCommand(cmdNameResume, cmdSynopsisResume, 0)
+
+	c.fs.StringVar(&c.Scan.ArchiveOutPath, flagNameOut, "", flagUsageOutResume)
+	c.fs.StringVar(&c.Scan.GitRepo, flagNameRepo, "", flagUsageRepoResume)
+
+	c.bind(flagNameOut, config.KeyOutput)
+	c.bind(flagNameRepo, config.KeyRepo)
+
+	// Repo is taken from the job state of the work directory, if it's left out.
+	c.validate = func(cs *CmdScan) error {
+		return requireFlags(requiredFlag{flagNameOut, cs.ArchiveOutPath})
+	}
+
+	return c
+}
+
+func daemonCommand() *Command {
+	c := newCommand(cmdNameDaemon, cmdSynopsisDaemon, 0)
+
+	c.fs.StringVar(&c.Scan.Socket, flagNameSocket, "", flagUsageSocket)
+	c.fs.UintVar(&c.Scan.Concurrency, flagNameConcurrency, 0, flagUsageConcurrency)
+	c.fs.DurationVar(&c.Scan.Jitter, flagNameJitter, 0, flagUsageJitter)
+	c.fs.StringVar(&c.Scan.HistoryPath, flagNameHistory, "", flagUsageHistory)
+	c.fs.StringVar(&c.Scan.MetricsAddr, flagNameMetricsAddr, "", flagUsageMetricsAddr)
+
+	c.bind(flagNameSocket, keySocket)
+
+	// Left out settings are taken from the daemon section of the config, which also holds the scheduled profiles.
+	// -profile is of no use, as every profile having a schedule is pushed.
+	c.validate = func(cs *CmdScan) error { return nil }
+
+	return c
+}
+
+func statusCommand() *Command {
+	c := newCommand(cmdNameStatus, cmdSynopsisStatus, 0)
+
+	c.fs.StringVar(&c.Scan.Socket, flagNameSocket, "", flagUsageSocket)
+
+	c.bind(flagNameSocket, keySocket)
+
+	c.validate = func(cs *CmdScan) error { return nil }
+
+	return c
+}
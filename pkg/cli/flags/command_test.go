@@ -0,0 +1,97 @@
+package flags
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/kaynetik/ax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type Suite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	t.Parallel()
+
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) TestUnitParseCommand() {
+	testCases := []ax.TestCase{
+		{
+			Name: "success flags are parsed by the flag set of the command",
+			Assert: func() {
+				cmd, err := ParseCommand([]string{cmdNameStatus, "-socket", "/tmp/ax-test.sock", "-output", "json"})
+				s.Require().Nil(err)
+
+				assert.Equal(s.T(), cmdNameStatus, cmd.Name)
+				assert.Equal(s.T(), "/tmp/ax-test.sock", cmd.Scan.Socket)
+				assert.Equal(s.T(), "json", cmd.Scan.Output)
+			},
+		},
+		{
+			Name: "success help is asked for, with a command or without one",
+			Assert: func() {
+				for _, args := range [][]string{{cmdNameHelp}, {"-h"}, {"--help"}, {cmdNameHelp, cmdNamePush}} {
+					cmd, err := ParseCommand(args)
+					assert.Nil(s.T(), cmd, args)
+					assert.ErrorIs(s.T(), err, flag.ErrHelp, args)
+				}
+			},
+		},
+		{
+			Name: "err unknown command",
+			Assert: func() {
+				cmd, err := ParseCommand([]string{"backup"})
+				assert.Nil(s.T(), cmd)
+				assert.ErrorIs(s.T(), err, ErrUnknownCommand)
+
+				_, err = ParseCommand([]string{cmdNameHelp, "backup"})
+				assert.ErrorIs(s.T(), err, ErrUnknownCommand)
+			},
+		},
+		{
+			Name: "err flag of another command",
+			Assert: func() {
+				cmd, err := ParseCommand([]string{cmdNameStatus, "-in", "/etc"})
+				assert.Nil(s.T(), cmd)
+				assert.Contains(s.T(), err.Error(), "flag provided but not defined: -in")
+			},
+		},
+		{
+			Name: "err positional arguments",
+			Assert: func() {
+				cmd, err := ParseCommand([]string{cmdNameStatus, "now"})
+				s.Require().NotNil(cmd)
+				assert.ErrorIs(s.T(), err, ErrUnexpectedArgs)
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitIsLegacyInvocation() {
+	testCases := []ax.TestCase{
+		{
+			Name: "success deprecated flags are legacy",
+			Assert: func() {
+				assert.True(s.T(), IsLegacyInvocation([]string{"-arc-in", "../tmp_to_archive"}))
+				assert.True(s.T(), IsLegacyInvocation([]string{"-hint"}))
+			},
+		},
+		{
+			Name: "success commands and help aren't legacy",
+			Assert: func() {
+				for _, args := range [][]string{nil, {cmdNamePush}, {"-h"}, {"-help"}, {"--h"}, {"--help"}} {
+					assert.False(s.T(), IsLegacyInvocation(args), args)
+				}
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
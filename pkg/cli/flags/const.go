@@ -38,3 +38,186 @@ const (
 	promptEnterPasswordForDecryption        = "Enter Password for Archive(s) Decryption"
 	promptAnswerNo                          = "no"
 )
+
+const (
+	cmdNameArchive = "archive"
+	cmdNameExtract = "extract"
+	cmdNameEncrypt = "encrypt"
+	cmdNameDecrypt = "decrypt"
+	cmdNamePush    = "push"
+	cmdNamePull    = "pull"
+	cmdNameRestore = "restore"
+	cmdNameVerify  = "verify"
+	cmdNameList    = "list"
+	cmdNameDoctor  = "doctor"
+	cmdNameResume  = "resume"
+	cmdNameDaemon  = "daemon"
+	cmdNameWatch   = "watch"
+	cmdNameStatus  = "status"
+	cmdNameHelp    = "help"
+
+	cmdSynopsisArchive = "Archive the chosen directory into password-protected 7z volume(s)"
+	cmdSynopsisExtract = "Extract previously created archive volume(s)"
+	cmdSynopsisEncrypt = "Encrypt all files in the chosen directory with AES"
+	cmdSynopsisDecrypt = "Decrypt all files in the chosen directory"
+	cmdSynopsisPush    = "Archive, Encrypt & Push the archive volume(s) to the remote GIT Repository"
+	cmdSynopsisPull    = "Clone the remote GIT Repository holding the encrypted archive volume(s)"
+	cmdSynopsisRestore = "Pull, Decrypt & Extract the archive volume(s) from the remote GIT Repository"
+	cmdSynopsisVerify  = "Verify integrity of the archive volume(s)"
+	cmdSynopsisList    = "List the contents of the archive volume(s)"
+	cmdSynopsisDoctor  = "Check that 7zip, git and the output path are ready for backups, suggesting fixes"
+	cmdSynopsisResume  = "Push the encrypted volume(s) of an interrupted push, which haven't reached the remote yet"
+	cmdSynopsisDaemon  = "Push the profiles of the config on their schedules, until interrupted"
+	cmdSynopsisWatch   = "Archive, Encrypt & Push the sources whenever they change, until interrupted"
+	cmdSynopsisStatus  = "Report the scheduled profiles and recent pushes of the running daemon"
+
+	flagNameIn      = "in"
+	flagNameOut     = "out"
+	flagNameName    = "name"
+	flagNameRepo    = "repo"
+	flagNameProtect = "pass"
+
+	flagValOut = "../tmp_archive_out"
+
+	flagUsageIn        = "Select the directory or file which you wish to Archive (repeatable)"
+	flagUsageInArchive = "Choose the path of Archive(s) location, or of a single Archive (or its first volume)"
+	flagUsageInFiles   = "Select the path in which the files are located"
+	flagUsageOut       = "Select the path where you want to store temporary Archive(s)"
+	flagUsageWorkDir   = "Work directory for the temporary Archive(s), used only if it's empty or has been created by " +
+		"ax, as its content is deleted (default: one of the backup under the system temp dir, removed once done)"
+	flagUsageOutPull    = "Select the path into which the remote GIT Repository should be cloned"
+	flagUsageProtect    = "Protect the Archive(s) with a password, which you will be prompted for"
+	flagUsageRepo       = "Enter the remote GIT Repository where your backup is persisted"
+	flagUsageOutDoctor  = "Path where the Archive(s) are going to be stored, checked for free space and write permissions"
+	flagUsageOutResume  = "Work directory of the interrupted push, given to it as -out"
+	flagUsageRepoResume = "Remote GIT Repository to push to (default: the one the interrupted push has been pushing to, " +
+		"unless its credentials have been redacted)"
+
+	legacyFlagPrefix = "-"
+)
+
+const (
+	flagNameConfig         = "config"
+	flagNameProfile        = "profile"
+	flagNameOutput         = "output"
+	flagNameVerbose        = "v"
+	flagNameQuiet          = "q"
+	flagNameType           = "type"
+	flagNameBlockSize      = "block-size"
+	flagNameVolumeSize     = "volume-size"
+	flagNameFastBytes      = "fast-bytes"
+	flagNameDictSize       = "dict-size"
+	flagNameCompression    = "compression"
+	flagNameHeadersEnc     = "headers-encryption"
+	flagNameSolid          = "solid"
+	flagNameSolidBlock     = "solid-block-size"
+	flagNameMethod         = "method"
+	flagNameThreads        = "threads"
+	flagNameRoot           = "root"
+	flagNameInclude        = "include"
+	flagNameExclude        = "exclude"
+	flagNameListOnly       = "list-only"
+	flagNameDryRun         = "dry-run"
+	flagNameVerify         = "verify"
+	flagNameStream         = "stream"
+	flagNameVerifyOnly     = "verify-only"
+	flagNameFile           = "file"
+	flagNameExtractOut     = "extract-out"
+	flagNameOverwrite      = "overwrite"
+	flagNameAllowUnsafe    = "allow-unsafe-paths"
+	flagNamePreserveMeta   = "preserve-metadata"
+	flagNameNumericOwner   = "numeric-owner"
+	flagNameNonInteractive = "non-interactive"
+	flagNameSocket         = "socket"
+	flagNameConcurrency    = "concurrency"
+	flagNameJitter         = "jitter"
+	flagNameHistory        = "history"
+	flagNameDebounce       = "debounce"
+	flagNameMinInterval    = "min-interval"
+	flagNameMaxBackoff     = "max-backoff"
+	flagNamePollInterval   = "poll"
+	flagNameHookCommand    = "hook-command"
+	flagNameHookCommandOn  = "hook-command-on"
+	flagNameHookWebhook    = "hook-webhook"
+	flagNameHookWebhookOn  = "hook-webhook-on"
+	flagNameStatusFile     = "status-file"
+	flagNameStatusFileOn   = "status-file-on"
+	flagNameMetricsFile    = "metrics-file"
+	flagNameMetricsAddr    = "metrics-addr"
+
+	flagUsageConfig     = "Path of the config file holding backup profiles (default ~/.config/ax/config.yaml)"
+	flagUsageProfile    = "Name of the backup profile, from the config file, to take flag values from"
+	flagUsageOutput     = "Format of the command result: text or json (printed to stdout, progress goes to stderr)"
+	flagUsageVerbose    = "Verbose progress, including the executed commands (with passwords redacted)"
+	flagUsageQuiet      = "Quiet progress, only errors are printed"
+	flagUsageType       = "Type of the Archive(s), passed to 7zip as -t"
+	flagUsageBlockSize  = "Unit of -volume-size given without one: b, k, m or g"
+	flagUsageVolumeSize = "Size of each Archive volume, i.e. 1.5g or 500m, in -block-size units if given without a " +
+		"unit (0 disables splitting)"
+	flagUsageFastBytes = "Number of fast bytes for LZMA2 (5-273) or Deflate (3-258), passed to 7zip as -mfb"
+	flagUsageDictSize  = "Dictionary size of LZMA2, i.e. 256m or 1g, in megabytes if given without a unit, " +
+		"passed to 7zip as -md"
+	flagUsageCompression = "Compression level from 0 (none) to 9 (ultra), passed to 7zip as -mx"
+	flagUsageHeadersEnc  = "Encrypt Archive headers, so that file names are hidden without the password"
+	flagUsageSolid       = "Create a solid Archive"
+	flagUsageSolidBlock  = "Size of solid blocks, i.e. 64m, so that single files are extracted faster " +
+		"(default: chosen by 7zip)"
+	flagUsageMethod   = "Compression method: LZMA2 (default), PPMd, BZip2 or zstd (requires 7zip with zstd codecs)"
+	flagUsageThreads  = "Number of threads 7zip compresses with, passed as -mmt (default: all CPUs)"
+	flagUsageRoot     = "Directory the sources are stored relative to (default: deepest directory holding all of them)"
+	flagUsageInclude  = "Archive only files matching the gitignore-style pattern (repeatable)"
+	flagUsageExclude  = "Don't archive files matching the gitignore-style pattern, on top of .axignore (repeatable)"
+	flagUsageListOnly = "Only list the files which would be archived, without archiving them"
+	flagUsageDryRun   = "Only report the files, volumes and commands, and what would be deleted or overwritten, " +
+		"without touching the disk or the remote (passwords aren't prompted for)"
+	flagUsageVerify = "Test integrity of the Archive(s) before encrypting and pushing them"
+	flagUsageStream = "Stream the sources as a tar.gz through the encryption into volumes, so that the Archive is " +
+		"never written unencrypted, without 7zip (no archive password is used)"
+	flagUsageFile        = "Extract only entries matching the gitignore-style pattern, i.e. 'nginx/*.conf' (repeatable)"
+	flagUsageVerifyOnly  = "Only test integrity of the decrypted Archive(s), without extracting them (restore drill)"
+	flagUsageOutExtract  = "Directory the Archive(s) are extracted into (default: the directory holding them)"
+	flagUsageOverwrite   = "What to do with extracted files which already exist: fail, skip, overwrite or rename"
+	flagUsageAllowUnsafe = "Extract entries with absolute or '..' paths, and symlinks pointing outside of the output " +
+		"(rejected by default, as the Archive(s) may have been tampered with)"
+	flagUsagePreserveMeta = "Archive the sources as a tarball within 7zip, preserving ownership, exact modes, " +
+		"symlinks and extended attributes"
+	flagUsageRestoreMeta    = "Restore modes, times and extended attributes of extracted entries (and ownership, as root)"
+	flagUsageNumericOwner   = "Restore ownership by the archived uid and gid, instead of the user and group names"
+	flagUsageNonInteractive = "Never prompt for passwords, failing if one of them has no non-interactive source"
+	flagUsageSocket         = "Unix socket the daemon reports its status on " +
+		"(default: $XDG_RUNTIME_DIR/ax/daemon.sock, or a directory of the user under the system temp dir)"
+	flagUsageConcurrency = "Maximum number of profiles pushed at once (default: daemon.concurrency of the config, or 1)"
+	flagUsageJitter      = "Maximum random delay of each push, i.e. 10m, spreading pushes scheduled for the same time " +
+		"(default: daemon.jitter of the config)"
+	flagUsageHistory = "File recording the recent pushes (default: daemon.history of the config, or history.json " +
+		"next to it)"
+	flagUsageDebounce     = "How long the sources have to stay unchanged, before they're pushed"
+	flagUsageMinInterval  = "Minimum time between the starts of two pushes"
+	flagUsageMaxBackoff   = "Maximum delay of the retry of a failed push, which is doubled with each failure in a row"
+	flagUsagePollInterval = "Poll the sources for changes this often, i.e. 30s, instead of watching them by inotify " +
+		"(the sources are polled every 10s where it isn't available)"
+	flagUsageHookCommand = "Shell command run after each push, with its JSON result on stdin and the outcome " +
+		"(success, failure or warning) in AX_HOOK_OUTCOME"
+	flagUsageHookWebhook = "URL the JSON result of each push is POSTed to"
+	flagUsageStatusFile  = "File the JSON result of the latest push is written into"
+	flagUsageHookOn      = "Outcomes the hook runs on: success, failure and/or warning, i.e. 'failure,warning' " +
+		"(default: all)"
+	flagUsageMetricsFile = "File the metrics of each push are written into, in the node_exporter textfile " +
+		"collector format, i.e. /var/lib/node_exporter/textfile/ax.prom"
+	flagUsageMetricsAddr = "Address the metrics of the pushes are served on for Prometheus, i.e. :9184 " +
+		"(default: daemon.metrics_addr of the config, not served if empty)"
+)
+
+const (
+	passwordKindArchive    = "archive"
+	passwordKindEncryption = "encryption"
+
+	flagSuffixPasswordFile    = "-password-file"
+	flagSuffixPasswordFD      = "-password-fd"
+	flagSuffixPasswordCommand = "-password-command"
+
+	flagUsagePasswordFile    = "Read the %s password from the first line of this file, instead of prompting for it"
+	flagUsagePasswordFD      = "Read the %s password from this inherited file descriptor, instead of prompting for it"
+	flagUsagePasswordCommand = "Read the %s password from the stdout of this command, i.e. 'pass show ax'. " +
+		"Without any password flag, the password is read from %s, before prompting for it"
+)
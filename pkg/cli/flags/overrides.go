@@ -0,0 +1,359 @@
+package flags
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/cli/config"
+	"github.com/kaynetik/ax/pkg/cli/output"
+	"github.com/kaynetik/ax/pkg/hooks"
+)
+
+const (
+	envPrefix = "AX_"
+
+	envConfig  = envPrefix + "CONFIG"
+	envProfile = envPrefix + "PROFILE"
+
+	// keyOutputFormat - key of the -output flag, which can be set only through AX_OUTPUT_FORMAT, not from a profile.
+	keyOutputFormat = "output_format"
+	// keyVerbose & keyQuiet - keys of the -v and -q flags, which can be set only through AX_VERBOSE and AX_QUIET.
+	keyVerbose = "verbose"
+	keyQuiet   = "quiet"
+	// keyAllowUnsafePaths - key of the -allow-unsafe-paths flag, which can't be set from a profile, as it should be
+	// opted into deliberately, for a single run.
+	keyAllowUnsafePaths = "allow_unsafe_paths"
+	// keyNonInteractive & keySocket - keys of the -non-interactive and -socket flags, which can be set only through
+	// AX_NON_INTERACTIVE and AX_SOCKET, as they aren't settings of a single profile.
+	keyNonInteractive = "non_interactive"
+	keySocket         = "socket"
+)
+
+// mebibyte - unit of ax.ArchiveConfig.DictSize.
+const mebibyte = 1 << 20
+
+var (
+	// ErrTooManyValues - multiple values were provided for a flag which accepts only one.
+	ErrTooManyValues = errors.New("too many values")
+
+	// ErrOutOfRange - numeric value doesn't fit the setting it's passed to.
+	ErrOutOfRange = errors.New("value out of range")
+)
+
+// registerCommonFlags - registers flags which are shared by every subcommand.
+func (c *Command) registerCommonFlags() {
+	c.fs.StringVar(&c.Scan.ConfigPath, flagNameConfig, "", flagUsageConfig)
+	c.fs.StringVar(&c.Scan.Profile, flagNameProfile, "", flagUsageProfile)
+	c.fs.StringVar(&c.Scan.Output, flagNameOutput, output.FormatText, flagUsageOutput)
+	c.fs.BoolVar(&c.Scan.Verbose, flagNameVerbose, false, flagUsageVerbose)
+	c.fs.BoolVar(&c.Scan.Quiet, flagNameQuiet, false, flagUsageQuiet)
+
+	c.bind(flagNameOutput, keyOutputFormat)
+	c.bind(flagNameVerbose, keyVerbose)
+	c.bind(flagNameQuiet, keyQuiet)
+}
+
+// registerArchiveSettings - registers flags for the ax.ArchiveConfig tuning, defaulting to its default values.
+func (c *Command) registerArchiveSettings() {
+	ac := ax.NewDefaultArchiveConfig()
+
+	c.fs.StringVar(&c.Scan.ArchiveType, flagNameType, ac.ArchiveType, flagUsageType)
+	c.fs.StringVar(&c.Scan.BlockSize, flagNameBlockSize, ac.BlockSize.String(), flagUsageBlockSize)
+	c.fs.StringVar(&c.Scan.VolumeSize, flagNameVolumeSize, strconv.FormatUint(ac.VolumeSize, 10), flagUsageVolumeSize)
+	c.fs.UintVar(&c.Scan.FastBytes, flagNameFastBytes, uint(ac.FastBytes), flagUsageFastBytes)
+	c.fs.StringVar(&c.Scan.DictSize, flagNameDictSize, fmt.Sprintf("%dm", ac.DictSize), flagUsageDictSize)
+	c.fs.UintVar(&c.Scan.Compression, flagNameCompression, uint(ac.Compression), flagUsageCompression)
+	c.fs.BoolVar(&c.Scan.HeadersEncryption, flagNameHeadersEnc, ac.HeadersEncryption, flagUsageHeadersEnc)
+	c.fs.BoolVar(&c.Scan.SolidArchive, flagNameSolid, ac.SolidArchive, flagUsageSolid)
+	c.fs.StringVar(&c.Scan.SolidBlockSize, flagNameSolidBlock, "", flagUsageSolidBlock)
+	c.fs.StringVar(&c.Scan.Method, flagNameMethod, "", flagUsageMethod)
+	c.fs.UintVar(&c.Scan.Threads, flagNameThreads, 0, flagUsageThreads)
+	c.fs.BoolVar(&c.Scan.PreserveMetadata, flagNamePreserveMeta, ac.PreserveMetadata, flagUsagePreserveMeta)
+
+	c.bind(flagNameType, config.KeyType)
+	c.bind(flagNameBlockSize, config.KeyBlockSize)
+	c.bind(flagNameVolumeSize, config.KeyVolumeSize)
+	c.bind(flagNameFastBytes, config.KeyFastBytes)
+	c.bind(flagNameDictSize, config.KeyDictSize)
+	c.bind(flagNameCompression, config.KeyCompression)
+	c.bind(flagNameHeadersEnc, config.KeyHeadersEncryption)
+	c.bind(flagNameSolid, config.KeySolidArchive)
+	c.bind(flagNameSolidBlock, config.KeySolidBlockSize)
+	c.bind(flagNameMethod, config.KeyMethod)
+	c.bind(flagNameThreads, config.KeyThreads)
+	c.bind(flagNamePreserveMeta, config.KeyPreserveMetadata)
+}
+
+// registerSourceFilters - registers flags selecting which files of the source are archived, and previewing them.
+func (c *Command) registerSourceFilters() {
+	c.fs.Var((*stringList)(&c.Scan.Include), flagNameInclude, flagUsageInclude)
+	c.fs.Var((*stringList)(&c.Scan.Exclude), flagNameExclude, flagUsageExclude)
+	c.fs.BoolVar(&c.Scan.ListOnly, flagNameListOnly, false, flagUsageListOnly)
+	c.fs.BoolVar(&c.Scan.DryRun, flagNameDryRun, false, flagUsageDryRun)
+
+	c.bind(flagNameInclude, config.KeyInclude)
+	c.bind(flagNameExclude, config.KeyExclude)
+}
+
+// registerExtractSafety - registers flags choosing what happens with extracted files which already exist, and with
+// entries which would be extracted outside of the output directory.
+func (c *Command) registerExtractSafety() {
+	c.fs.StringVar(&c.Scan.Overwrite, flagNameOverwrite, string(ax.OverwriteFail), flagUsageOverwrite)
+	c.fs.BoolVar(&c.Scan.AllowUnsafePaths, flagNameAllowUnsafe, false, flagUsageAllowUnsafe)
+
+	c.bind(flagNameOverwrite, config.KeyOverwrite)
+	c.bind(flagNameAllowUnsafe, keyAllowUnsafePaths)
+}
+
+// registerMetadataRestore - registers flags choosing if, and how, metadata of extracted entries is restored. The
+// profile value is shared with -preserve-metadata of the archiving commands.
+func (c *Command) registerMetadataRestore() {
+	c.fs.BoolVar(&c.Scan.PreserveMetadata, flagNamePreserveMeta, false, flagUsageRestoreMeta)
+	c.fs.BoolVar(&c.Scan.NumericOwner, flagNameNumericOwner, false, flagUsageNumericOwner)
+
+	c.bind(flagNamePreserveMeta, config.KeyPreserveMetadata)
+	c.bind(flagNameNumericOwner, config.KeyNumericOwner)
+}
+
+// stringList - flag.Value of a flag which can be repeated, collecting all of its values.
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+
+	return strings.Join(*l, ",")
+}
+
+// Set - appends the value to the list.
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+
+	return nil
+}
+
+// outcomeList - flag.Value of a flag which can be repeated, collecting the outcomes of all of its values. Each value
+// may be a comma separated list, i.e. 'failure,warning'.
+type outcomeList []hooks.Outcome
+
+func (l *outcomeList) String() string {
+	if l == nil {
+		return ""
+	}
+
+	names := make([]string, 0, len(*l))
+
+	for _, o := range *l {
+		names = append(names, string(o))
+	}
+
+	return strings.Join(names, ",")
+}
+
+// Set - appends the outcomes of the value to the list.
+func (l *outcomeList) Set(v string) error {
+	outcomes, err := hooks.ParseOutcomes([]string{v})
+	if err != nil {
+		return fmt.Errorf("invalid outcomes: %w", err)
+	}
+
+	*l = append(*l, outcomes...)
+
+	return nil
+}
+
+// applyDefaultArchiveSettings - sets the ax.ArchiveConfig tuning, and verification of the Archive(s), to their default
+// values. Used by the modes which don't register flags for them.
+func (cs *CmdScan) applyDefaultArchiveSettings() {
+	ac := ax.NewDefaultArchiveConfig()
+
+	cs.Verify = true
+
+	cs.ArchiveType = ac.ArchiveType
+	cs.BlockSize = ac.BlockSize.String()
+	cs.VolumeSize = strconv.FormatUint(ac.VolumeSize, 10)
+	cs.FastBytes = uint(ac.FastBytes)
+	cs.DictSize = fmt.Sprintf("%dm", ac.DictSize)
+	cs.Compression = uint(ac.Compression)
+	cs.HeadersEncryption = ac.HeadersEncryption
+	cs.SolidArchive = ac.SolidArchive
+	cs.PreserveMetadata = ac.PreserveMetadata
+
+	// Default sizes are always valid.
+	_ = cs.parseArchiveSizes()
+}
+
+// parseArchiveSizes - parses the human readable sizes of the ax.ArchiveConfig tuning into bytes, and checks that its
+// numeric settings fit the fields of ax.ArchiveConfig, so that they're never truncated.
+func (cs *CmdScan) parseArchiveSizes() error {
+	var err error
+
+	for _, n := range []struct {
+		flag     string
+		val, max uint
+	}{
+		{flagNameFastBytes, cs.FastBytes, math.MaxUint16},
+		{flagNameCompression, cs.Compression, math.MaxUint8},
+		{flagNameThreads, cs.Threads, math.MaxUint16},
+	} {
+		if n.val > n.max {
+			return fmt.Errorf("-%s: %w: %d is larger than %d", n.flag, ErrOutOfRange, n.val, n.max)
+		}
+	}
+
+	cs.VolumeBytes, err = ax.ParseSize(cs.VolumeSize, ax.DetermineBlockSize(cs.BlockSize))
+	if err != nil {
+		return fmt.Errorf("-%s: %w", flagNameVolumeSize, err)
+	}
+
+	cs.DictBytes, err = ax.ParseSize(cs.DictSize, ax.BlockSizeMB)
+	if err != nil {
+		return fmt.Errorf("-%s: %w", flagNameDictSize, err)
+	}
+
+	if cs.DictBytes%mebibyte != 0 || cs.DictBytes/mebibyte > math.MaxUint16 {
+		return fmt.Errorf("-%s: %w: %q isn't a whole number of megabytes, up to %dm",
+			flagNameDictSize, ax.ErrInvalidSize, cs.DictSize, math.MaxUint16)
+	}
+
+	cs.SolidBlockBytes = 0
+
+	if cs.SolidBlockSize != "" {
+		cs.SolidBlockBytes, err = ax.ParseSize(cs.SolidBlockSize, ax.BlockSizeMB)
+		if err != nil {
+			return fmt.Errorf("-%s: %w", flagNameSolidBlock, err)
+		}
+	}
+
+	return nil
+}
+
+// bind - ties the flag to the profile value and the AX_* environment variable with the chosen key.
+func (c *Command) bind(flagName, key string) {
+	c.keys[flagName] = key
+}
+
+// applyOverrides - fills in flags which weren't provided on the command line.
+//
+// Precedence, from highest to lowest: command line flag, AX_* environment variable, selected profile, default value.
+func (c *Command) applyOverrides(lookupEnv func(string) (string, bool)) error {
+	explicit := make(map[string]bool)
+	c.fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	if !explicit[flagNameConfig] {
+		c.Scan.ConfigPath, _ = lookupEnv(envConfig)
+	}
+
+	if !explicit[flagNameProfile] {
+		c.Scan.Profile, _ = lookupEnv(envProfile)
+	}
+
+	profileVals, err := c.loadProfileValues()
+	if err != nil {
+		return err
+	}
+
+	for flagName, key := range c.keys {
+		if explicit[flagName] {
+			continue
+		}
+
+		vals, ok := envValues(lookupEnv, key)
+		if !ok {
+			vals, ok = profileVals[key]
+		}
+
+		if !ok {
+			continue
+		}
+
+		var isList bool
+
+		switch c.fs.Lookup(flagName).Value.(type) {
+		case *stringList, *outcomeList:
+			isList = true
+		}
+
+		if len(vals) > 1 && !isList {
+			return fmt.Errorf("%w for -%s: %v", ErrTooManyValues, flagName, vals)
+		}
+
+		for _, v := range vals {
+			err = c.fs.Set(flagName, v)
+			if err != nil {
+				return fmt.Errorf("invalid value for -%s: %w", flagName, err)
+			}
+		}
+	}
+
+	c.defaultHeadersEncryption()
+
+	return nil
+}
+
+// defaultHeadersEncryption - turns headers encryption, which is on by default, off for the archive types which don't
+// support it, unless it has been asked for by the flag, its AX_* environment variable or the profile.
+func (c *Command) defaultHeadersEncryption() {
+	if c.fs.Lookup(flagNameHeadersEnc) == nil || ax.SupportsHeadersEncryption(c.Scan.ArchiveType) {
+		return
+	}
+
+	set := false
+	c.fs.Visit(func(f *flag.Flag) { set = set || f.Name == flagNameHeadersEnc })
+
+	if !set {
+		c.Scan.HeadersEncryption = false
+	}
+}
+
+// envValues - returns value(s) of the AX_* environment variable for the key.
+// List values, such as sources, are separated in the same way as PATH is.
+func envValues(lookupEnv func(string) (string, bool), key string) ([]string, bool) {
+	val, ok := lookupEnv(envPrefix + strings.ToUpper(key))
+	if !ok || val == "" {
+		return nil, false
+	}
+
+	switch key {
+	case config.KeySources, config.KeyInclude, config.KeyExclude:
+		return filepath.SplitList(val), true
+	}
+
+	return []string{val}, true
+}
+
+// loadProfileValues - loads values of the selected profile. If no profile has been selected, none are returned.
+func (c *Command) loadProfileValues() (map[string][]string, error) {
+	if c.Scan.Profile == "" {
+		return nil, nil
+	}
+
+	path := c.Scan.ConfigPath
+	if path == "" {
+		defaultPath, err := config.DefaultPath()
+		if err != nil {
+			return nil, fmt.Errorf("no config file provided: %w", err)
+		}
+
+		path = defaultPath
+	}
+
+	conf, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading profile %q: %w", c.Scan.Profile, err)
+	}
+
+	profile, err := conf.Profile(c.Scan.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading profile: %w", err)
+	}
+
+	return profile.Values(), nil
+}
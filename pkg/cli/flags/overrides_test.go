@@ -0,0 +1,183 @@
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/cli/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testOverridesConfig = `
+profiles:
+  nginx:
+    sources: [/etc/nginx]
+    name: profile-name
+    output: /var/tmp/ax_nginx
+    archive:
+      compression: 7
+`
+
+func (s *Suite) TestUnitApplyOverrides() {
+	var (
+		configPath string
+		env        map[string]string
+	)
+
+	prepare := func() {
+		configPath = filepath.Join(s.T().TempDir(), "config.yaml")
+		s.Require().Nil(os.WriteFile(configPath, []byte(testOverridesConfig), 0o600))
+
+		env = make(map[string]string)
+	}
+
+	lookupEnv := func(key string) (string, bool) {
+		v, ok := env[key]
+
+		return v, ok
+	}
+
+	// parse - parses the args with the archive command, applying the overrides of the test environment.
+	parse := func(args ...string) (*CmdScan, error) {
+		c := archiveCommand()
+		s.Require().Nil(c.fs.Parse(args))
+
+		err := c.applyOverrides(lookupEnv)
+
+		return &c.Scan, err
+	}
+
+	testCases := []ax.TestCase{
+		{
+			Name:          "success flag wins over the environment and the profile",
+			PreRequisites: prepare,
+			Assert: func() {
+				env["AX_NAME"] = "env-name"
+
+				cs, err := parse("-config", configPath, "-profile", "nginx", "-name", "flag-name")
+				s.Require().Nil(err)
+				assert.Equal(s.T(), "flag-name", cs.NewArchiveName)
+			},
+		},
+		{
+			Name:          "success environment wins over the profile",
+			PreRequisites: prepare,
+			Assert: func() {
+				env["AX_NAME"] = "env-name"
+
+				cs, err := parse("-config", configPath, "-profile", "nginx")
+				s.Require().Nil(err)
+				assert.Equal(s.T(), "env-name", cs.NewArchiveName)
+				assert.Equal(s.T(), "/var/tmp/ax_nginx", cs.ArchiveOutPath)
+			},
+		},
+		{
+			Name:          "success profile wins over the default, selected through the environment",
+			PreRequisites: prepare,
+			Assert: func() {
+				env["AX_CONFIG"], env["AX_PROFILE"] = configPath, "nginx"
+
+				cs, err := parse()
+				s.Require().Nil(err)
+				assert.Equal(s.T(), "nginx", cs.Profile)
+				assert.Equal(s.T(), "profile-name", cs.NewArchiveName)
+				assert.Equal(s.T(), uint(7), cs.Compression)
+				assert.Equal(s.T(), []string{"/etc/nginx"}, cs.Sources)
+			},
+		},
+		{
+			Name:          "success default is left without any override",
+			PreRequisites: prepare,
+			Assert: func() {
+				cs, err := parse()
+				s.Require().Nil(err)
+				assert.Equal(s.T(), flagValNewArchiveName, cs.NewArchiveName)
+				assert.Equal(s.T(), uint(ax.NewDefaultArchiveConfig().Compression), cs.Compression)
+			},
+		},
+		{
+			Name:          "success list values of the environment are split like PATH",
+			PreRequisites: prepare,
+			Assert: func() {
+				env["AX_SOURCES"] = strings.Join([]string{"/etc/nginx", "/srv/www"}, string(os.PathListSeparator))
+				env["AX_NAME"] = "a" + string(os.PathListSeparator) + "b"
+
+				cs, err := parse("-config", configPath, "-profile", "nginx")
+				s.Require().Nil(err)
+				assert.Equal(s.T(), []string{"/etc/nginx", "/srv/www"}, cs.Sources)
+				assert.Equal(s.T(), "a"+string(os.PathListSeparator)+"b", cs.NewArchiveName, "only lists are split")
+			},
+		},
+		{
+			Name:          "success extraction commands share the flag and key of the output directory",
+			PreRequisites: prepare,
+			Assert: func() {
+				env["AX_EXTRACT_OUT"] = "/srv/env"
+
+				for _, c := range []*Command{extractCommand(), restoreCommand()} {
+					s.Require().Nil(c.fs.Parse(nil))
+					s.Require().Nil(c.applyOverrides(lookupEnv))
+					assert.Equal(s.T(), "/srv/env", c.Scan.ExtractOut, c.Name)
+
+					s.Require().Nil(c.fs.Parse([]string{"-" + flagNameExtractOut, "/srv/flag"}))
+					assert.Equal(s.T(), "/srv/flag", c.Scan.ExtractOut, c.Name)
+				}
+			},
+		},
+		{
+			Name:          "success headers encryption is on by default only for 7z archives",
+			PreRequisites: prepare,
+			Assert: func() {
+				cs, err := parse()
+				s.Require().Nil(err)
+				assert.True(s.T(), cs.HeadersEncryption)
+
+				cs, err = parse("-type", "zip")
+				s.Require().Nil(err)
+				assert.False(s.T(), cs.HeadersEncryption)
+
+				env["AX_HEADERS_ENCRYPTION"] = "true"
+
+				cs, err = parse("-type", "zip")
+				s.Require().Nil(err)
+				assert.True(s.T(), cs.HeadersEncryption, "asked for, so that it's reported as unsupported")
+			},
+		},
+		{
+			Name:          "err numeric values which don't fit the archive config",
+			PreRequisites: prepare,
+			Assert: func() {
+				for _, args := range [][]string{
+					{"-compression", "265"}, {"-fast-bytes", "65541"}, {"-threads", "65536"},
+				} {
+					cs, err := parse(args...)
+					s.Require().Nil(err)
+					assert.ErrorIs(s.T(), cs.parseArchiveSizes(), ErrOutOfRange, args)
+				}
+			},
+		},
+		{
+			Name:          "err unknown profile",
+			PreRequisites: prepare,
+			Assert: func() {
+				_, err := parse("-config", configPath, "-profile", "missing")
+				assert.ErrorIs(s.T(), err, config.ErrProfileNotFound)
+			},
+		},
+		{
+			Name:          "err invalid value of the environment",
+			PreRequisites: prepare,
+			Assert: func() {
+				env["AX_COMPRESSION"] = "ultra"
+
+				_, err := parse()
+				assert.NotNil(s.T(), err)
+				assert.Contains(s.T(), err.Error(), "-compression")
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
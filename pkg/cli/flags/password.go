@@ -0,0 +1,177 @@
+package flags
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+const (
+	envArchivePassword    = envPrefix + "ARCHIVE_PASSWORD"
+	envEncryptionPassword = envPrefix + "ENCRYPTION_PASSWORD"
+
+	// unsetFD - value of the fd flags when no file descriptor has been chosen, as 0 is a valid one (stdin).
+	unsetFD = -1
+
+	osWindows = "windows"
+)
+
+var (
+	// ErrEmptyPassword - non-interactive password source provided an empty password.
+	ErrEmptyPassword = errors.New("password source provided an empty password")
+
+	// ErrPasswordRequired - password would have been prompted for, while ax runs non-interactively.
+	ErrPasswordRequired = errors.New("password is required, but ax runs non-interactively")
+)
+
+// PasswordSource - represents non-interactive sources a password can be read from.
+//
+// Sources are tried in the following order: File, FD, Command, Env. If none of them is set, the password is read
+// from the terminal, as before. Only the first line provided by a source is used as the password, which follows the
+// convention of password managers such as `pass`.
+type PasswordSource struct {
+	// File - path of the file holding the password.
+	File string
+
+	// FD - inherited file descriptor from which the password is read, i.e. `3` for `ax ... 3<secret`.
+	FD int
+
+	// Command - command which prints the password to its stdout, i.e. `pass show backups/ax`.
+	// It's run through the system shell, while its stderr is left attached, so that it can prompt the user.
+	Command string
+
+	// Env - name of the environment variable holding the password.
+	Env string
+
+	// kind - prefix of the flags choosing the sources, i.e. `archive`.
+	kind string
+}
+
+// isSet - reports whether any of the non-interactive sources has been chosen.
+func (ps *PasswordSource) isSet() bool {
+	if ps.File != "" || ps.FD != unsetFD || ps.Command != "" {
+		return true
+	}
+
+	_, ok := os.LookupEnv(ps.Env)
+
+	return ps.Env != "" && ok
+}
+
+// ReadNonInteractive - reads the password from the first source which has been set, returning ErrPasswordRequired,
+// which names the sources, if there is none.
+func (ps *PasswordSource) ReadNonInteractive() ([]byte, error) {
+	if !ps.isSet() {
+		return nil, fmt.Errorf("%w: set -%[2]s%[3]s, -%[2]s%[4]s, -%[2]s%[5]s or %[6]s", ErrPasswordRequired,
+			ps.kind, flagSuffixPasswordFile, flagSuffixPasswordCommand, flagSuffixPasswordFD, ps.Env)
+	}
+
+	return ps.Read("")
+}
+
+// Read - reads the password from the first source which has been set, prompting the terminal if there is none.
+func (ps *PasswordSource) Read(prompt string) ([]byte, error) {
+	if !ps.isSet() {
+		return protectedScan(prompt)
+	}
+
+	var (
+		raw []byte
+		err error
+	)
+
+	switch {
+	case ps.File != "":
+		raw, err = os.ReadFile(ps.File)
+	case ps.FD != unsetFD:
+		raw, err = readFD(ps.FD)
+	case ps.Command != "":
+		raw, err = runPasswordCommand(ps.Command)
+	default:
+		raw = []byte(os.Getenv(ps.Env))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed reading password: %w", err)
+	}
+
+	passwd := firstLine(raw)
+	if len(passwd) == 0 {
+		return nil, ErrEmptyPassword
+	}
+
+	return passwd, nil
+}
+
+// readFD - reads everything from the inherited file descriptor, and closes it afterwards.
+func readFD(fd int) ([]byte, error) {
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+	if f == nil {
+		return nil, fmt.Errorf("invalid file descriptor: %d", fd)
+	}
+
+	defer f.Close()
+
+	raw, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading fd %d: %w", fd, err)
+	}
+
+	return raw, nil
+}
+
+// runPasswordCommand - runs the password helper through the system shell, returning its stdout.
+func runPasswordCommand(command string) ([]byte, error) {
+	shell, shellFlag := "sh", "-c"
+	if runtime.GOOS == osWindows {
+		shell, shellFlag = "cmd", "/C"
+	}
+
+	//nolint:gosec // Running the command configured by the user is the whole point of this source.
+	cmd := exec.Command(shell, shellFlag, command)
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("password command failed: %w", err)
+	}
+
+	return out, nil
+}
+
+// firstLine - returns the first line of the input, without the line ending.
+func firstLine(raw []byte) []byte {
+	s := bufio.NewScanner(bytes.NewReader(raw))
+	if !s.Scan() {
+		return nil
+	}
+
+	return bytes.TrimRight(s.Bytes(), "\r")
+}
+
+// envPasswordSource - returns PasswordSource reading just from the environment variable, or else the terminal.
+func envPasswordSource(env string) *PasswordSource {
+	return &PasswordSource{FD: unsetFD, Env: env}
+}
+
+// registerPasswordSource - registers flags choosing non-interactive sources of a password, prefixed with `kind`
+// (i.e. -archive-password-file). File and command sources can also be set from a profile, under the chosen keys.
+func (c *Command) registerPasswordSource(ps *PasswordSource, kind, env, fileKey, cmdKey string) {
+	fileFlag := kind + flagSuffixPasswordFile
+	fdFlag := kind + flagSuffixPasswordFD
+	cmdFlag := kind + flagSuffixPasswordCommand
+
+	c.fs.StringVar(&ps.File, fileFlag, "", fmt.Sprintf(flagUsagePasswordFile, kind))
+	c.fs.IntVar(&ps.FD, fdFlag, unsetFD, fmt.Sprintf(flagUsagePasswordFD, kind))
+	c.fs.StringVar(&ps.Command, cmdFlag, "", fmt.Sprintf(flagUsagePasswordCommand, kind, env))
+
+	ps.Env, ps.kind = env, kind
+
+	c.bind(fileFlag, fileKey)
+	c.bind(cmdFlag, cmdKey)
+}
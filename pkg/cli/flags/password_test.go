@@ -0,0 +1,129 @@
+package flags
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/kaynetik/ax"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPasswordEnv - environment variable the password is read from by the tests, which no one else sets.
+const testPasswordEnv = "AX_TEST_FLAGS_PASSWORD"
+
+func (s *Suite) TestUnitPasswordSource() {
+	var (
+		dir string
+		ps  *PasswordSource
+	)
+
+	prepare := func() {
+		dir = s.T().TempDir()
+		ps = &PasswordSource{FD: unsetFD, Env: testPasswordEnv + "_UNSET", kind: passwordKindArchive}
+	}
+
+	// writeFile - writes the content into a file of the test, returning its path.
+	writeFile := func(content string) string {
+		path := filepath.Join(dir, "password")
+		s.Require().Nil(os.WriteFile(path, []byte(content), 0o600))
+
+		return path
+	}
+
+	testCases := []ax.TestCase{
+		{
+			Name:          "success file, with the line ending stripped",
+			PreRequisites: prepare,
+			Assert: func() {
+				ps.File = writeFile("s3cret\r\nsecond line\n")
+
+				passwd, err := ps.ReadNonInteractive()
+				s.Require().Nil(err)
+				assert.Equal(s.T(), []byte("s3cret"), passwd)
+			},
+		},
+		{
+			Name:          "success file wins over the command",
+			PreRequisites: prepare,
+			Assert: func() {
+				ps.File = writeFile("from-file\n")
+				ps.Command = "echo from-command"
+
+				passwd, err := ps.Read("")
+				s.Require().Nil(err)
+				assert.Equal(s.T(), []byte("from-file"), passwd)
+			},
+		},
+		{
+			Name:          "success command, taking the first line of its stdout",
+			PreRequisites: prepare,
+			Assert: func() {
+				ps.Command = "echo from-command && echo second line"
+
+				passwd, err := ps.ReadNonInteractive()
+				s.Require().Nil(err)
+				assert.Equal(s.T(), []byte("from-command"), passwd)
+			},
+		},
+		{
+			Name:          "success env",
+			PreRequisites: prepare,
+			Assert: func() {
+				s.Require().Nil(os.Setenv(testPasswordEnv, "from-env"))
+				defer os.Unsetenv(testPasswordEnv)
+
+				ps.Env = testPasswordEnv
+
+				passwd, err := ps.ReadNonInteractive()
+				s.Require().Nil(err)
+				assert.Equal(s.T(), []byte("from-env"), passwd)
+			},
+		},
+		{
+			Name:          "err failing command",
+			PreRequisites: prepare,
+			Assert: func() {
+				ps.Command = "echo never-used && exit 3"
+
+				passwd, err := ps.ReadNonInteractive()
+				assert.Nil(s.T(), passwd)
+				assert.Contains(s.T(), err.Error(), "password command failed")
+			},
+		},
+		{
+			Name:          "err missing file",
+			PreRequisites: prepare,
+			Assert: func() {
+				ps.File = filepath.Join(dir, "missing")
+
+				_, err := ps.ReadNonInteractive()
+				assert.ErrorIs(s.T(), err, os.ErrNotExist)
+			},
+		},
+		{
+			Name:          "err empty password",
+			PreRequisites: prepare,
+			Assert: func() {
+				ps.File = writeFile("\nsecond line\n")
+
+				_, err := ps.ReadNonInteractive()
+				assert.ErrorIs(s.T(), err, ErrEmptyPassword)
+			},
+		},
+		{
+			Name:          "err no source, while ax runs non-interactively",
+			PreRequisites: prepare,
+			Assert: func() {
+				cs := &CmdScan{NonInteractive: true}
+
+				passwd, err := cs.readPassword(ps, promptEnterPasswordForArchiveEncryption)
+				assert.Nil(s.T(), passwd)
+				assert.ErrorIs(s.T(), err, ErrPasswordRequired)
+				assert.Contains(s.T(), err.Error(), "-archive-password-file")
+				assert.Contains(s.T(), err.Error(), ps.Env)
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
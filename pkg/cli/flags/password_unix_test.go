@@ -0,0 +1,42 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package flags
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/kaynetik/ax"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitPasswordSourceFD() {
+	testCases := []ax.TestCase{
+		{
+			Name: "success fd",
+			Assert: func() {
+				r, w, err := os.Pipe()
+				s.Require().Nil(err)
+
+				defer r.Close()
+
+				_, err = w.WriteString("from-fd\n")
+				s.Require().Nil(err)
+				s.Require().Nil(w.Close())
+
+				// Duplicated, as the password source closes the fd it has read, while r closes its own.
+				fd, err := syscall.Dup(int(r.Fd()))
+				s.Require().Nil(err)
+
+				ps := &PasswordSource{FD: fd, kind: passwordKindEncryption}
+
+				passwd, err := ps.ReadNonInteractive()
+				s.Require().Nil(err)
+				assert.Equal(s.T(), []byte("from-fd"), passwd)
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
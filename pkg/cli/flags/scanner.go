@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/kaynetik/ax/pkg/hooks"
 	"golang.org/x/term"
 )
 
@@ -23,10 +25,105 @@ type CmdScan struct {
 	DecryptPath              string
 	EncryptPassword          []byte
 	DecryptPassword          []byte
+
+	// ConfigPath - path of the config file, holding named backup profiles.
+	ConfigPath string
+	// Profile - name of the selected backup profile.
+	Profile string
+	// Output - format in which the result of the command is printed.
+	Output string
+	// Sources - directories and files which are archived, along with PathToArchive.
+	Sources []string
+	// Root - directory the sources are stored relative to.
+	Root string
+
+	// Include & Exclude - gitignore-style patterns selecting the archived files.
+	Include []string
+	Exclude []string
+	// ListOnly - only the files which would be archived are listed.
+	ListOnly bool
+	// DryRun - only what would be archived, executed, deleted and overwritten is reported.
+	DryRun bool
+
+	// Files - gitignore-style patterns selecting the extracted entries.
+	Files []string
+	// ExtractOut - directory the Archive(s) are extracted into, instead of the one holding them.
+	ExtractOut string
+	// Overwrite - policy for extracted files which already exist: fail, skip, overwrite or rename.
+	Overwrite string
+	// AllowUnsafePaths - entries which would be extracted outside of the output directory aren't rejected.
+	AllowUnsafePaths bool
+
+	// PreserveMetadata - ownership, exact modes, times and extended attributes are preserved while archiving, and
+	// restored while extracting.
+	PreserveMetadata bool
+	// NumericOwner - ownership is restored by the archived uid and gid, instead of the user and group names.
+	NumericOwner bool
+
+	// Verify - integrity of the Archive(s) is tested before they're encrypted.
+	Verify bool
+	// Stream - the Archive is streamed through the encryption into volumes, instead of being archived by 7zip.
+	Stream bool
+	// VerifyOnly - restored Archive(s) are only tested, instead of being extracted.
+	VerifyOnly bool
+
+	// Verbose & Quiet - how much of the progress is printed to stderr.
+	Verbose bool
+	Quiet   bool
+
+	// ArchivePasswordSource - where the password protecting the Archive(s) is read from.
+	ArchivePasswordSource PasswordSource
+	// EncryptionPasswordSource - where the password for Encryption/Decryption of the Archive(s) is read from.
+	EncryptionPasswordSource PasswordSource
+	// NonInteractive - passwords are read only from their non-interactive sources, failing instead of prompting.
+	NonInteractive bool
+
+	// Socket - Unix socket the daemon reports its status on.
+	Socket string
+	// Concurrency - maximum number of profiles the daemon pushes at once.
+	Concurrency uint
+	// Jitter - maximum random delay of each push started by the daemon.
+	Jitter time.Duration
+	// HistoryPath - file recording the pushes started by the daemon.
+	HistoryPath string
+
+	// Debounce, MinInterval & MaxBackoff - timing of the pushes started by watch, once the sources change.
+	Debounce    time.Duration
+	MinInterval time.Duration
+	MaxBackoff  time.Duration
+	// PollInterval - how often watch polls the sources for changes, instead of watching them natively.
+	PollInterval time.Duration
+
+	// Hooks - what's run after each push, to report its outcome.
+	Hooks hooks.Config
+	// MetricsFile - file the metrics of each push are written into, for the node_exporter textfile collector.
+	MetricsFile string
+	// MetricsAddr - address the daemon serves the metrics of its pushes on.
+	MetricsAddr string
+
+	ArchiveType       string
+	BlockSize         string
+	VolumeSize        string
+	FastBytes         uint
+	DictSize          string
+	Compression       uint
+	HeadersEncryption bool
+	SolidArchive      bool
+	SolidBlockSize    string
+	Method            string
+	Threads           uint
+
+	// VolumeBytes, DictBytes & SolidBlockBytes - VolumeSize, DictSize and SolidBlockSize parsed into bytes.
+	VolumeBytes     uint64
+	DictBytes       uint64
+	SolidBlockBytes uint64
 }
 
 // ParseAllFlags - parses flags from the tty and applies validation for that input.
-func ParseAllFlags() *CmdScan {
+// Error is returned if reading any of the passwords fails.
+//
+// Deprecated: flag-style invocation is superseded by subcommands, use ParseCommand instead.
+func ParseAllFlags() (*CmdScan, error) {
 	var (
 		err          error
 		cs           CmdScan
@@ -45,6 +142,8 @@ func ParseAllFlags() *CmdScan {
 
 	flag.Parse()
 
+	cs.applyDefaultArchiveSettings()
+
 	var (
 		argsStr          string
 		archiveCalled    bool
@@ -60,9 +159,9 @@ func ParseAllFlags() *CmdScan {
 	pushCalled = encryptionCalled && cs.GitRepo != flagValGitRepo && flagPass == flagValPass
 
 	if archiveCalled || pushCalled {
-		bytePassword, err = protectedScan(promptEnterPasswordForArchiveEncryption)
+		bytePassword, err = envPasswordSource(envArchivePassword).Read(promptEnterPasswordForArchiveEncryption)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 
 		cs.ProtectArchiveWithPasswd = true
@@ -72,29 +171,30 @@ func ParseAllFlags() *CmdScan {
 	}
 
 	if encryptionCalled || pushCalled {
-		bytePassword, err = protectedScan(promptEnterPasswordForEncryption)
+		bytePassword, err = envPasswordSource(envEncryptionPassword).Read(promptEnterPasswordForEncryption)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 
 		cs.EncryptPassword = bytePassword
 	}
 
 	if decryptionCalled {
-		bytePassword, err = protectedScan(promptEnterPasswordForDecryption)
+		bytePassword, err = envPasswordSource(envEncryptionPassword).Read(promptEnterPasswordForDecryption)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 
 		cs.DecryptPassword = bytePassword
 	}
 
-	return &cs
+	return &cs, nil
 }
 
 // protectedScan - used to read password from stdin. Input is being hidden while typing.
+// The prompt is printed to stderr, so that it never ends up in the output of the command.
 func protectedScan(prompt string) ([]byte, error) {
-	printStdoutLn(fmt.Sprintf("\n%s: ", prompt))
+	_, _ = fmt.Fprintf(os.Stderr, "\n%s: \n", prompt)
 
 	bytePassword, err := term.ReadPassword(int(os.Stdin.Fd()))
 	if err != nil {
@@ -130,10 +230,12 @@ func (s *scanner) scanWithMsg(prompt string) string {
 // User will be prompted line by line for the required information. If something is left out, it will be ignored
 //	if not required. But if it's required then a default value will be used instead.
 // But for cases like Password for protecting the Archive - it can be left blank, but it's advised not to do so.
-func (cs *CmdScan) ReadUserInput(fn getScannerFn) {
+func (cs *CmdScan) ReadUserInput(fn getScannerFn) error {
 	// Get new bufio scanner instance [*bufio.Scanner].
 	s := newScanner(fn())
 
+	cs.applyDefaultArchiveSettings()
+
 	// Scan for Path which should be Archived.
 	pathToArchive := s.scanWithMsg("Path to Archive")
 	if pathToArchive != "" {
@@ -143,12 +245,8 @@ func (cs *CmdScan) ReadUserInput(fn getScannerFn) {
 	}
 
 	// Scan for Archive Temporary Output path.
-	archOutPath := s.scanWithMsg("Path for Archive(s) Output (default '../tmp_archive_out')")
-	if archOutPath != "" {
-		cs.ArchiveOutPath = archOutPath
-	} else {
-		cs.ArchiveOutPath = flagValArchiveOutPath
-	}
+	// Left blank, the work directory of the backup under the system temp dir is used.
+	cs.ArchiveOutPath = s.scanWithMsg("Path for Archive(s) Output (default: a directory under the system temp dir)")
 
 	// Scan for GIT Repository.
 	gitRepo := s.scanWithMsg("GIT Repository")
@@ -162,7 +260,7 @@ func (cs *CmdScan) ReadUserInput(fn getScannerFn) {
 	// Scan for Archive Password.
 	archivePasswd, err := protectedScan("Password for Archive protection")
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	cs.PasswordByte = archivePasswd
@@ -178,13 +276,15 @@ func (cs *CmdScan) ReadUserInput(fn getScannerFn) {
 		// Scan for Encryption Password.
 		encryptionPassword, errPwd := protectedScan("Password for Archive Volume(s) Encryption")
 		if errPwd != nil {
-			panic(errPwd)
+			return errPwd
 		}
 
 		cs.EncryptPassword = encryptionPassword
 	}
 
 	cs.EncryptPath = cs.ArchiveOutPath // TODO: We might want this flexible?
+
+	return nil
 }
 
 func printStdoutLn(args ...interface{}) {
@@ -0,0 +1,107 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Level - represents the minimal severity of messages printed by HumanLogger.
+type Level int
+
+// Levels of HumanLogger, from the most verbose one.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+const (
+	prefixDebug = "DEBUG: "
+	prefixWarn  = "WARN: "
+	prefixError = "ERROR: "
+
+	missingValue = "!MISSING"
+)
+
+// LevelFor - returns the Level matching -v/-q flags, where verbose takes precedence.
+func LevelFor(verbose, quiet bool) Level {
+	switch {
+	case verbose:
+		return LevelDebug
+	case quiet:
+		return LevelError
+	default:
+		return LevelInfo
+	}
+}
+
+// HumanLogger - ax.Logger which prints messages in a human-readable format, i.e. "Finished Archiving! (path=./out)".
+//
+// Info messages are printed as they are, while the other levels are prefixed with their name.
+type HumanLogger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+// NewHumanLogger - returns HumanLogger printing to w messages of the chosen Level and above.
+func NewHumanLogger(w io.Writer, level Level) *HumanLogger {
+	return &HumanLogger{w: w, level: level}
+}
+
+// Debug - prints the debug message, if the logger is verbose.
+func (l *HumanLogger) Debug(msg string, args ...interface{}) {
+	l.print(LevelDebug, prefixDebug, msg, args)
+}
+
+// Info - prints the progress message.
+func (l *HumanLogger) Info(msg string, args ...interface{}) {
+	l.print(LevelInfo, "", msg, args)
+}
+
+// Warn - prints the warning.
+func (l *HumanLogger) Warn(msg string, args ...interface{}) {
+	l.print(LevelWarn, prefixWarn, msg, args)
+}
+
+// Error - prints the error, unless nothing is to be printed at all.
+func (l *HumanLogger) Error(msg string, args ...interface{}) {
+	l.print(LevelError, prefixError, msg, args)
+}
+
+func (l *HumanLogger) print(level Level, prefix, msg string, args []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	line := prefix + msg
+	if attrs := formatAttrs(args); attrs != "" {
+		line = fmt.Sprintf("%s (%s)", line, attrs)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, _ = fmt.Fprintln(l.w, line)
+}
+
+// formatAttrs - formats key/value pairs as "key=value, key=value". A key without value is reported as missing,
+// the same way log/slog does it.
+func formatAttrs(args []interface{}) string {
+	attrs := make([]string, 0, (len(args)+1)/2) //nolint:gomnd // Pairs of key and value.
+
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			attrs = append(attrs, fmt.Sprintf("%s=%v", missingValue, args[i]))
+
+			break
+		}
+
+		attrs = append(attrs, fmt.Sprintf("%v=%v", args[i], args[i+1]))
+	}
+
+	return strings.Join(attrs, ", ")
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"bytes"
+
+	"github.com/kaynetik/ax"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitHumanLogger() {
+	testCases := []ax.TestCase{
+		{
+			Name: "success levels chosen by flags",
+			Assert: func() {
+				assert.Equal(s.T(), LevelInfo, LevelFor(false, false))
+				assert.Equal(s.T(), LevelDebug, LevelFor(true, false))
+				assert.Equal(s.T(), LevelError, LevelFor(false, true))
+				assert.Equal(s.T(), LevelDebug, LevelFor(true, true))
+			},
+		},
+		{
+			Name: "success default level",
+			Assert: func() {
+				buf := &bytes.Buffer{}
+				l := NewHumanLogger(buf, LevelInfo)
+
+				l.Debug("executing command", "cmd", "7z")
+				l.Info("Finished Archiving!", "path", "./in", "output", "./out")
+				l.Warn("no changes")
+				l.Error("push failed", "repo")
+
+				assert.Equal(s.T(), "Finished Archiving! (path=./in, output=./out)\n"+
+					"WARN: no changes\n"+
+					"ERROR: push failed (!MISSING=repo)\n", buf.String())
+			},
+		},
+		{
+			Name: "success verbose and quiet",
+			Assert: func() {
+				buf := &bytes.Buffer{}
+
+				NewHumanLogger(buf, LevelDebug).Debug("executing command", "cmd", "7z")
+				assert.Equal(s.T(), "DEBUG: executing command (cmd=7z)\n", buf.String())
+
+				buf.Reset()
+
+				l := NewHumanLogger(buf, LevelError)
+				l.Info("Finished Archiving!")
+				l.Warn("no changes")
+				assert.Empty(s.T(), buf.String())
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
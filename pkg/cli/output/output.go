@@ -0,0 +1,214 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kaynetik/ax"
+)
+
+// Exit codes of the ax CLI. Those are part of its API, so they must never be renumbered.
+const (
+	ExitOK                = 0
+	ExitFailure           = 1
+	ExitUsage             = 2
+	ExitMissingDependency = 3
+	ExitWrongPassword     = 4
+	ExitIntegrity         = 5
+	ExitRemote            = 6
+	ExitUnsafePath        = 7
+
+	// ExitInterrupted - ax has been interrupted by SIGINT or SIGTERM, following the shell convention of 128+SIGINT.
+	ExitInterrupted = 130
+)
+
+// Formats in which the result of a command can be printed.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Kinds of errors, as reported in the JSON output.
+const (
+	KindUsage             = "usage"
+	KindMissingDependency = "missing_dependency"
+	KindWrongPassword     = "wrong_password"
+	KindIntegrity         = "integrity"
+	KindRemote            = "remote"
+	KindUnsafePath        = "unsafe_path"
+	KindInterrupted       = "interrupted"
+	KindFailure           = "failure"
+
+	statusOK    = "ok"
+	statusError = "error"
+
+	// hintMissingDependency - printed along with ErrMissingDependency in text format, unless it's reported by the
+	// doctor command itself.
+	hintMissingDependency = "Hint: run 'ax doctor' to check which dependencies are missing, and how to install them"
+	cmdDoctor             = "doctor"
+)
+
+var (
+	// ErrUsage - the CLI has been invoked with invalid commands, flags or values.
+	ErrUsage = errors.New("usage error")
+
+	// ErrUnknownFormat - output format isn't one of FormatText or FormatJSON.
+	ErrUnknownFormat = errors.New("unknown output format")
+)
+
+// usageError - wraps errors caused by invalid usage of the CLI, so that they are reported with ExitUsage.
+type usageError struct{ err error }
+
+func (e usageError) Error() string        { return e.err.Error() }
+func (e usageError) Unwrap() error        { return e.err }
+func (e usageError) Is(target error) bool { return target == ErrUsage }
+
+// Usage - marks the error as caused by invalid usage of the CLI.
+func Usage(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return usageError{err: err}
+}
+
+// ValidateFormat - returns ErrUnknownFormat if the format isn't supported.
+func ValidateFormat(format string) error {
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("%w: %q (supported: %s, %s)", ErrUnknownFormat, format, FormatText, FormatJSON)
+	}
+
+	return nil
+}
+
+// classify - returns the exit code and the kind of the error.
+func classify(err error) (int, string) {
+	switch {
+	case err == nil:
+		return ExitOK, ""
+	case errors.Is(err, context.Canceled):
+		// Command has been canceled as ax has been interrupted, which is the cause of however it has failed.
+		return ExitInterrupted, KindInterrupted
+	case errors.Is(err, ErrUsage), errors.Is(err, ax.ErrInvalidArchiveConfig):
+		return ExitUsage, KindUsage
+	case errors.Is(err, ax.ErrMissingDependency):
+		return ExitMissingDependency, KindMissingDependency
+	case errors.Is(err, ax.ErrWrongPassword):
+		return ExitWrongPassword, KindWrongPassword
+	case errors.Is(err, ax.ErrIntegrity):
+		return ExitIntegrity, KindIntegrity
+	case errors.Is(err, ax.ErrRemote):
+		return ExitRemote, KindRemote
+	case errors.Is(err, ax.ErrUnsafePath):
+		return ExitUnsafePath, KindUnsafePath
+	default:
+		return ExitFailure, KindFailure
+	}
+}
+
+// ExitCode - returns the exit code the CLI should exit with, for the error returned by a command.
+func ExitCode(err error) int {
+	code, _ := classify(err)
+
+	return code
+}
+
+// Result - represents machine-readable outcome of a command.
+type Result struct {
+	Command         string     `json:"command"`
+	Status          string     `json:"status"`
+	ExitCode        int        `json:"exit_code"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	Error           *ErrorInfo `json:"error,omitempty"`
+
+	// Data - what the command reports, if anything (i.e. the listed files).
+	Data interface{} `json:"data,omitempty"`
+}
+
+// TextWriter - implemented by Result.Data, which is printed in text format.
+type TextWriter interface {
+	WriteText(w io.Writer) error
+}
+
+// ErrorInfo - represents the error of a failed command.
+type ErrorInfo struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// Lines - Result.Data printed one item per line in text format, and as an array in JSON.
+type Lines []string
+
+// WriteText - writes each line to w.
+func (l Lines) WriteText(w io.Writer) error {
+	for _, line := range l {
+		_, err := fmt.Fprintln(w, line)
+		if err != nil {
+			return fmt.Errorf("failed writing line: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// NewResult - builds Result of the command, which started at `started` and returned `data` and `err`.
+func NewResult(command string, started time.Time, data interface{}, err error) Result {
+	code, kind := classify(err)
+
+	r := Result{
+		Command:         command,
+		Status:          statusOK,
+		ExitCode:        code,
+		DurationSeconds: time.Since(started).Seconds(),
+		Data:            data,
+	}
+
+	if err != nil {
+		r.Status = statusError
+		r.Error = &ErrorInfo{Kind: kind, Message: err.Error()}
+	}
+
+	return r
+}
+
+// Print - prints the result in the chosen format.
+//
+// JSON results are always printed to stdout. In text format only the Data (to stdout) and errors (to stderr) are
+// printed, as the progress of the command has already been printed along the way.
+func Print(stdout, stderr io.Writer, format string, r Result) error {
+	if format == FormatJSON {
+		err := json.NewEncoder(stdout).Encode(r)
+		if err != nil {
+			return fmt.Errorf("failed encoding result: %w", err)
+		}
+
+		return nil
+	}
+
+	if tw, ok := r.Data.(TextWriter); ok {
+		err := tw.WriteText(stdout)
+		if err != nil {
+			return fmt.Errorf("failed printing result: %w", err)
+		}
+	}
+
+	if r.Error != nil {
+		_, err := fmt.Fprintf(stderr, "Error (%s): %s\n", r.Error.Kind, r.Error.Message)
+		if err != nil {
+			return fmt.Errorf("failed printing result: %w", err)
+		}
+
+		if r.Error.Kind == KindMissingDependency && r.Command != cmdDoctor {
+			_, err = fmt.Fprintln(stderr, hintMissingDependency)
+			if err != nil {
+				return fmt.Errorf("failed printing result: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type Suite struct {
+	suite.Suite
+
+	// stdout & stderr - buffers the result is printed to.
+	stdout, stderr *bytes.Buffer
+}
+
+func TestSuite(t *testing.T) {
+	t.Parallel()
+
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) TestUnitExitCode() {
+	testCases := []ax.TestCase{
+		{
+			Name: "success map all cases",
+			Assert: func() {
+				cases := []struct {
+					err      error
+					expected int
+				}{
+					{nil, ExitOK},
+					{errors.New("something failed"), ExitFailure},
+					{Usage(errors.New("required flag is missing")), ExitUsage},
+					{fmt.Errorf("archiving: %w", ax.ErrInvalidArchiveConfig), ExitUsage},
+					{fmt.Errorf("archiving: %w", ax.ErrMissingDependency), ExitMissingDependency},
+					{fmt.Errorf("extracting: %w", ax.ErrWrongPassword), ExitWrongPassword},
+					{fmt.Errorf("extracting: %w", ax.ErrIntegrity), ExitIntegrity},
+					{fmt.Errorf("pushing: %w", ax.ErrRemote), ExitRemote},
+					{fmt.Errorf("extracting: %w", &ax.UnsafePathsError{}), ExitUnsafePath},
+					{fmt.Errorf("pushing: %w", context.Canceled), ExitInterrupted},
+				}
+
+				for _, c := range cases {
+					assert.Equal(s.T(), c.expected, ExitCode(c.err), fmt.Sprint(c.err))
+				}
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitPrint() {
+	testCases := []ax.TestCase{
+		{
+			Name: "success json error result",
+			PreRequisites: func() {
+				s.stdout, s.stderr = &bytes.Buffer{}, &bytes.Buffer{}
+			},
+			Assert: func() {
+				r := NewResult("extract", time.Now(), nil, fmt.Errorf("extracting: %w", ax.ErrWrongPassword))
+
+				err := Print(s.stdout, s.stderr, FormatJSON, r)
+				assert.Nil(s.T(), err)
+
+				var got Result
+
+				assert.Nil(s.T(), json.Unmarshal(s.stdout.Bytes(), &got))
+				assert.Equal(s.T(), "error", got.Status)
+				assert.Equal(s.T(), ExitWrongPassword, got.ExitCode)
+				assert.Equal(s.T(), KindWrongPassword, got.Error.Kind)
+				assert.Equal(s.T(), 0, s.stderr.Len())
+			},
+		},
+		{
+			Name: "success text ok result prints nothing",
+			PreRequisites: func() {
+				s.stdout, s.stderr = &bytes.Buffer{}, &bytes.Buffer{}
+			},
+			Assert: func() {
+				err := Print(s.stdout, s.stderr, FormatText, NewResult("archive", time.Now(), nil, nil))
+
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), 0, s.stdout.Len())
+				assert.Equal(s.T(), 0, s.stderr.Len())
+			},
+		},
+		{
+			Name: "success text error result printed to stderr",
+			PreRequisites: func() {
+				s.stdout, s.stderr = &bytes.Buffer{}, &bytes.Buffer{}
+			},
+			Assert: func() {
+				r := NewResult("push", time.Now(), nil, Usage(errors.New("required flag is missing: -repo")))
+
+				err := Print(s.stdout, s.stderr, FormatText, r)
+
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), 0, s.stdout.Len())
+				assert.Contains(s.T(), s.stderr.String(), "-repo")
+			},
+		},
+		{
+			Name: "success hint printed with missing dependency",
+			PreRequisites: func() {
+				s.stdout, s.stderr = &bytes.Buffer{}, &bytes.Buffer{}
+			},
+			Assert: func() {
+				err := fmt.Errorf("archiving: %w", ax.ErrMissingDependency)
+
+				assert.Nil(s.T(), Print(s.stdout, s.stderr, FormatText, NewResult("push", time.Now(), nil, err)))
+				assert.Contains(s.T(), s.stderr.String(), "ax doctor")
+
+				s.stderr.Reset()
+
+				assert.Nil(s.T(), Print(s.stdout, s.stderr, FormatText, NewResult("doctor", time.Now(), nil, err)))
+				assert.NotContains(s.T(), s.stderr.String(), "ax doctor")
+			},
+		},
+		{
+			Name: "success data printed in both formats",
+			PreRequisites: func() {
+				s.stdout, s.stderr = &bytes.Buffer{}, &bytes.Buffer{}
+			},
+			Assert: func() {
+				r := NewResult("archive", time.Now(), Lines{"src/main.go", "src/go.mod"}, nil)
+
+				err := Print(s.stdout, s.stderr, FormatText, r)
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), "src/main.go\nsrc/go.mod\n", s.stdout.String())
+
+				s.stdout.Reset()
+
+				err = Print(s.stdout, s.stderr, FormatJSON, r)
+				assert.Nil(s.T(), err)
+				assert.Contains(s.T(), s.stdout.String(), `"data":["src/main.go","src/go.mod"]`)
+				assert.Equal(s.T(), 0, s.stderr.Len())
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
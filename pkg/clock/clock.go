@@ -0,0 +1,129 @@
+// Package clock provides the time source of the long running modes of ax, so that they can be tested with a Fake one,
+// which moves only when it's advanced.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock - source of the current time, and of the timers waiting for it.
+type Clock interface {
+	// Now - returns the current time.
+	Now() time.Time
+
+	// After - returns a channel receiving the current time, once the duration has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real - returns the Clock of the system, backed by the time package.
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Fake - Clock which moves only when it's advanced, firing the timers which are due by then.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []waiter
+}
+
+// waiter - timer of the Fake clock, fired once the clock reaches its time.
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFake - returns the Fake clock, starting at the time.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+
+	return f
+}
+
+// Now - returns the current time of the clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// After - returns a channel receiving the time of the clock, once it has been advanced by the duration.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+
+	if d <= 0 {
+		ch <- f.now
+
+		return ch
+	}
+
+	f.waiters = append(f.waiters, waiter{at: f.now.Add(d), ch: ch})
+	f.cond.Broadcast()
+
+	return ch
+}
+
+// Advance - moves the clock forward by the duration, firing the timers which are due by then, in order.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+
+	sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+
+	pending := f.waiters[:0]
+
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			pending = append(pending, w)
+
+			continue
+		}
+
+		w.ch <- f.now
+	}
+
+	f.waiters = pending
+	f.cond.Broadcast()
+}
+
+// Set - moves the clock forward to the time, just as Advance does. It's never moved backwards.
+func (f *Fake) Set(t time.Time) {
+	d := t.Sub(f.Now())
+	if d > 0 {
+		f.Advance(d)
+	}
+}
+
+// Waiters - returns the number of timers which haven't fired yet.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.waiters)
+}
+
+// BlockUntil - blocks until at least n timers are waiting on the clock, so that a test advances it only once the code
+// under the test has started waiting.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
@@ -0,0 +1,74 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type Suite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	t.Parallel()
+
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) TestUnitFake() {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []ax.TestCase{
+		{
+			Name: "success timers fire once the clock reaches them, in order",
+			Assert: func() {
+				f := NewFake(start)
+
+				late, early := f.After(2*time.Minute), f.After(time.Minute)
+				assert.Equal(s.T(), 2, f.Waiters())
+
+				f.Advance(30 * time.Second)
+				assert.Len(s.T(), early, 0)
+
+				f.Advance(30 * time.Second)
+				assert.Equal(s.T(), start.Add(time.Minute), <-early)
+				assert.Len(s.T(), late, 0)
+
+				f.Set(start.Add(time.Hour))
+				assert.Equal(s.T(), start.Add(time.Hour), <-late)
+				assert.Equal(s.T(), 0, f.Waiters())
+				assert.Equal(s.T(), start.Add(time.Hour), f.Now())
+			},
+		},
+		{
+			Name: "success past timer fires right away, and the clock never moves backwards",
+			Assert: func() {
+				f := NewFake(start)
+
+				assert.Equal(s.T(), start, <-f.After(0))
+
+				f.Set(start.Add(-time.Hour))
+				assert.Equal(s.T(), start, f.Now())
+			},
+		},
+		{
+			Name: "success block until the timer is waiting",
+			Assert: func() {
+				f := NewFake(start)
+				fired := make(chan time.Time)
+
+				go func() { fired <- <-f.After(time.Second) }()
+
+				f.BlockUntil(1)
+				f.Advance(time.Second)
+				assert.Equal(s.T(), start.Add(time.Second), <-fired)
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
@@ -0,0 +1,335 @@
+// Package daemon runs backup jobs on their cron schedules, with a limit on how many of them run at once and a random
+// delay spreading their starts, recording the History of their runs and reporting the Status over a Unix socket.
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/clock"
+)
+
+var (
+	// ErrNoJobs - daemon has been configured without a single job.
+	ErrNoJobs = errors.New("no scheduled jobs")
+
+	// ErrInvalidJob - job is missing its name, schedule or run func, or its name isn't unique.
+	ErrInvalidJob = errors.New("invalid job")
+)
+
+// Job - backup run by the Daemon on its Schedule.
+type Job struct {
+	Name     string
+	Schedule *Schedule
+
+	// Run - runs the backup, which should stop, cleaning up after itself, once the context is done.
+	Run func(ctx context.Context) error
+}
+
+// Config - represents the settings of the Daemon.
+type Config struct {
+	Jobs []Job
+
+	// Concurrency - maximum number of jobs run at once, 1 (the default) running them sequentially. Jobs due while
+	// all slots are taken are queued, in order.
+	Concurrency int
+
+	// Jitter - each run starts after a random delay, up to the Jitter, so that jobs scheduled for the same time
+	// (on one or many hosts) don't all start at once. It should be well below the interval of the schedules.
+	Jitter time.Duration
+
+	// History - where runs are recorded, kept only in memory if it's nil.
+	History *History
+
+	// Clock - time source, clock.Real if it's nil.
+	Clock clock.Clock
+
+	// Random - returns the jitter of a run, within [0, n), chosen uniformly by default.
+	Random func(n time.Duration) time.Duration
+
+	// Logger - if set, progress is reported to it instead of the ax.DefaultLogger.
+	Logger ax.Logger
+}
+
+// Daemon - runs jobs on their schedules, until its context is done.
+type Daemon struct {
+	conf    Config
+	clock   clock.Clock
+	log     ax.Logger
+	history *History
+
+	mu      sync.Mutex
+	started time.Time
+	jobs    []*jobState
+	queue   []*jobState
+	running int
+
+	// finished - signaled whenever a run finishes, so that a queued job takes its slot.
+	finished chan struct{}
+	wg       sync.WaitGroup
+}
+
+// jobState - represents the job within the Daemon, along with its next run.
+type jobState struct {
+	job Job
+
+	// planned & next - time the next run is scheduled for, and the time it starts, after the jitter.
+	planned, next time.Time
+
+	// due - time the queued (or running) run has been scheduled for.
+	due time.Time
+
+	running, queued bool
+}
+
+// New - returns the Daemon running the jobs, once it's started with Run.
+func New(conf Config) (*Daemon, error) {
+	if len(conf.Jobs) == 0 {
+		return nil, ErrNoJobs
+	}
+
+	d := &Daemon{
+		conf:     conf,
+		clock:    conf.Clock,
+		log:      conf.Logger,
+		history:  conf.History,
+		jobs:     make([]*jobState, 0, len(conf.Jobs)),
+		queue:    make([]*jobState, 0),
+		finished: make(chan struct{}, 1),
+	}
+
+	names := make(map[string]bool, len(conf.Jobs))
+
+	for _, job := range conf.Jobs {
+		if job.Name == "" || job.Schedule == nil || job.Run == nil || names[job.Name] {
+			return nil, fmt.Errorf("%w: %q needs a unique name, a schedule and a run func", ErrInvalidJob, job.Name)
+		}
+
+		names[job.Name] = true
+		d.jobs = append(d.jobs, &jobState{job: job})
+	}
+
+	if d.conf.Concurrency < 1 {
+		d.conf.Concurrency = 1
+	}
+
+	if d.conf.Random == nil {
+		d.conf.Random = uniformJitter
+	}
+
+	if d.clock == nil {
+		d.clock = clock.Real()
+	}
+
+	if d.log == nil {
+		d.log = ax.DefaultLogger()
+	}
+
+	if d.history == nil {
+		d.history, _ = OpenHistory("", DefaultHistorySize)
+	}
+
+	return d, nil
+}
+
+// Run - runs the jobs on their schedules, until the context is done. Running jobs are canceled then, and Run returns
+// once they've finished cleaning up.
+func (d *Daemon) Run(ctx context.Context) error {
+	d.mu.Lock()
+	d.started = d.clock.Now()
+
+	for _, js := range d.jobs {
+		d.schedule(js, d.started)
+	}
+
+	d.mu.Unlock()
+
+	d.log.Info("Daemon started", "jobs", len(d.jobs), "concurrency", d.conf.Concurrency, "jitter", d.conf.Jitter)
+
+	var (
+		timer   <-chan time.Time
+		timerAt time.Time
+	)
+
+	for {
+		next := d.dispatch(ctx)
+
+		// Timer is replaced only if the next run is due earlier, so that a Fake clock isn't left with stale ones.
+		if timer == nil || next.Before(timerAt) {
+			timer, timerAt = d.clock.After(next.Sub(d.clock.Now())), next
+		}
+
+		select {
+		case <-ctx.Done():
+			d.wg.Wait()
+			d.log.Info("Daemon stopped")
+
+			return nil
+		case <-timer:
+			timer = nil
+		case <-d.finished:
+		}
+	}
+}
+
+// dispatch - queues the jobs which are due, starts queued ones while there are free slots, and returns the time the
+// next run is due.
+func (d *Daemon) dispatch(ctx context.Context) time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.clock.Now()
+
+	for _, js := range d.jobs {
+		if js.next.IsZero() || js.next.After(now) {
+			continue
+		}
+
+		if js.running || js.queued {
+			d.log.Warn("previous run of the job hasn't finished yet, skipping", "job", js.job.Name)
+			d.record(Record{Job: js.job.Name, Scheduled: js.planned, Started: now, Finished: now, Status: StatusSkipped,
+				Error: "previous run hasn't finished yet"})
+		} else {
+			js.queued, js.due = true, js.planned
+			d.queue = append(d.queue, js)
+		}
+
+		d.schedule(js, now)
+	}
+
+	for len(d.queue) != 0 && d.running < d.conf.Concurrency {
+		js := d.queue[0]
+		d.queue = d.queue[1:]
+
+		d.start(ctx, js)
+	}
+
+	var next time.Time
+
+	for _, js := range d.jobs {
+		if !js.next.IsZero() && (next.IsZero() || js.next.Before(next)) {
+			next = js.next
+		}
+	}
+
+	if next.IsZero() {
+		// None of the schedules matches anymore, so the daemon only waits for the running jobs and its context.
+		next = now.AddDate(scheduleHorizon, 0, 0)
+	}
+
+	return next
+}
+
+// schedule - plans the next run of the job, following its previous one, or the time if that has passed already.
+func (d *Daemon) schedule(js *jobState, now time.Time) {
+	var planned time.Time
+	if !js.planned.IsZero() {
+		planned = js.job.Schedule.Next(js.planned)
+	}
+
+	if !planned.After(now) {
+		planned = js.job.Schedule.Next(now)
+	}
+
+	js.planned, js.next = planned, planned
+
+	if !planned.IsZero() && d.conf.Jitter > 0 {
+		js.next = planned.Add(d.conf.Random(d.conf.Jitter))
+	}
+}
+
+// start - runs the job in its own goroutine, recording the run once it finishes.
+func (d *Daemon) start(ctx context.Context, js *jobState) {
+	js.queued, js.running = false, true
+	d.running++
+	d.wg.Add(1)
+
+	r := Record{Job: js.job.Name, Scheduled: js.due}
+
+	go func() {
+		defer d.wg.Done()
+
+		r.Started = d.clock.Now()
+		d.log.Info("Starting scheduled backup", "job", js.job.Name)
+
+		err := js.job.Run(ctx)
+
+		r.Finished, r.Status = d.clock.Now(), StatusOK
+
+		switch {
+		case err != nil && ctx.Err() != nil:
+			r.Status, r.Error = StatusCanceled, err.Error()
+		case err != nil:
+			r.Status, r.Error = StatusFailed, err.Error()
+			d.log.Error("scheduled backup failed", "job", js.job.Name, "err", err)
+		default:
+			d.log.Info("Finished scheduled backup", "job", js.job.Name, "duration", r.Duration())
+		}
+
+		d.mu.Lock()
+		js.running = false
+		d.running--
+		d.record(r)
+		d.mu.Unlock()
+
+		select {
+		case d.finished <- struct{}{}:
+		default:
+		}
+	}()
+}
+
+// record - adds the run to the history, reporting it if it can't be saved, as that mustn't stop the daemon.
+func (d *Daemon) record(r Record) {
+	err := d.history.Add(r)
+	if err != nil {
+		d.log.Warn("failed recording the run", "job", r.Job, "err", err)
+	}
+}
+
+// Status - returns the current state of the daemon, its jobs and their recent runs.
+func (d *Daemon) Status() *Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s := &Status{
+		Started:     d.started,
+		Now:         d.clock.Now(),
+		Concurrency: d.conf.Concurrency,
+		Running:     d.running,
+		Jobs:        make([]JobStatus, 0, len(d.jobs)),
+		History:     d.history.Records(),
+	}
+
+	for _, js := range d.jobs {
+		s.Jobs = append(s.Jobs, JobStatus{
+			Name:     js.job.Name,
+			Schedule: js.job.Schedule.String(),
+			Next:     js.next,
+			Running:  js.running,
+			Queued:   js.queued,
+			Last:     d.history.Last(js.job.Name),
+		})
+	}
+
+	sort.Slice(s.Jobs, func(i, j int) bool { return s.Jobs[i].Name < s.Jobs[j].Name })
+
+	return s
+}
+
+// uniformJitter - returns a random duration within [0, n).
+func uniformJitter(n time.Duration) time.Duration {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(v.Int64())
+}
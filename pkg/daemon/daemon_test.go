@@ -0,0 +1,266 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingJob - job which reports its start, and runs until it's released or canceled.
+type blockingJob struct {
+	started chan string
+	release chan error
+}
+
+func newBlockingJob() *blockingJob {
+	return &blockingJob{started: make(chan string, 10), release: make(chan error)}
+}
+
+func (b *blockingJob) job(name, expr string) Job {
+	sched, err := ParseSchedule(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return Job{Name: name, Schedule: sched, Run: func(ctx context.Context) error {
+		b.started <- name
+
+		select {
+		case err := <-b.release:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}}
+}
+
+func (s *Suite) TestUnitDaemon() {
+	var (
+		fake   *clock.Fake
+		jobs   *blockingJob
+		ctx    context.Context
+		cancel context.CancelFunc
+		done   chan error
+		start  = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	)
+
+	prepare := func() {
+		fake, jobs = clock.NewFake(start), newBlockingJob()
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan error, 1)
+	}
+
+	run := func(conf Config) *Daemon {
+		conf.Clock, conf.Logger = fake, nopLogger{}
+
+		d, err := New(conf)
+		s.Require().Nil(err)
+
+		go func() { done <- d.Run(ctx) }()
+
+		fake.BlockUntil(1)
+
+		return d
+	}
+
+	stop := func() {
+		cancel()
+		s.Require().Nil(<-done)
+	}
+
+	eventually := func(cond func() bool) {
+		s.Eventually(cond, 5*time.Second, time.Millisecond)
+	}
+
+	testCases := []ax.TestCase{
+		{
+			Name:          "success due jobs are queued, and run sequentially",
+			PreRequisites: prepare,
+			Assert: func() {
+				d := run(Config{Jobs: []Job{jobs.job("a", "@hourly"), jobs.job("b", "0 * * * *")}})
+
+				fake.Set(start.Add(time.Hour))
+				assert.Equal(s.T(), "a", <-jobs.started)
+
+				status := d.Status()
+				assert.Equal(s.T(), 1, status.Running)
+				assert.True(s.T(), status.Jobs[0].Running)
+				assert.True(s.T(), status.Jobs[1].Queued)
+				assert.Equal(s.T(), start.Add(2*time.Hour), status.Jobs[1].Next)
+
+				jobs.release <- nil
+				assert.Equal(s.T(), "b", <-jobs.started)
+				jobs.release <- errors.New("remote is down")
+
+				eventually(func() bool { return len(d.Status().History) == 2 })
+
+				history := d.Status().History
+				assert.Equal(s.T(), StatusOK, history[0].Status)
+				assert.Equal(s.T(), start.Add(time.Hour), history[0].Scheduled)
+				assert.Equal(s.T(), StatusFailed, history[1].Status)
+				assert.Equal(s.T(), "remote is down", history[1].Error)
+				assert.Equal(s.T(), StatusFailed, d.Status().Jobs[1].Last.Status)
+
+				stop()
+			},
+		},
+		{
+			Name:          "success jobs run at once up to the concurrency limit",
+			PreRequisites: prepare,
+			Assert: func() {
+				d := run(Config{
+					Jobs:        []Job{jobs.job("a", "@hourly"), jobs.job("b", "@hourly"), jobs.job("c", "@hourly")},
+					Concurrency: 2,
+				})
+
+				fake.Set(start.Add(time.Hour))
+				<-jobs.started
+				<-jobs.started
+
+				assert.Equal(s.T(), 2, d.Status().Running)
+
+				jobs.release <- nil
+				<-jobs.started
+
+				eventually(func() bool { return d.Status().Running == 2 })
+
+				stop()
+			},
+		},
+		{
+			Name:          "success runs start after their jitter",
+			PreRequisites: prepare,
+			Assert: func() {
+				d := run(Config{
+					Jobs:   []Job{jobs.job("a", "@hourly")},
+					Jitter: time.Minute,
+					Random: func(n time.Duration) time.Duration { return n / 2 },
+				})
+
+				assert.Equal(s.T(), start.Add(time.Hour+30*time.Second), d.Status().Jobs[0].Next)
+
+				fake.Set(start.Add(time.Hour))
+				fake.BlockUntil(1)
+				assert.False(s.T(), d.Status().Jobs[0].Running)
+
+				fake.Advance(30 * time.Second)
+				assert.Equal(s.T(), "a", <-jobs.started)
+
+				// Next run follows the schedule, rather than the jittered start.
+				assert.Equal(s.T(), start.Add(2*time.Hour+30*time.Second), d.Status().Jobs[0].Next)
+
+				stop()
+			},
+		},
+		{
+			Name:          "success run is skipped while the previous one hasn't finished",
+			PreRequisites: prepare,
+			Assert: func() {
+				d := run(Config{Jobs: []Job{jobs.job("a", "@hourly")}})
+
+				fake.Set(start.Add(time.Hour))
+				<-jobs.started
+
+				fake.BlockUntil(1)
+				fake.Set(start.Add(2 * time.Hour))
+
+				eventually(func() bool { return len(d.Status().History) == 1 })
+				assert.Equal(s.T(), StatusSkipped, d.Status().History[0].Status)
+
+				jobs.release <- nil
+				eventually(func() bool { return len(d.Status().History) == 2 })
+
+				stop()
+			},
+		},
+		{
+			Name:          "success stopping the daemon cancels the running jobs, and waits for them",
+			PreRequisites: prepare,
+			Assert: func() {
+				d := run(Config{Jobs: []Job{jobs.job("a", "@hourly")}})
+
+				fake.Set(start.Add(time.Hour))
+				<-jobs.started
+
+				stop()
+
+				history := d.Status().History
+				s.Require().Len(history, 1)
+				assert.Equal(s.T(), StatusCanceled, history[0].Status)
+			},
+		},
+		{
+			Name:          "success history is saved, and status is served over the socket",
+			PreRequisites: prepare,
+			Assert: func() {
+				dir, err := os.MkdirTemp("", "ax-daemon")
+				s.Require().Nil(err)
+
+				defer os.RemoveAll(dir)
+
+				history, err := OpenHistory(filepath.Join(dir, "history.json"), 1)
+				s.Require().Nil(err)
+
+				d := run(Config{Jobs: []Job{jobs.job("a", "@hourly")}, History: history})
+
+				ln, err := Listen(ctx, filepath.Join(dir, socketName))
+				s.Require().Nil(err)
+
+				go func() { _ = d.Serve(ctx, ln) }()
+
+				_, err = Listen(ctx, filepath.Join(dir, socketName))
+				assert.ErrorIs(s.T(), err, ErrDaemonRunning)
+
+				for i := 1; i <= 2; i++ {
+					fake.BlockUntil(1)
+					fake.Set(start.Add(time.Duration(i) * time.Hour))
+					<-jobs.started
+					jobs.release <- nil
+
+					eventually(func() bool { return d.Status().Running == 0 })
+				}
+
+				status, err := QueryStatus(ctx, filepath.Join(dir, socketName))
+				s.Require().Nil(err)
+				assert.Equal(s.T(), "a", status.Jobs[0].Name)
+				assert.Equal(s.T(), "@hourly", status.Jobs[0].Schedule)
+				s.Require().Len(status.History, 1)
+				assert.Equal(s.T(), start.Add(2*time.Hour), status.History[0].Scheduled)
+
+				stop()
+
+				reopened, err := OpenHistory(filepath.Join(dir, "history.json"), 10)
+				s.Require().Nil(err)
+				assert.Equal(s.T(), status.History, reopened.Records())
+			},
+		},
+		{
+			Name: "err jobs are required, with unique names",
+			Assert: func() {
+				_, err := New(Config{})
+				assert.ErrorIs(s.T(), err, ErrNoJobs)
+
+				jobs = newBlockingJob()
+
+				_, err = New(Config{Jobs: []Job{jobs.job("a", "@daily"), jobs.job("a", "@hourly")}})
+				assert.ErrorIs(s.T(), err, ErrInvalidJob)
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
+
+// nopLogger - discards the progress of the daemon.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
@@ -0,0 +1,166 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHistorySize - number of runs kept in the History, unless chosen otherwise.
+	DefaultHistorySize = 100
+)
+
+// Statuses of the recorded runs.
+const (
+	StatusOK       = "ok"
+	StatusFailed   = "failed"
+	StatusSkipped  = "skipped"
+	StatusCanceled = "canceled"
+)
+
+// Record - represents a single run of a job.
+type Record struct {
+	Job string `json:"job"`
+
+	// Scheduled - time the run has been scheduled for, before the jitter.
+	Scheduled time.Time `json:"scheduled"`
+
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+
+	// Status - one of StatusOK, StatusFailed, StatusSkipped (the previous run of the job hasn't finished yet) and
+	// StatusCanceled (the daemon has been stopped while it was running).
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Duration - returns how long the run took.
+func (r *Record) Duration() time.Duration {
+	return r.Finished.Sub(r.Started)
+}
+
+// History - the most recent runs, oldest first, which are saved into a JSON file after each one, so they're kept
+// across restarts of the daemon.
+type History struct {
+	mu      sync.Mutex
+	path    string
+	size    int
+	records []Record
+}
+
+// OpenHistory - loads the history saved at the path, keeping up to size of the most recent runs. If the path is empty,
+// the history is kept only in memory.
+func OpenHistory(path string, size int) (*History, error) {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+
+	h := &History{path: path, size: size, records: make([]Record, 0)}
+	if path == "" {
+		return h, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed reading history: %w", err)
+	}
+
+	err = json.Unmarshal(content, &h.records)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing history [%s]: %w", path, err)
+	}
+
+	h.trim()
+
+	return h, nil
+}
+
+// Add - records the run, dropping the oldest one if the history is full, and saves the history.
+func (h *History) Add(r Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records = append(h.records, r)
+	h.trim()
+
+	return h.save()
+}
+
+// Records - returns a copy of the recorded runs, oldest first.
+func (h *History) Records() []Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append(make([]Record, 0, len(h.records)), h.records...)
+}
+
+// Last - returns the most recent run of the job, or nil if it hasn't been run yet.
+func (h *History) Last(job string) *Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := len(h.records) - 1; i >= 0; i-- {
+		if h.records[i].Job == job {
+			r := h.records[i]
+
+			return &r
+		}
+	}
+
+	return nil
+}
+
+func (h *History) trim() {
+	if len(h.records) > h.size {
+		h.records = append(h.records[:0], h.records[len(h.records)-h.size:]...)
+	}
+}
+
+// save - writes the history into a temporary file next to its path, which replaces it once it's complete.
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(h.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed encoding history: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(h.path), 0o700)
+	if err != nil {
+		return fmt.Errorf("failed creating history dir: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*.part")
+	if err != nil {
+		return fmt.Errorf("failed saving history: %w", err)
+	}
+
+	// Temporary file is accessible only by the current user, just as the history.
+	_, err = f.Write(content)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), h.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("failed saving history: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	macroEvery = "@every "
+
+	// scheduleHorizon - how far ahead Schedule.Next looks for a matching time, so that an expression which never
+	// matches (i.e. '0 0 30 2 *') doesn't loop forever.
+	scheduleHorizon = 5
+)
+
+// ErrInvalidSchedule - cron expression can't be parsed.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Schedule - parsed cron expression, which is either one of the macros (@hourly, @daily, @weekly, @monthly,
+// @yearly, or '@every <duration>'), or five space separated fields: minute, hour, day of month, month and day of week.
+//
+// Each field is '*', a value, a range ('1-5'), or a list of them ('1,3-5'), optionally stepped ('*/15', '0-30/10').
+// Months and days of week may be given by their names ('jan', 'mon'), and Sunday is either 0 or 7. Just as in cron,
+// if both day of month and day of week are restricted, a day matching either of them matches.
+type Schedule struct {
+	expr string
+
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+
+	every time.Duration
+}
+
+// scheduleField - range and names of the values of a single field.
+type scheduleField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+func scheduleFields() []scheduleField {
+	return []scheduleField{
+		{name: "minute", min: 0, max: 59},
+		{name: "hour", min: 0, max: 23},
+		{name: "day of month", min: 1, max: 31},
+		{name: "month", min: 1, max: 12, names: []string{
+			"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec",
+		}},
+		{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+	}
+}
+
+func scheduleMacros() map[string]string {
+	return map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+}
+
+// ParseSchedule - parses the cron expression.
+func ParseSchedule(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	s := &Schedule{expr: expr}
+
+	if strings.HasPrefix(expr, macroEvery) {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, macroEvery)))
+		if err != nil || every < time.Second {
+			return nil, fmt.Errorf("%w: %q: '@every' needs a duration of at least 1s, i.e. '@every 6h'",
+				ErrInvalidSchedule, expr)
+		}
+
+		s.every = every
+
+		return s, nil
+	}
+
+	if macro, ok := scheduleMacros()[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(scheduleFields()) {
+		return nil, fmt.Errorf("%w: %q: expected 5 fields (minute hour day-of-month month day-of-week), or a macro "+
+			"such as @daily", ErrInvalidSchedule, s.expr)
+	}
+
+	bits := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+
+	for i, field := range scheduleFields() {
+		var err error
+
+		*bits[i], err = field.parse(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %s: %v", ErrInvalidSchedule, s.expr, field.name, err)
+		}
+	}
+
+	// Sunday is matched by 0, whether it has been given as 0 or 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	s.domAny, s.dowAny = parts[2] == "*", parts[4] == "*"
+
+	return s, nil
+}
+
+// parse - returns the bitset of the values the field matches.
+func (f scheduleField) parse(field string) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			var err error
+
+			rangePart = item[:i]
+
+			step, err = strconv.Atoi(item[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", item)
+			}
+		}
+
+		lo, hi, err := f.parseRange(rangePart)
+		if err != nil {
+			return 0, err
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// parseRange - returns the bounds of '*', a single value or a range of them.
+func (f scheduleField) parseRange(r string) (int, int, error) {
+	if r == "*" {
+		return f.min, f.max, nil
+	}
+
+	bounds := strings.SplitN(r, "-", 2)
+
+	lo, err := f.parseValue(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	hi := lo
+
+	if len(bounds) == 2 {
+		hi, err = f.parseValue(bounds[1])
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if hi < lo {
+		return 0, 0, fmt.Errorf("range %q is reversed", r)
+	}
+
+	return lo, hi, nil
+}
+
+// parseValue - parses a single value, given either by its number or by its name.
+func (f scheduleField) parseValue(v string) (int, error) {
+	for i, name := range f.names {
+		if name != "" && strings.EqualFold(v, name) {
+			return i, nil
+		}
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("%q isn't within %d-%d", v, f.min, f.max)
+	}
+
+	return n, nil
+}
+
+// Next - returns the first time the schedule matches after the time, in its location. Zero time is returned if it
+// doesn't match within the next 5 years.
+func (s *Schedule) Next(after time.Time) time.Time {
+	if s.every != 0 {
+		return after.Add(s.every)
+	}
+
+	loc := after.Location()
+	t := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.AddDate(scheduleHorizon, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !has(s.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// matchesDay - reports whether the day matches both day of month and day of week, or either of them, if both are
+// restricted.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+
+	return dom || dow
+}
+
+// String - returns the expression the schedule has been parsed from.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
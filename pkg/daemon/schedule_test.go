@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type Suite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	t.Parallel()
+
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) TestUnitSchedule() {
+	// Monday.
+	start := time.Date(2021, 3, 1, 12, 30, 45, 0, time.UTC)
+
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2021, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := []ax.TestCase{
+		{
+			Name: "success next matching time",
+			Assert: func() {
+				cases := []struct {
+					expr     string
+					expected time.Time
+				}{
+					{"* * * * *", at(3, 1, 12, 31)},
+					{"*/15 * * * *", at(3, 1, 12, 45)},
+					{"0 3 * * *", at(3, 2, 3, 0)},
+					{"@daily", at(3, 2, 0, 0)},
+					{"@hourly", at(3, 1, 13, 0)},
+					{"30 2 * * sat,sun", at(3, 6, 2, 30)},
+					{"0 0 1 * *", at(4, 1, 0, 0)},
+					{"0 9-17/4 * * 1-5", at(3, 1, 13, 0)},
+					{"0 0 * jun *", at(6, 1, 0, 0)},
+					{"0 0 * * 7", at(3, 7, 0, 0)},
+					// Either day of month or day of week matches, if both are restricted.
+					{"0 0 13 * 5", at(3, 5, 0, 0)},
+					{"@every 90m", start.Add(90 * time.Minute)},
+				}
+
+				for _, c := range cases {
+					sched, err := ParseSchedule(c.expr)
+					s.Require().Nil(err, c.expr)
+					assert.Equal(s.T(), c.expected, sched.Next(start), c.expr)
+					assert.Equal(s.T(), c.expr, sched.String())
+				}
+			},
+		},
+		{
+			Name: "success schedule which never matches",
+			Assert: func() {
+				sched, err := ParseSchedule("0 0 30 2 *")
+				s.Require().Nil(err)
+				assert.True(s.T(), sched.Next(start).IsZero())
+			},
+		},
+		{
+			Name: "err invalid expressions",
+			Assert: func() {
+				for _, expr := range []string{
+					"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+					"*/0 * * * *", "5-1 * * * *", "* * * foo *", "@every", "@every 10ms", "@fortnightly",
+				} {
+					_, err := ParseSchedule(expr)
+					assert.ErrorIs(s.T(), err, ErrInvalidSchedule, expr)
+				}
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
@@ -0,0 +1,201 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	socketName = "daemon.sock"
+	socketPerm = 0o600
+	socketDir  = "ax"
+
+	// statusTimeout - how long writing or reading the status over the socket may take.
+	statusTimeout = 5 * time.Second
+
+	timeFormat = "2006-01-02 15:04:05 MST"
+)
+
+// ErrDaemonRunning - another daemon is already listening on the socket.
+var ErrDaemonRunning = errors.New("daemon is already running")
+
+// Status - represents the state of the Daemon, its jobs and their recent runs.
+type Status struct {
+	Started     time.Time `json:"started"`
+	Now         time.Time `json:"now"`
+	Concurrency int       `json:"concurrency"`
+	Running     int       `json:"running"`
+
+	Jobs []JobStatus `json:"jobs"`
+
+	// History - recent runs, oldest first.
+	History []Record `json:"history"`
+}
+
+// JobStatus - represents a single job of the Daemon.
+type JobStatus struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+
+	// Next - time the next run starts, including its jitter. Zero if the schedule doesn't match anymore.
+	Next time.Time `json:"next"`
+
+	Running bool `json:"running"`
+	Queued  bool `json:"queued"`
+
+	// Last - the most recent run of the job, if there is one.
+	Last *Record `json:"last,omitempty"`
+}
+
+// WriteText - writes the status as a table of the jobs.
+func (s *Status) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Daemon running since %s, %d of %d job(s) running at most at once.\n\n",
+		s.Started.Format(timeFormat), s.Running, s.Concurrency)
+	if err != nil {
+		return fmt.Errorf("failed writing status: %w", err)
+	}
+
+	for _, j := range s.Jobs {
+		state, last, next := "idle", "never run", "never"
+
+		switch {
+		case j.Running:
+			state = "running"
+		case j.Queued:
+			state = "queued"
+		}
+
+		if j.Last != nil {
+			last = fmt.Sprintf("%s at %s", j.Last.Status, j.Last.Started.Format(timeFormat))
+			if j.Last.Error != "" {
+				last += ": " + j.Last.Error
+			}
+		}
+
+		if !j.Next.IsZero() {
+			next = j.Next.Format(timeFormat)
+		}
+
+		_, err = fmt.Fprintf(w, "%s [%s] %s\n  next: %s\n  last: %s\n", j.Name, j.Schedule, state, next, last)
+		if err != nil {
+			return fmt.Errorf("failed writing status: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DefaultSocketPath - returns the socket the daemon listens on by default: 'ax/daemon.sock' in $XDG_RUNTIME_DIR, or
+// else in a directory of the current user under the system temp dir.
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, socketDir, socketName)
+	}
+
+	return filepath.Join(os.TempDir(), socketDir+"-"+strconv.Itoa(os.Getuid()), socketName)
+}
+
+// Listen - listens on the Unix socket at the path, which is accessible only by the current user. A socket left behind
+// by a daemon which hasn't stopped cleanly is replaced, while ErrDaemonRunning is returned if one is listening on it.
+func Listen(ctx context.Context, path string) (net.Listener, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating socket dir: %w", err)
+	}
+
+	if _, err = os.Lstat(path); err == nil {
+		conn, dialErr := dial(ctx, path)
+		if dialErr == nil {
+			_ = conn.Close()
+
+			return nil, fmt.Errorf("%w: %s is in use", ErrDaemonRunning, path)
+		}
+
+		_ = os.Remove(path)
+	}
+
+	var lc net.ListenConfig
+
+	ln, err := lc.Listen(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed listening on %s: %w", path, err)
+	}
+
+	err = os.Chmod(path, socketPerm)
+	if err != nil {
+		_ = ln.Close()
+
+		return nil, fmt.Errorf("failed restricting access to %s: %w", path, err)
+	}
+
+	return ln, nil
+}
+
+// Serve - writes the status of the daemon, as JSON, to each connection accepted on the listener, until the context is
+// done. The listener is closed then.
+func (d *Daemon) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("failed accepting status connection: %w", err)
+		}
+
+		_ = conn.SetWriteDeadline(time.Now().Add(statusTimeout))
+
+		err = json.NewEncoder(conn).Encode(d.Status())
+		if err != nil {
+			d.log.Warn("failed writing status", "err", err)
+		}
+
+		_ = conn.Close()
+	}
+}
+
+// QueryStatus - returns the status of the daemon listening on the socket at the path.
+func QueryStatus(ctx context.Context, path string) (*Status, error) {
+	conn, err := dial(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed connecting to the daemon (is it running?): %w", err)
+	}
+
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(statusTimeout))
+
+	var s Status
+
+	err = json.NewDecoder(conn).Decode(&s)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading status: %w", err)
+	}
+
+	return &s, nil
+}
+
+func dial(ctx context.Context, path string) (net.Conn, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed dialing %s: %w", path, err)
+	}
+
+	return conn, nil
+}
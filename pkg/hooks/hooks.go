@@ -0,0 +1,274 @@
+// Package hooks reports the outcome of a run to whatever watches the backups: a command receiving the result on its
+// stdin, a webhook the result is POSTed to, and a status file it's written into. Each of them can be limited to some
+// of the outcomes, i.e. a webhook alerting only on failures.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/kaynetik/ax"
+)
+
+// Outcomes of a run, which the hooks can be limited to.
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+
+	// OutcomeWarning - run has succeeded, but with warnings, i.e. there have been no changes since the previous one.
+	OutcomeWarning Outcome = "warning"
+)
+
+const (
+	// DefaultTimeout - how long each hook may take, before it's canceled.
+	DefaultTimeout = 30 * time.Second
+
+	// EnvOutcome - environment variable holding the outcome, set for the hook command.
+	EnvOutcome = "AX_HOOK_OUTCOME"
+
+	statusFilePerm = 0o600
+	statusDirPerm  = 0o700
+	contentType    = "application/json"
+)
+
+var (
+	// ErrUnknownOutcome - outcome isn't one of OutcomeSuccess, OutcomeFailure or OutcomeWarning.
+	ErrUnknownOutcome = errors.New("unknown outcome")
+
+	// ErrWebhook - webhook has responded with a non-2xx status.
+	ErrWebhook = errors.New("webhook failed")
+
+	// ErrHooks - some of the hooks have failed.
+	ErrHooks = errors.New("hooks failed")
+
+	// errInvalidWebhook - reported in place of the error parsing the webhook URL, which would hold the URL.
+	errInvalidWebhook = errors.New("invalid webhook URL")
+)
+
+// Outcome - represents how a run has ended.
+type Outcome string
+
+// ParseOutcomes - parses the outcomes, each of which may be a comma separated list, i.e. 'failure,warning'.
+func ParseOutcomes(values []string) ([]Outcome, error) {
+	var outcomes []Outcome
+
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			o := Outcome(strings.TrimSpace(name))
+
+			switch o {
+			case "":
+				continue
+			case OutcomeSuccess, OutcomeFailure, OutcomeWarning:
+				outcomes = append(outcomes, o)
+			default:
+				return nil, fmt.Errorf("%w: %q (supported: %s, %s, %s)", ErrUnknownOutcome, o, OutcomeSuccess,
+					OutcomeFailure, OutcomeWarning)
+			}
+		}
+	}
+
+	return outcomes, nil
+}
+
+// Config - represents the hooks run after each run. Hooks which aren't set are skipped, and each one runs on every
+// outcome, unless it's limited to some of them.
+type Config struct {
+	// Command - shell command, receiving the JSON payload on its stdin and the outcome in AX_HOOK_OUTCOME.
+	Command   string
+	CommandOn []Outcome
+
+	// Webhook - URL the JSON payload is POSTed to.
+	Webhook   string
+	WebhookOn []Outcome
+
+	// StatusFile - path of the file the JSON payload is written into, replacing the previous one.
+	StatusFile   string
+	StatusFileOn []Outcome
+
+	// Timeout - how long each hook may take (DefaultTimeout if zero).
+	Timeout time.Duration
+
+	// Client - HTTP client the webhook is called with, http.DefaultClient if it's nil.
+	Client *http.Client
+
+	// Logger - if set, progress is reported to it instead of the ax.DefaultLogger.
+	Logger ax.Logger
+}
+
+// Enabled - reports whether any of the hooks is set.
+func (c *Config) Enabled() bool {
+	return c.Command != "" || c.Webhook != "" || c.StatusFile != ""
+}
+
+// Run - runs the hooks set for the outcome, passing them the payload encoded as JSON. A failing hook doesn't stop the
+// others, the errors of all of them are returned together.
+func Run(ctx context.Context, conf *Config, outcome Outcome, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed encoding hook payload: %w", err)
+	}
+
+	log := conf.Logger
+	if log == nil {
+		log = ax.DefaultLogger()
+	}
+
+	var errs []string
+
+	hooks := []struct {
+		name string
+		set  bool
+		on   []Outcome
+		run  func(ctx context.Context) error
+	}{
+		{"command", conf.Command != "", conf.CommandOn, func(ctx context.Context) error {
+			return runCommand(ctx, conf.Command, outcome, body)
+		}},
+		{"webhook", conf.Webhook != "", conf.WebhookOn, func(ctx context.Context) error {
+			return postWebhook(ctx, conf.Client, conf.Webhook, body)
+		}},
+		{"status file", conf.StatusFile != "", conf.StatusFileOn, func(context.Context) error {
+			return writeStatusFile(conf.StatusFile, body)
+		}},
+	}
+
+	for _, h := range hooks {
+		if !h.set || !matches(h.on, outcome) {
+			continue
+		}
+
+		log.Debug("Running hook", "hook", h.name, "outcome", outcome)
+
+		err = runWithTimeout(ctx, conf.Timeout, h.run)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", h.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %s", ErrHooks, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// matches - reports whether a hook limited to the outcomes runs on the outcome. Hooks which aren't limited run on any.
+func matches(on []Outcome, outcome Outcome) bool {
+	if len(on) == 0 {
+		return true
+	}
+
+	for _, o := range on {
+		if o == outcome {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runWithTimeout - runs the hook, canceling it once the timeout passes.
+func runWithTimeout(ctx context.Context, timeout time.Duration, run func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return run(ctx)
+}
+
+// runCommand - runs the command by the shell, with the payload on its stdin. Its output goes to stderr, so that it's
+// never mixed with the result of ax.
+func runCommand(ctx context.Context, command string, outcome Outcome, body []byte) error {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	//nolint:gosec // Hook command is chosen by the user, the same as the password commands.
+	cmd := exec.CommandContext(ctx, shell, flag, command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	cmd.Env = append(os.Environ(), EnvOutcome+"="+string(outcome))
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("failed running hook command: %w", err)
+	}
+
+	return nil
+}
+
+// postWebhook - POSTs the payload to the URL. The URL isn't part of the errors, as it may hold a token.
+func postWebhook(ctx context.Context, client *http.Client, webhook string, body []byte) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return errInvalidWebhook
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return fmt.Errorf("failed calling webhook: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %s", ErrWebhook, resp.Status)
+	}
+
+	return nil
+}
+
+// writeStatusFile - writes the payload into the file, through a temporary one renamed over it, so that whatever reads
+// the status never sees it half-written.
+func writeStatusFile(path string, body []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), statusDirPerm)
+	if err != nil {
+		return fmt.Errorf("failed creating status file dir: %w", err)
+	}
+
+	tmp := path + ".part"
+
+	err = os.WriteFile(tmp, append(body, '\n'), statusFilePerm)
+	if err != nil {
+		return fmt.Errorf("failed writing status file: %w", err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed writing status file: %w", err)
+	}
+
+	return nil
+}
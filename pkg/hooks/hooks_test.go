@@ -0,0 +1,166 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaynetik/ax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type Suite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	t.Parallel()
+
+	suite.Run(t, new(Suite))
+}
+
+// testPayload - payload of the runs reported by the tests.
+type testPayload struct {
+	Status   string   `json:"status"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func (s *Suite) TestUnitRun() {
+	var (
+		dir      string
+		server   *httptest.Server
+		received chan []byte
+		status   int
+		payload  = testPayload{Status: "ok", Warnings: []string{"no changes"}}
+	)
+
+	prepare := func() {
+		dir = s.T().TempDir()
+		received, status = make(chan []byte, 1), http.StatusNoContent
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(s.T(), contentType, r.Header.Get("Content-Type"))
+
+			received <- body
+
+			w.WriteHeader(status)
+		}))
+		s.T().Cleanup(server.Close)
+	}
+
+	// assertPayload - asserts that the JSON holds the payload.
+	assertPayload := func(raw []byte) {
+		var got testPayload
+
+		s.Require().Nil(json.Unmarshal(raw, &got))
+		assert.Equal(s.T(), payload, got)
+	}
+
+	testCases := []ax.TestCase{
+		{
+			Name:          "success command, webhook and status file receive the payload",
+			PreRequisites: prepare,
+			Assert: func() {
+				out := filepath.Join(dir, "stdin.json")
+				statusFile := filepath.Join(dir, "status", "last.json")
+
+				err := Run(context.Background(), &Config{
+					Command:    `cat > "` + out + `" && test "$` + EnvOutcome + `" = warning`,
+					Webhook:    server.URL,
+					StatusFile: statusFile,
+					Logger:     nopLogger{},
+				}, OutcomeWarning, payload)
+				s.Require().Nil(err)
+
+				raw, err := os.ReadFile(out)
+				s.Require().Nil(err)
+				assertPayload(raw)
+
+				assertPayload(<-received)
+
+				raw, err = os.ReadFile(statusFile)
+				s.Require().Nil(err)
+				assertPayload(raw)
+			},
+		},
+		{
+			Name:          "success hooks limited to other outcomes are skipped",
+			PreRequisites: prepare,
+			Assert: func() {
+				statusFile := filepath.Join(dir, "last.json")
+
+				err := Run(context.Background(), &Config{
+					Webhook:      server.URL,
+					WebhookOn:    []Outcome{OutcomeFailure},
+					StatusFile:   statusFile,
+					StatusFileOn: []Outcome{OutcomeSuccess, OutcomeWarning},
+					Logger:       nopLogger{},
+				}, OutcomeSuccess, payload)
+				s.Require().Nil(err)
+
+				assert.Empty(s.T(), received)
+				assert.FileExists(s.T(), statusFile)
+			},
+		},
+		{
+			Name:          "err failing hooks don't stop the others",
+			PreRequisites: prepare,
+			Assert: func() {
+				status = http.StatusInternalServerError
+				statusFile := filepath.Join(dir, "last.json")
+
+				err := Run(context.Background(), &Config{
+					Command:    "exit 3",
+					Webhook:    server.URL,
+					StatusFile: statusFile,
+					Logger:     nopLogger{},
+				}, OutcomeFailure, payload)
+				assert.ErrorIs(s.T(), err, ErrHooks)
+				assert.Contains(s.T(), err.Error(), "webhook failed: 500")
+				assert.Contains(s.T(), err.Error(), "command")
+
+				assertPayload(<-received)
+				assert.FileExists(s.T(), statusFile)
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitParseOutcomes() {
+	testCases := []ax.TestCase{
+		{
+			Name: "success lists are split on commas",
+			Assert: func() {
+				outcomes, err := ParseOutcomes([]string{"failure, warning", "success"})
+				s.Require().Nil(err)
+				assert.Equal(s.T(), []Outcome{OutcomeFailure, OutcomeWarning, OutcomeSuccess}, outcomes)
+			},
+		},
+		{
+			Name: "err unknown outcome",
+			Assert: func() {
+				_, err := ParseOutcomes([]string{"failure,error"})
+				assert.ErrorIs(s.T(), err, ErrUnknownOutcome)
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
+
+// nopLogger - discards the progress of the hooks.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
@@ -0,0 +1,417 @@
+// Package metrics records metrics of the backups from their progress events, and exposes them in the Prometheus text
+// format, either over HTTP or as a file for the textfile collector of node_exporter.
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType - content type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Names of the metrics.
+const (
+	MetricRuns             = "ax_runs_total"
+	MetricLastRun          = "ax_last_run_timestamp_seconds"
+	MetricLastDuration     = "ax_last_run_duration_seconds"
+	MetricLastSuccess      = "ax_last_success_timestamp_seconds"
+	MetricLastFailure      = "ax_last_failure_timestamp_seconds"
+	MetricUp               = "ax_last_run_success"
+	MetricStageDuration    = "ax_last_stage_duration_seconds"
+	MetricSourceBytes      = "ax_last_source_bytes"
+	MetricArchiveBytes     = "ax_last_archive_bytes"
+	MetricPushedBytes      = "ax_last_pushed_bytes"
+	MetricPushedBytesTotal = "ax_pushed_bytes_total"
+	MetricCompressionRatio = "ax_last_compression_ratio"
+	MetricVolumes          = "ax_last_volumes"
+)
+
+const (
+	labelBackup  = "backup"
+	labelOutcome = "outcome"
+	labelStage   = "stage"
+	labelReason  = "reason"
+
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+
+	textfilePerm    = 0o644
+	textfileDirPerm = 0o755
+)
+
+// ErrInvalidText - metrics can't be read, as they aren't in the Prometheus text format written by Registry.
+var ErrInvalidText = errors.New("invalid metrics text")
+
+// description - represents the HELP and TYPE lines of a metric.
+type description struct {
+	name, kind, help string
+}
+
+// descriptions - returns descriptions of all the metrics, in the order in which they're written.
+func descriptions() []description {
+	return []description{
+		{MetricRuns, "counter", "Runs of the backup, by their outcome."},
+		{MetricLastRun, "gauge", "When the latest run of the backup has finished."},
+		{MetricLastDuration, "gauge", "How long the latest run of the backup has taken."},
+		{MetricUp, "gauge", "Whether the latest run of the backup has succeeded."},
+		{MetricLastSuccess, "gauge", "When the latest successful run of the backup has finished."},
+		{MetricLastFailure, "gauge", "When the latest failed run of the backup has finished, by the kind of its error."},
+		{MetricStageDuration, "gauge", "How long each stage of the latest run of the backup has taken."},
+		{MetricSourceBytes, "gauge", "Total size of the files archived by the latest successful run."},
+		{MetricArchiveBytes, "gauge", "Total size of the archive(s) written by the latest successful run."},
+		{MetricCompressionRatio, "gauge", "Size of the sources divided by the size of the archive(s), " +
+			"of the latest successful run."},
+		{MetricPushedBytes, "gauge", "Total size of the encrypted volume(s) pushed by the latest successful run."},
+		{MetricVolumes, "gauge", "Number of the encrypted volume(s) pushed by the latest successful run."},
+		{MetricPushedBytesTotal, "counter", "Total size of the encrypted volume(s) pushed by all runs."},
+	}
+}
+
+// Result - represents a finished run of a backup, along with its metrics.
+type Result struct {
+	Run
+
+	// Finished & Duration - when the run has finished, and how long it has taken.
+	Finished time.Time
+	Duration time.Duration
+
+	// FailureReason - kind of the error the run has failed with (i.e. remote), empty if it has succeeded.
+	FailureReason string
+}
+
+// sample - represents a single value of a metric, with its labels.
+type sample struct {
+	name   string
+	labels [][2]string
+	value  float64
+}
+
+// key - returns the series of the sample, i.e. 'ax_runs_total{backup="nginx",outcome="success"}'.
+func (s sample) key() string {
+	pairs := make([]string, 0, len(s.labels))
+
+	for _, l := range s.labels {
+		pairs = append(pairs, l[0]+`="`+escapeLabel(l[1])+`"`)
+	}
+
+	return s.name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// label - returns value of the label, or an empty string if the sample doesn't have it.
+func (s sample) label(name string) string {
+	for _, l := range s.labels {
+		if l[0] == name {
+			return l[1]
+		}
+	}
+
+	return ""
+}
+
+// Registry - holds the metrics of the runs of each backup, labeled by its name.
+type Registry struct {
+	mu      sync.Mutex
+	samples map[string]sample
+}
+
+// NewRegistry - returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{samples: make(map[string]sample)}
+}
+
+// Observe - records the finished run of the backup.
+func (r *Registry) Observe(backup string, res Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := [2]string{labelBackup, backup}
+	finished := float64(res.Finished.UnixNano()) / float64(time.Second)
+
+	outcome, up := outcomeSuccess, 1.0
+	if res.FailureReason != "" {
+		outcome, up = outcomeFailure, 0
+	}
+
+	r.add(MetricRuns, 1, b, [2]string{labelOutcome, outcome})
+	r.set(MetricLastRun, finished, b)
+	r.set(MetricLastDuration, res.Duration.Seconds(), b)
+	r.set(MetricUp, up, b)
+
+	// Stages are replaced, so that the ones which a failed run hasn't reached aren't left from the previous one.
+	r.remove(MetricStageDuration, backup)
+
+	for stage, d := range res.Stages {
+		r.set(MetricStageDuration, d.Seconds(), b, [2]string{labelStage, stage})
+	}
+
+	if res.FailureReason != "" {
+		r.remove(MetricLastFailure, backup)
+		r.set(MetricLastFailure, finished, b, [2]string{labelReason, res.FailureReason})
+
+		return
+	}
+
+	r.set(MetricLastSuccess, finished, b)
+	r.set(MetricSourceBytes, float64(res.SourceBytes), b)
+	r.set(MetricArchiveBytes, float64(res.ArchiveBytes), b)
+	r.set(MetricCompressionRatio, res.CompressionRatio(), b)
+	r.set(MetricPushedBytes, float64(res.PushedBytes), b)
+	r.set(MetricVolumes, float64(res.Volumes), b)
+	r.add(MetricPushedBytesTotal, float64(res.PushedBytes), b)
+}
+
+func (r *Registry) set(name string, value float64, labels ...[2]string) {
+	s := sample{name: name, labels: labels, value: value}
+	r.samples[s.key()] = s
+}
+
+func (r *Registry) add(name string, value float64, labels ...[2]string) {
+	s := sample{name: name, labels: labels}
+	r.set(name, r.samples[s.key()].value+value, labels...)
+}
+
+// remove - removes the samples of the metric, of the backup.
+func (r *Registry) remove(name, backup string) {
+	for key, s := range r.samples {
+		if s.name == name && s.label(labelBackup) == backup {
+			delete(r.samples, key)
+		}
+	}
+}
+
+// WriteText - writes the metrics in the Prometheus text format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+
+	for _, d := range descriptions() {
+		keys := make([]string, 0)
+
+		for key, s := range r.samples {
+			if s.name == d.name {
+				keys = append(keys, key)
+			}
+		}
+
+		if len(keys) == 0 {
+			continue
+		}
+
+		sort.Strings(keys)
+
+		_, _ = fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, d.kind)
+
+		for _, key := range keys {
+			_, _ = fmt.Fprintf(bw, "%s %s\n", key, strconv.FormatFloat(r.samples[key].value, 'f', -1, 64))
+		}
+	}
+
+	err := bw.Flush()
+	if err != nil {
+		return fmt.Errorf("failed writing metrics: %w", err)
+	}
+
+	return nil
+}
+
+// ReadText - reads the metrics written by WriteText, i.e. by a previous run, merging them into the Registry. Metrics
+// which aren't written by the Registry are left out.
+func (r *Registry) ReadText(rd io.Reader) error {
+	known := make(map[string]bool)
+	for _, d := range descriptions() {
+		known[d.name] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	scanner := bufio.NewScanner(rd)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		s, err := parseSample(line)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: %v", ErrInvalidText, n, err)
+		}
+
+		if known[s.name] {
+			r.samples[s.key()] = s
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return fmt.Errorf("failed reading metrics: %w", err)
+	}
+
+	return nil
+}
+
+// Handler - returns http.Handler serving the metrics in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+
+		_ = r.WriteText(w)
+	})
+}
+
+// LoadTextfile - reads the metrics written into the file by WriteTextfile. Missing file is treated as an empty one.
+func (r *Registry) LoadTextfile(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed opening metrics file: %w", err)
+	}
+
+	defer f.Close()
+
+	return r.ReadText(f)
+}
+
+// WriteTextfile - writes the metrics into the file, i.e. 'ax.prom' within the directory of the textfile collector of
+// node_exporter. They're written into a temporary file first, which is renamed over it, as the collector may read it
+// at any time.
+func (r *Registry) WriteTextfile(path string) error {
+	//nolint:gosec // Metrics are read by node_exporter, which usually runs as another user.
+	err := os.MkdirAll(filepath.Dir(path), textfileDirPerm)
+	if err != nil {
+		return fmt.Errorf("failed creating metrics file dir: %w", err)
+	}
+
+	// Collector only reads *.prom files, so it never reads the temporary one.
+	tmp := path + ".part"
+
+	//nolint:gosec // Metrics are read by node_exporter, which usually runs as another user.
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, textfilePerm)
+	if err != nil {
+		return fmt.Errorf("failed writing metrics file: %w", err)
+	}
+
+	err = r.WriteText(f)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed writing metrics file: %w", closeErr)
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed writing metrics file: %w", err)
+	}
+
+	return nil
+}
+
+// parseSample - parses the line holding a sample, i.e. 'ax_last_volumes{backup="nginx"} 3'.
+func parseSample(line string) (sample, error) {
+	var s sample
+
+	end := strings.IndexAny(line, "{ ")
+	if end <= 0 {
+		return s, errors.New("missing metric name")
+	}
+
+	s.name, line = line[:end], line[end:]
+
+	if strings.HasPrefix(line, "{") {
+		var err error
+
+		s.labels, line, err = parseLabels(line[1:])
+		if err != nil {
+			return s, err
+		}
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return s, errors.New("missing value")
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return s, fmt.Errorf("invalid value: %w", err)
+	}
+
+	s.value = value
+
+	return s, nil
+}
+
+// parseLabels - parses the labels following the opening brace, returning the rest of the line after the closing one.
+func parseLabels(line string) ([][2]string, string, error) {
+	var labels [][2]string
+
+	for {
+		line = strings.TrimLeft(line, " ,")
+		if strings.HasPrefix(line, "}") {
+			return labels, line[1:], nil
+		}
+
+		eq := strings.Index(line, `="`)
+		if eq <= 0 {
+			return nil, "", errors.New("invalid label")
+		}
+
+		name := line[:eq]
+		line = line[eq+2:]
+
+		var value strings.Builder
+
+		closed := false
+
+		for i := 0; i < len(line) && !closed; i++ {
+			switch c := line[i]; {
+			case c == '"':
+				closed, line = true, line[i+1:]
+			case c == '\\' && i+1 < len(line):
+				i++
+				value.WriteByte(unescapeLabelByte(line[i]))
+			default:
+				value.WriteByte(c)
+			}
+		}
+
+		if !closed {
+			return nil, "", errors.New("unterminated label value")
+		}
+
+		labels = append(labels, [2]string{name, value.String()})
+	}
+}
+
+// escapeLabel - escapes the label value, as the text format requires.
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func unescapeLabelByte(c byte) byte {
+	if c == 'n' {
+		return '\n'
+	}
+
+	return c
+}
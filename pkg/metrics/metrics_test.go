@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type Suite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	t.Parallel()
+
+	suite.Run(t, new(Suite))
+}
+
+// countingLogger - Logger counting the messages passed on to it.
+type countingLogger struct{ n int }
+
+func (l *countingLogger) Debug(string, ...interface{}) { l.n++ }
+func (l *countingLogger) Info(string, ...interface{})  { l.n++ }
+func (l *countingLogger) Warn(string, ...interface{})  { l.n++ }
+func (l *countingLogger) Error(string, ...interface{}) { l.n++ }
+
+func (s *Suite) TestUnitRecorder() {
+	testCases := []ax.TestCase{
+		{
+			Name: "success run is recorded from the progress events, which are passed on",
+			Assert: func() {
+				next := &countingLogger{}
+				r := NewRecorder(next)
+
+				r.Info(ax.EventArchived, "sources", []string{"/etc"}, ax.AttrDuration, 3*time.Second,
+					ax.AttrBytesIn, uint64(3000), ax.AttrBytesOut, uint64(1000))
+				r.Info(ax.EventVerified, ax.AttrDuration, time.Second)
+				r.Info(ax.EventEncrypted, "count", 2, ax.AttrDuration, time.Second, ax.AttrBytesOut, uint64(1010))
+				r.Info("Pushed a volume to origin/master", "volume", "backup.7z.001.enc.0")
+				r.Info(ax.EventPushed, ax.AttrVolumes, 2, ax.AttrDuration, 2*time.Second, ax.AttrBytesOut, uint64(1010))
+				r.Warn("unrelated warning", ax.AttrBytesIn, uint64(1))
+
+				run := r.Run()
+				assert.Equal(s.T(), map[string]time.Duration{
+					StageArchive: 3 * time.Second, StageVerify: time.Second, StageEncrypt: time.Second,
+					StagePush: 2 * time.Second,
+				}, run.Stages)
+				assert.Equal(s.T(), uint64(3000), run.SourceBytes)
+				assert.Equal(s.T(), uint64(1000), run.ArchiveBytes)
+				assert.Equal(s.T(), uint64(1010), run.PushedBytes)
+				assert.Equal(s.T(), 2, run.Volumes)
+				assert.Equal(s.T(), 3.0, run.CompressionRatio())
+				assert.Equal(s.T(), 6, next.n)
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitRegistry() {
+	var (
+		reg      *Registry
+		finished = time.Date(2021, 4, 1, 3, 0, 0, 0, time.UTC)
+		success  = Result{
+			Run: Run{
+				Stages:      map[string]time.Duration{StageStream: 1500 * time.Millisecond, StagePush: time.Second},
+				SourceBytes: 4096, ArchiveBytes: 1024, PushedBytes: 1024, Volumes: 1,
+			},
+			Finished: finished,
+			Duration: 3 * time.Second,
+		}
+	)
+
+	prepare := func() {
+		reg = NewRegistry()
+	}
+
+	text := func() string {
+		var buf bytes.Buffer
+
+		s.Require().Nil(reg.WriteText(&buf))
+
+		return buf.String()
+	}
+
+	testCases := []ax.TestCase{
+		{
+			Name:          "success runs are written in the text format",
+			PreRequisites: prepare,
+			Assert: func() {
+				reg.Observe("nginx", success)
+
+				failed := Result{
+					Run:           Run{Stages: map[string]time.Duration{StageStream: time.Second}},
+					Finished:      finished.Add(time.Hour),
+					Duration:      2 * time.Second,
+					FailureReason: "remote",
+				}
+				reg.Observe("nginx", failed)
+
+				out := text()
+				assert.Contains(s.T(), out, "# TYPE ax_runs_total counter\n"+
+					`ax_runs_total{backup="nginx",outcome="failure"} 1`+"\n"+
+					`ax_runs_total{backup="nginx",outcome="success"} 1`+"\n")
+				assert.Contains(s.T(), out, `ax_last_run_success{backup="nginx"} 0`+"\n")
+				assert.Contains(s.T(), out, `ax_last_success_timestamp_seconds{backup="nginx"} 1617246000`+"\n")
+				assert.Contains(s.T(), out,
+					`ax_last_failure_timestamp_seconds{backup="nginx",reason="remote"} 1617249600`+"\n")
+				assert.Contains(s.T(), out, `ax_last_stage_duration_seconds{backup="nginx",stage="stream"} 1`+"\n")
+				assert.NotContains(s.T(), out, `stage="push"`, "stages of the previous run are replaced")
+				assert.Contains(s.T(), out, `ax_last_compression_ratio{backup="nginx"} 4`+"\n")
+				assert.Contains(s.T(), out, `ax_pushed_bytes_total{backup="nginx"} 1024`+"\n")
+			},
+		},
+		{
+			Name:          "success textfile is read back, so that the next run adds to its counters",
+			PreRequisites: prepare,
+			Assert: func() {
+				path := filepath.Join(s.T().TempDir(), "textfile", "ax.prom")
+
+				reg.Observe(`odd "name"`, success)
+				s.Require().Nil(reg.WriteTextfile(path))
+
+				next := NewRegistry()
+				s.Require().Nil(next.LoadTextfile(path))
+				next.Observe(`odd "name"`, success)
+				reg = next
+
+				out := text()
+				assert.Contains(s.T(), out, `ax_runs_total{backup="odd \"name\"",outcome="success"} 2`+"\n")
+				assert.Contains(s.T(), out, `ax_pushed_bytes_total{backup="odd \"name\""} 2048`+"\n")
+				assert.NoFileExists(s.T(), path+".part")
+			},
+		},
+		{
+			Name:          "success missing textfile is an empty one",
+			PreRequisites: prepare,
+			Assert: func() {
+				s.Require().Nil(reg.LoadTextfile(filepath.Join(s.T().TempDir(), "missing.prom")))
+				assert.Empty(s.T(), text())
+			},
+		},
+		{
+			Name:          "success metrics are served over HTTP",
+			PreRequisites: prepare,
+			Assert: func() {
+				reg.Observe("notes", success)
+
+				rec := httptest.NewRecorder()
+				reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+				resp := rec.Result()
+				defer resp.Body.Close()
+
+				body, err := io.ReadAll(resp.Body)
+				s.Require().Nil(err)
+				assert.Equal(s.T(), ContentType, rec.Header().Get("Content-Type"))
+				assert.Equal(s.T(), text(), string(body))
+			},
+		},
+		{
+			Name:          "err text isn't in the format",
+			PreRequisites: prepare,
+			Assert: func() {
+				err := reg.ReadText(strings.NewReader(`ax_runs_total{backup="nginx} 1`))
+				assert.ErrorIs(s.T(), err, ErrInvalidText)
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
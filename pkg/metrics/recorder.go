@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kaynetik/ax"
+)
+
+// Stages of a run, as they're labeled in the metrics.
+const (
+	StageArchive = "archive"
+	StageStream  = "stream"
+	StageVerify  = "verify"
+	StageEncrypt = "encrypt"
+	StagePush    = "push"
+)
+
+// Run - represents the metrics of a single run, recorded from its progress events.
+type Run struct {
+	// Stages - how long each of the completed stages has taken, by its name, i.e. StageArchive.
+	Stages map[string]time.Duration
+
+	// SourceBytes - total size of the archived files.
+	SourceBytes uint64
+
+	// ArchiveBytes - total size of the archive(s). Streamed archives are already encrypted.
+	ArchiveBytes uint64
+
+	// PushedBytes & Volumes - total size and number of the encrypted volume(s) pushed to the remote.
+	PushedBytes uint64
+	Volumes     int
+}
+
+// CompressionRatio - returns the size of the sources divided by the size of the archive(s), or 0 if nothing has been
+// archived.
+func (r Run) CompressionRatio() float64 {
+	if r.ArchiveBytes == 0 {
+		return 0
+	}
+
+	return float64(r.SourceBytes) / float64(r.ArchiveBytes)
+}
+
+// Recorder - ax.Logger recording the metrics of a run from its progress events (see ax.EventArchived), while passing
+// everything on to the next Logger, so that the progress is reported just as it would be without it.
+type Recorder struct {
+	next ax.Logger
+
+	mu  sync.Mutex
+	run Run
+}
+
+// NewRecorder - returns Recorder passing the progress on to the next Logger, or to the ax.DefaultLogger if it's nil.
+func NewRecorder(next ax.Logger) *Recorder {
+	if next == nil {
+		next = ax.DefaultLogger()
+	}
+
+	return &Recorder{next: next, run: Run{Stages: make(map[string]time.Duration)}}
+}
+
+// Debug - passes the message on.
+func (r *Recorder) Debug(msg string, args ...interface{}) { r.next.Debug(msg, args...) }
+
+// Info - records the event, if it's one of the progress events, and passes it on.
+func (r *Recorder) Info(msg string, args ...interface{}) {
+	r.observe(msg, args)
+	r.next.Info(msg, args...)
+}
+
+// Warn - passes the warning on.
+func (r *Recorder) Warn(msg string, args ...interface{}) { r.next.Warn(msg, args...) }
+
+// Error - passes the error on.
+func (r *Recorder) Error(msg string, args ...interface{}) { r.next.Error(msg, args...) }
+
+// Run - returns the metrics recorded so far.
+func (r *Recorder) Run() Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run := r.run
+	run.Stages = make(map[string]time.Duration, len(r.run.Stages))
+
+	for stage, d := range r.run.Stages {
+		run.Stages[stage] = d
+	}
+
+	return run
+}
+
+// observe - records the attributes of the progress event.
+func (r *Recorder) observe(msg string, args []interface{}) {
+	stage := stageOf(msg)
+	if stage == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := 0; i+1 < len(args); i += 2 {
+		key, _ := args[i].(string)
+
+		switch v := args[i+1].(type) {
+		case time.Duration:
+			if key == ax.AttrDuration {
+				r.run.Stages[stage] += v
+			}
+		case uint64:
+			r.observeBytes(stage, key, v)
+		case int:
+			if key == ax.AttrVolumes && stage == StagePush {
+				r.run.Volumes = v
+			}
+		}
+	}
+}
+
+// observeBytes - records the size reported by the stage.
+func (r *Recorder) observeBytes(stage, key string, v uint64) {
+	switch {
+	case key == ax.AttrBytesIn:
+		r.run.SourceBytes = v
+	case key == ax.AttrBytesOut && (stage == StageArchive || stage == StageStream):
+		r.run.ArchiveBytes = v
+	case key == ax.AttrBytesOut && stage == StagePush:
+		r.run.PushedBytes = v
+	}
+}
+
+// stageOf - returns the stage finished by the progress event, or an empty string if it's another message.
+func stageOf(msg string) string {
+	switch msg {
+	case ax.EventArchived:
+		return StageArchive
+	case ax.EventStreamed:
+		return StageStream
+	case ax.EventVerified:
+		return StageVerify
+	case ax.EventEncrypted:
+		return StageEncrypt
+	case ax.EventPushed:
+		return StagePush
+	default:
+		return ""
+	}
+}
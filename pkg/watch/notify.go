@@ -0,0 +1,179 @@
+package watch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/clock"
+)
+
+const (
+	// DefaultPollInterval - how often the paths are walked, if they can't be watched natively.
+	DefaultPollInterval = 10 * time.Second
+
+	// eventBuffer - number of changes a Notifier holds, before it drops the ones which aren't received.
+	eventBuffer = 128
+)
+
+// NewNotifier - returns Notifier watching the paths (and everything within them), by inotify on Linux. Polling every
+// interval is used instead if the interval is set, or if the paths can't be watched natively, which is reported to the
+// logger then.
+func NewNotifier(paths []string, interval time.Duration, log ax.Logger) (Notifier, error) {
+	if interval > 0 {
+		return NewPoller(paths, interval, clock.Real())
+	}
+
+	n, err := newNativeNotifier(paths)
+	if err == nil {
+		return n, nil
+	}
+
+	log.Warn("changes can't be watched natively, polling for them instead", "err", err, "interval",
+		DefaultPollInterval)
+
+	return NewPoller(paths, DefaultPollInterval, clock.Real())
+}
+
+// fileState - represents what polling compares, to tell whether a file has changed.
+type fileState struct {
+	size int64
+	mode os.FileMode
+
+	// modTime - modification time, in nanoseconds since the epoch.
+	modTime int64
+}
+
+// poller - Notifier walking the paths every interval, reporting what has changed since the previous walk.
+type poller struct {
+	paths    []string
+	interval time.Duration
+	clock    clock.Clock
+
+	states map[string]fileState
+	events chan string
+	errs   chan error
+
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// NewPoller - returns Notifier walking the paths every interval, reporting files which have been created, removed or
+// modified since the previous walk. The paths are walked once before it returns, so that later changes are reported.
+func NewPoller(paths []string, interval time.Duration, clk clock.Clock) (Notifier, error) {
+	p := &poller{
+		paths:    paths,
+		interval: interval,
+		clock:    clk,
+		events:   make(chan string, eventBuffer),
+		errs:     make(chan error, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	states, err := p.walk()
+	if err != nil {
+		return nil, err
+	}
+
+	p.states = states
+
+	go p.poll()
+
+	return p, nil
+}
+
+// Events - receives the path of each change.
+func (p *poller) Events() <-chan string { return p.events }
+
+// Errors - receives errors of the walks which haven't completed.
+func (p *poller) Errors() <-chan error { return p.errs }
+
+// Close - stops polling.
+func (p *poller) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	<-p.stopped
+
+	return nil
+}
+
+func (p *poller) poll() {
+	defer close(p.stopped)
+	defer close(p.events)
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-p.clock.After(p.interval):
+		}
+
+		states, err := p.walk()
+		if err != nil {
+			reportErr(p.errs, err)
+
+			continue
+		}
+
+		for path, state := range states {
+			if prev, ok := p.states[path]; !ok || prev != state {
+				sendNonBlocking(p.events, path)
+			}
+		}
+
+		for path := range p.states {
+			if _, ok := states[path]; !ok {
+				sendNonBlocking(p.events, path)
+			}
+		}
+
+		p.states = states
+	}
+}
+
+// walk - returns the state of everything within the paths. Files which disappear while walking are left out.
+func (p *poller) walk() (map[string]fileState, error) {
+	states := make(map[string]fileState)
+
+	for _, root := range p.paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			states[path] = fileState{size: info.Size(), mode: info.Mode(), modTime: info.ModTime().UnixNano()}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed polling %s for changes: %w", root, err)
+		}
+	}
+
+	return states, nil
+}
+
+// sendNonBlocking - sends the value, unless the channel is full. Dropped changes don't matter, as the watch only needs
+// to know that something has changed, which the ones in the channel already tell.
+func sendNonBlocking(ch chan<- string, path string) {
+	select {
+	case ch <- path:
+	default:
+	}
+}
+
+// reportErr - sends the error, unless there is one which hasn't been received yet.
+func reportErr(ch chan<- error, err error) {
+	select {
+	case ch <- err:
+	default:
+	}
+}
@@ -0,0 +1,194 @@
+//go:build linux
+// +build linux
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// inotifyMask - events which tell that something within a watched directory has changed.
+	inotifyMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MODIFY | unix.IN_ATTRIB | unix.IN_MOVED_FROM |
+		unix.IN_MOVED_TO | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF
+
+	// inotifyBuffer - size of the buffer events are read into, fitting many of them, even with the longest names.
+	inotifyBuffer = 64 * (unix.SizeofInotifyEvent + unix.NAME_MAX + 1)
+)
+
+// inotify - Notifier watching every directory within the paths by inotify, adding watches for directories as they're
+// created.
+type inotify struct {
+	paths []string
+	fd    int
+	f     *os.File
+
+	mu sync.Mutex
+	// watches - watched paths, by their watch descriptors.
+	watches map[int]string
+
+	events  chan string
+	errs    chan error
+	stopped chan struct{}
+}
+
+// newNativeNotifier - returns Notifier watching the paths by inotify. It fails if inotify isn't available, or if the
+// limit of watches (fs.inotify.max_user_watches) has been reached.
+func newNativeNotifier(paths []string) (Notifier, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed initializing inotify: %w", err)
+	}
+
+	n := &inotify{
+		paths: paths,
+		fd:    fd,
+		// Being non-blocking, the descriptor is read through the runtime poller, so that closing it stops the read.
+		f:       os.NewFile(uintptr(fd), "inotify"),
+		watches: make(map[int]string),
+		events:  make(chan string, eventBuffer),
+		errs:    make(chan error, 1),
+		stopped: make(chan struct{}),
+	}
+
+	for _, p := range paths {
+		err = n.addRecursive(p)
+		if err != nil {
+			_ = n.f.Close()
+
+			return nil, err
+		}
+	}
+
+	go n.read()
+
+	return n, nil
+}
+
+// Events - receives the path of each change.
+func (n *inotify) Events() <-chan string { return n.events }
+
+// Errors - receives errors of directories which couldn't be watched, once they've been created.
+func (n *inotify) Errors() <-chan error { return n.errs }
+
+// Close - removes all watches.
+func (n *inotify) Close() error {
+	err := n.f.Close()
+	<-n.stopped
+
+	if err != nil && !errors.Is(err, os.ErrClosed) {
+		return fmt.Errorf("failed closing inotify: %w", err)
+	}
+
+	return nil
+}
+
+// addRecursive - watches the path, along with every directory within it.
+func (n *inotify) addRecursive(root string) error {
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if path != root && !info.IsDir() {
+			return nil
+		}
+
+		wd, err := unix.InotifyAddWatch(n.fd, path, inotifyMask)
+		if err != nil {
+			return fmt.Errorf("failed watching %s: %w", path, err)
+		}
+
+		n.mu.Lock()
+		n.watches[wd] = path
+		n.mu.Unlock()
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed watching %s: %w", root, err)
+	}
+
+	return nil
+}
+
+// read - reads events until the descriptor is closed.
+func (n *inotify) read() {
+	defer close(n.stopped)
+	defer close(n.events)
+
+	buf := make([]byte, inotifyBuffer)
+
+	for {
+		size, err := n.f.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				reportErr(n.errs, fmt.Errorf("failed reading inotify events: %w", err))
+			}
+
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= size; {
+			//nolint:gosec // Events are laid out by the kernel as InotifyEvent structs, followed by their names.
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameAt := offset + unix.SizeofInotifyEvent
+			offset = nameAt + int(event.Len)
+
+			n.handle(int(event.Wd), event.Mask, strings.TrimRight(string(buf[nameAt:offset]), "\x00"))
+		}
+	}
+}
+
+// handle - reports the changed path, watching directories which have been created or moved in.
+func (n *inotify) handle(wd int, mask uint32, name string) {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		// Events have been dropped, so which of the paths has changed isn't known.
+		for _, p := range n.paths {
+			sendNonBlocking(n.events, p)
+		}
+
+		return
+	}
+
+	n.mu.Lock()
+	dir, ok := n.watches[wd]
+
+	// Watch has been removed, as its directory has been.
+	ignored := mask&unix.IN_IGNORED != 0
+	if ignored {
+		delete(n.watches, wd)
+	}
+
+	n.mu.Unlock()
+
+	if !ok || ignored {
+		return
+	}
+
+	path := dir
+	if name != "" {
+		path = filepath.Join(dir, name)
+	}
+
+	if mask&unix.IN_ISDIR != 0 && mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 {
+		err := n.addRecursive(path)
+		if err != nil {
+			reportErr(n.errs, err)
+		}
+	}
+
+	sendNonBlocking(n.events, path)
+}
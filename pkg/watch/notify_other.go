@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package watch
+
+import "errors"
+
+// errNativeUnsupported - the platform has no native change notification supported by ax.
+var errNativeUnsupported = errors.New("native change notification isn't supported on this platform")
+
+// newNativeNotifier - returns errNativeUnsupported, as only inotify on Linux is supported, so the paths are polled.
+func newNativeNotifier([]string) (Notifier, error) {
+	return nil, errNativeUnsupported
+}
@@ -0,0 +1,252 @@
+// Package watch runs a backup whenever the watched sources change. Bursts of changes are coalesced by a debounce,
+// backups start no more often than a minimum interval, and failed ones are retried after an exponential backoff.
+package watch
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/clock"
+)
+
+const (
+	// DefaultDebounce - how long the sources have to stay unchanged, before the backup starts.
+	DefaultDebounce = 2 * time.Second
+
+	// DefaultMinInterval - minimum time between the starts of two backups.
+	DefaultMinInterval = time.Minute
+
+	// DefaultBackoff & DefaultMaxBackoff - delay of the retry of a failed backup, which is doubled with each failure
+	// in a row, up to the max.
+	DefaultBackoff    = 30 * time.Second
+	DefaultMaxBackoff = time.Hour
+)
+
+var (
+	// ErrInvalidConfig - watch is missing its notifier or run func.
+	ErrInvalidConfig = errors.New("watch needs a notifier and a run func")
+
+	// ErrNotifierClosed - notifier has stopped reporting changes, while the watch was still running.
+	ErrNotifierClosed = errors.New("notifier has been closed")
+)
+
+// Notifier - reports changes within the watched paths.
+type Notifier interface {
+	// Events - receives the path of each change. Bursts of changes may be reported only partially, as the watch only
+	// needs to know that something has changed. It's closed once the Notifier is.
+	Events() <-chan string
+
+	// Errors - receives errors which haven't stopped the Notifier, i.e. a directory which couldn't be watched.
+	Errors() <-chan error
+
+	// Close - stops reporting changes, releasing the resources of the Notifier.
+	Close() error
+}
+
+// Config - represents the settings of the watch.
+type Config struct {
+	Notifier Notifier
+
+	// Run - runs the backup, which should stop, cleaning up after itself, once the context is done.
+	Run func(ctx context.Context) error
+
+	// Debounce - how long the sources have to stay unchanged, before the backup starts (DefaultDebounce if zero).
+	Debounce time.Duration
+
+	// MinInterval - minimum time between the starts of two backups (DefaultMinInterval if zero). Changes made while a
+	// backup runs, or sooner than that, are backed up once it passes.
+	MinInterval time.Duration
+
+	// Backoff & MaxBackoff - delay of the retry of a failed backup, doubled with each failure in a row, up to the max
+	// (DefaultBackoff and DefaultMaxBackoff if zero).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Ignore - if set, changes of the paths it reports are ignored, i.e. the ones within the work directory.
+	Ignore func(path string) bool
+
+	// Clock - time source, clock.Real if it's nil.
+	Clock clock.Clock
+
+	// Logger - if set, progress is reported to it instead of the ax.DefaultLogger.
+	Logger ax.Logger
+}
+
+// watcher - represents the state of a running watch.
+type watcher struct {
+	conf Config
+
+	// dirty - there are changes which haven't been backed up yet.
+	dirty bool
+
+	// lastChange, lastStart & retryAt - times of the latest change, of the start of the latest backup, and before which
+	// a failed backup isn't retried.
+	lastChange, lastStart, retryAt time.Time
+
+	// failures - number of backups which have failed in a row.
+	failures int
+}
+
+// Run - backs up the sources once at start, as they may have changed while they weren't watched, and then whenever they
+// change, until the context is done. A running backup is canceled then, and Run returns once it has cleaned up.
+func Run(ctx context.Context, conf Config) error {
+	if conf.Notifier == nil || conf.Run == nil {
+		return ErrInvalidConfig
+	}
+
+	w := &watcher{conf: withDefaults(conf), dirty: true}
+	clk, log := w.conf.Clock, w.conf.Logger
+
+	var (
+		done    chan error
+		timer   <-chan time.Time
+		timerAt time.Time
+	)
+
+	log.Info("Watching for changes", "debounce", w.conf.Debounce, "min_interval", w.conf.MinInterval)
+
+	for {
+		if w.dirty && done == nil {
+			now, due := clk.Now(), w.due()
+
+			if !due.After(now) {
+				done = w.start(ctx)
+
+				continue
+			}
+
+			// Timer is replaced only if the backup is due earlier, so that a Fake clock isn't left with stale ones.
+			if timer == nil || due.Before(timerAt) {
+				timer, timerAt = clk.After(due.Sub(now)), due
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if done != nil {
+				<-done
+			}
+
+			log.Info("Stopped watching")
+
+			return nil
+		case path, ok := <-w.conf.Notifier.Events():
+			if !ok {
+				if done != nil {
+					<-done
+				}
+
+				return ErrNotifierClosed
+			}
+
+			if w.conf.Ignore == nil || !w.conf.Ignore(path) {
+				log.Debug("Change noticed", "path", path)
+				w.dirty, w.lastChange = true, clk.Now()
+			}
+		case err := <-w.conf.Notifier.Errors():
+			log.Warn("watching for changes failed partially", "err", err)
+		case err := <-done:
+			done = nil
+			w.finish(ctx, err)
+		case <-timer:
+			timer = nil
+		}
+	}
+}
+
+// withDefaults - returns the config with defaults in place of the settings which have been left out.
+func withDefaults(conf Config) Config {
+	if conf.Debounce <= 0 {
+		conf.Debounce = DefaultDebounce
+	}
+
+	if conf.MinInterval <= 0 {
+		conf.MinInterval = DefaultMinInterval
+	}
+
+	if conf.Backoff <= 0 {
+		conf.Backoff = DefaultBackoff
+	}
+
+	if conf.MaxBackoff <= 0 {
+		conf.MaxBackoff = DefaultMaxBackoff
+	}
+
+	if conf.MaxBackoff < conf.Backoff {
+		conf.MaxBackoff = conf.Backoff
+	}
+
+	if conf.Clock == nil {
+		conf.Clock = clock.Real()
+	}
+
+	if conf.Logger == nil {
+		conf.Logger = ax.DefaultLogger()
+	}
+
+	return conf
+}
+
+// due - returns the time the next backup may start: once the sources have settled, the minimum interval has passed
+// since the previous backup started, and the backoff of a failed one is over.
+func (w *watcher) due() time.Time {
+	due := w.lastChange.Add(w.conf.Debounce)
+
+	if !w.lastStart.IsZero() && w.lastStart.Add(w.conf.MinInterval).After(due) {
+		due = w.lastStart.Add(w.conf.MinInterval)
+	}
+
+	if w.retryAt.After(due) {
+		due = w.retryAt
+	}
+
+	return due
+}
+
+// start - runs the backup in its own goroutine, returning the channel its result is sent to.
+func (w *watcher) start(ctx context.Context) chan error {
+	w.dirty, w.lastStart = false, w.conf.Clock.Now()
+
+	done := make(chan error, 1)
+
+	go func() { done <- w.conf.Run(ctx) }()
+
+	return done
+}
+
+// finish - handles the result of the backup, retrying a failed one once its backoff is over.
+func (w *watcher) finish(ctx context.Context, err error) {
+	if err == nil {
+		w.failures, w.retryAt = 0, time.Time{}
+		w.conf.Logger.Info("Changes backed up")
+
+		return
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	w.failures++
+	backoff := w.backoff()
+	w.dirty, w.retryAt = true, w.conf.Clock.Now().Add(backoff)
+
+	w.conf.Logger.Error("backup failed, retrying", "err", err, "failures", w.failures, "retry_in", backoff)
+}
+
+// backoff - returns the delay of the retry, after the number of failures in a row.
+func (w *watcher) backoff() time.Duration {
+	backoff := w.conf.Backoff
+
+	for i := 1; i < w.failures && backoff < w.conf.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > w.conf.MaxBackoff {
+		backoff = w.conf.MaxBackoff
+	}
+
+	return backoff
+}
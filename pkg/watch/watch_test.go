@@ -0,0 +1,276 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaynetik/ax"
+	"github.com/kaynetik/ax/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// ignoredPrefix - prefix of the paths ignored by the watch, sent after each change to wait until it's been handled.
+const ignoredPrefix = "/work/"
+
+type Suite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	t.Parallel()
+
+	suite.Run(t, new(Suite))
+}
+
+// fakeNotifier - Notifier reporting the changes sent by the test. Events aren't buffered, so that a send returns only
+// once the watch has received it.
+type fakeNotifier struct {
+	events chan string
+	errs   chan error
+}
+
+func (n *fakeNotifier) Events() <-chan string { return n.events }
+func (n *fakeNotifier) Errors() <-chan error  { return n.errs }
+func (n *fakeNotifier) Close() error          { return nil }
+
+func (s *Suite) TestUnitRun() {
+	var (
+		fake     *clock.Fake
+		notifier *fakeNotifier
+		started  chan time.Time
+		results  chan error
+		ctx      context.Context
+		cancel   context.CancelFunc
+		done     chan error
+		start    = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	)
+
+	prepare := func() {
+		fake = clock.NewFake(start)
+		notifier = &fakeNotifier{events: make(chan string), errs: make(chan error)}
+		started, results = make(chan time.Time, 10), make(chan error)
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan error, 1)
+	}
+
+	run := func(conf Config) {
+		conf.Notifier, conf.Clock, conf.Logger = notifier, fake, nopLogger{}
+		conf.Ignore = func(path string) bool { return strings.HasPrefix(path, ignoredPrefix) }
+		conf.Run = func(ctx context.Context) error {
+			started <- fake.Now()
+
+			select {
+			case err := <-results:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		go func() { done <- Run(ctx, conf) }()
+	}
+
+	// change - reports the change, returning once the watch has handled it.
+	change := func(path string) {
+		notifier.events <- path
+		notifier.events <- ignoredPrefix + path
+	}
+
+	stop := func() {
+		cancel()
+		s.Require().Nil(<-done)
+	}
+
+	testCases := []ax.TestCase{
+		{
+			Name:          "success bursts of changes are coalesced into a single backup",
+			PreRequisites: prepare,
+			Assert: func() {
+				run(Config{Debounce: 2 * time.Second, MinInterval: 10 * time.Second})
+
+				assert.Equal(s.T(), start, <-started, "sources are backed up at start")
+				results <- nil
+
+				fake.Advance(20 * time.Second)
+				change("a")
+				change("b")
+				fake.Advance(time.Second)
+				change("c")
+
+				// Debounce of the first changes is over, but not of the last one.
+				fake.Advance(time.Second)
+				fake.BlockUntil(1)
+				assert.Empty(s.T(), started)
+
+				fake.Advance(time.Second)
+				assert.Equal(s.T(), start.Add(23*time.Second), <-started)
+				results <- nil
+
+				change(ignoredPrefix + "volume.001")
+				assert.Empty(s.T(), started)
+				assert.Zero(s.T(), fake.Waiters())
+
+				stop()
+			},
+		},
+		{
+			Name:          "success changes made while a backup runs are backed up after the min interval",
+			PreRequisites: prepare,
+			Assert: func() {
+				run(Config{Debounce: 2 * time.Second, MinInterval: 10 * time.Second})
+
+				<-started
+				change("a")
+				fake.Advance(3 * time.Second)
+				results <- nil
+
+				fake.BlockUntil(1)
+				fake.Advance(6 * time.Second)
+				assert.Empty(s.T(), started)
+
+				fake.Advance(time.Second)
+				assert.Equal(s.T(), start.Add(10*time.Second), <-started)
+
+				stop()
+			},
+		},
+		{
+			Name:          "success failed backups are retried with an exponential backoff",
+			PreRequisites: prepare,
+			Assert: func() {
+				run(Config{
+					Debounce: time.Second, MinInterval: time.Second, Backoff: 5 * time.Second, MaxBackoff: 12 * time.Second,
+				})
+
+				<-started
+
+				for _, backoff := range []time.Duration{5 * time.Second, 10 * time.Second, 12 * time.Second} {
+					results <- errors.New("remote is down")
+
+					fake.BlockUntil(1)
+					fake.Advance(backoff)
+					assert.Equal(s.T(), fake.Now(), <-started)
+				}
+
+				results <- nil
+
+				change(ignoredPrefix + "sync")
+				assert.Zero(s.T(), fake.Waiters(), "backup isn't retried once it has succeeded")
+				assert.Equal(s.T(), start.Add(27*time.Second), fake.Now())
+
+				stop()
+			},
+		},
+		{
+			Name:          "success stopping the watch cancels the running backup",
+			PreRequisites: prepare,
+			Assert: func() {
+				run(Config{})
+
+				<-started
+				stop()
+			},
+		},
+		{
+			Name: "err notifier and run func are required",
+			Assert: func() {
+				err := Run(context.Background(), Config{})
+				assert.ErrorIs(s.T(), err, ErrInvalidConfig)
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitNotifier() {
+	var dir string
+
+	prepare := func() {
+		dir = s.T().TempDir()
+	}
+
+	// expectEvent - waits for the change of the path to be reported, skipping the others.
+	expectEvent := func(events <-chan string, path string) {
+		timeout := time.After(5 * time.Second)
+
+		for {
+			select {
+			case p := <-events:
+				if p == path {
+					return
+				}
+			case <-timeout:
+				s.T().Fatalf("change of %s hasn't been reported", path)
+			}
+		}
+	}
+
+	write := func(path, content string) {
+		s.Require().Nil(os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	testCases := []ax.TestCase{
+		{
+			Name:          "success polling reports created, modified and removed files",
+			PreRequisites: prepare,
+			Assert: func() {
+				fake := clock.NewFake(time.Now())
+				write(filepath.Join(dir, "a"), "a")
+
+				n, err := NewPoller([]string{dir}, time.Second, fake)
+				s.Require().Nil(err)
+
+				write(filepath.Join(dir, "a"), "modified")
+				write(filepath.Join(dir, "b"), "b")
+
+				fake.BlockUntil(1)
+				fake.Advance(time.Second)
+				expectEvent(n.Events(), filepath.Join(dir, "a"))
+
+				fake.BlockUntil(1)
+				s.Require().Nil(os.Remove(filepath.Join(dir, "b")))
+				fake.Advance(time.Second)
+				expectEvent(n.Events(), filepath.Join(dir, "b"))
+
+				s.Require().Nil(n.Close())
+
+				// Changes left unreceived are dropped, and the channel is closed.
+				for range n.Events() {
+				}
+			},
+		},
+		{
+			Name:          "success native notifier reports changes within directories created after it started",
+			PreRequisites: prepare,
+			Assert: func() {
+				n, err := NewNotifier([]string{dir}, 0, nopLogger{})
+				s.Require().Nil(err)
+
+				s.Require().Nil(os.Mkdir(filepath.Join(dir, "sub"), 0o700))
+				expectEvent(n.Events(), filepath.Join(dir, "sub"))
+
+				write(filepath.Join(dir, "sub", "note.md"), "note")
+				expectEvent(n.Events(), filepath.Join(dir, "sub", "note.md"))
+
+				s.Require().Nil(n.Close())
+			},
+		},
+	}
+
+	ax.RunTestCases(s, testCases)
+}
+
+// nopLogger - discards the progress of the watch.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
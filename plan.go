@@ -0,0 +1,168 @@
+package ax
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// planListFile - stands for the temporary list file in the planned 7zip command, as it's never written by a plan.
+	planListFile = "ax-list-*.txt"
+
+	// firstVolumeSuffix - suffix 7zip appends to the name of the first volume, when the archive is split.
+	firstVolumeSuffix = ".001"
+)
+
+// ArchivePlan - represents what Archive would do with the config, as reported by PlanArchive.
+type ArchivePlan struct {
+	// Root - directory the files are archived relative to, which 7zip is executed in.
+	Root string `json:"root"`
+
+	// Files - paths of the files (and empty directories) as they'd be stored within the archive(s).
+	Files []string `json:"files"`
+
+	// Size - total size of the files in bytes, before compression.
+	Size uint64 `json:"size"`
+
+	// Archive - path of the archive, or of its first volume if it's split.
+	Archive string `json:"archive"`
+
+	// VolumeSize - size of each volume in bytes, or 0 if the archive isn't split.
+	VolumeSize uint64 `json:"volume_size"`
+
+	// Volumes - estimated number of volumes. It's an upper bound, as it's estimated from the uncompressed Size.
+	Volumes uint64 `json:"volumes"`
+
+	// Existing - archive files which already exist at the output, which 7zip would update instead of replacing.
+	Existing []string `json:"existing,omitempty"`
+
+	// Command - 7zip command which would be executed, with the password redacted, if any.
+	Command []string `json:"command,omitempty"`
+}
+
+// PlanArchive - returns what Archive would do with the config, without archiving, executing or writing anything.
+// The config is validated just as by Archive, but it isn't modified. Password isn't needed to plan, if ApplyPassword
+// is set the planned command holds it redacted either way.
+func PlanArchive(conf *ArchiveConfig) (*ArchivePlan, error) {
+	err := conf.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := planEntries(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	ac := *conf
+	if ac.ApplyPassword && len(ac.Password) == 0 {
+		ac.Password = []byte(redactedSecret)
+	}
+
+	input := "@" + filepath.Join(os.TempDir(), planListFile)
+	if ac.PreserveMetadata {
+		input = "-si" + metadataTarName
+	}
+
+	args, err := cmdArgsArchive(&ac, input)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.Command = append([]string{sevenZipCmd()}, redactArgs(args)...)
+
+	return plan.withVolumes(args[len(args)-2], ac.VolumeSize*unitBytes(ac.BlockSize))
+}
+
+// PlanStream - returns what StreamArchive would do with the config, just as PlanArchive does. No command is planned,
+// as 7zip isn't executed by StreamArchive.
+func PlanStream(conf *ArchiveConfig) (*ArchivePlan, error) {
+	plan, err := planEntries(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.withVolumes(streamBase(conf), streamVolumeSize(conf))
+}
+
+// planEntries - returns the plan holding the entries which would be archived, and their total size.
+func planEntries(conf *ArchiveConfig) (*ArchivePlan, error) {
+	err := validatePathToArchive(conf)
+	if err != nil {
+		return nil, fmt.Errorf("path validation issue: %w", err)
+	}
+
+	root, entries, err := listArchiveEntries(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := entriesSize(root, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArchivePlan{Root: root, Files: entries, Size: size}, nil
+}
+
+// withVolumes - sets path of the archive (of its first volume, if it's split), estimates the number of volumes, and
+// finds the archive files which already exist.
+func (p *ArchivePlan) withVolumes(archive string, volumeSize uint64) (*ArchivePlan, error) {
+	p.Archive, p.VolumeSize, p.Volumes = archive, volumeSize, 1
+
+	if volumeSize != 0 {
+		p.Archive += firstVolumeSuffix
+
+		if p.Size > volumeSize {
+			p.Volumes = (p.Size + volumeSize - 1) / volumeSize
+		}
+	}
+
+	var err error
+
+	p.Existing, err = existingArchives(p.Archive)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// entriesSize - returns total size of the files among the entries, relative to root.
+func entriesSize(root string, entries []string) (uint64, error) {
+	var size uint64
+
+	for _, entry := range entries {
+		info, err := os.Lstat(filepath.Join(root, entry))
+		if err != nil {
+			return 0, fmt.Errorf("failed getting stat of %s: %w", entry, err)
+		}
+
+		if info.Mode().IsRegular() {
+			size += uint64(info.Size())
+		}
+	}
+
+	return size, nil
+}
+
+// existingArchives - returns the archive, or its volumes, which already exist.
+func existingArchives(archive string) ([]string, error) {
+	base := strings.TrimSuffix(archive, firstVolumeSuffix)
+
+	volumes, err := filepath.Glob(base + ".[0-9][0-9][0-9]")
+	if err != nil {
+		return nil, fmt.Errorf("failed listing existing volumes: %w", err)
+	}
+
+	if _, err := os.Lstat(base); err == nil {
+		volumes = append(volumes, base)
+	}
+
+	sort.Strings(volumes)
+
+	return volumes, nil
+}
@@ -0,0 +1,425 @@
+package ax
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsafePath - archived entry would be extracted outside of the output directory.
+var ErrUnsafePath = errors.New("unsafe archive entry path")
+
+// Bits of the attributes reported by 7zip, which hold the unix mode of the entry in their upper 16 bits, if the unix
+// extension bit is set.
+const (
+	attrUnixExtension = 0x8000
+	modeTypeMask      = 0xF000
+	modeTypeSymlink   = 0xA000
+)
+
+// maxSymlinks - number of symlinks resolved within a single path, after which it's considered to be a loop, just like
+// the file system does.
+const maxSymlinks = 40
+
+// Reasons for which entries are rejected.
+const (
+	unsafeAbsolutePath   = "absolute path"
+	unsafeParentDir      = "reference to a parent directory"
+	unsafeSymlink        = "symlink pointing outside of the output directory"
+	unsafeUnknownSymlink = "symlink whose target isn't listed, so it can't be checked"
+	unsafeHardLink       = "hard link pointing outside of the output directory"
+	unsafeResolvedParent = "path resolves outside of the output directory through a symlink"
+)
+
+// UnsafeEntry - represents an archived entry which has been rejected, as it would end up outside of the output
+// directory once extracted.
+type UnsafeEntry struct {
+	// Path - path of the entry, as stored in the archive.
+	Path string `json:"path"`
+
+	// Reason - why the entry has been rejected.
+	Reason string `json:"reason"`
+}
+
+// UnsafePathsError - represents rejection of the archive(s), due to the entries which would be extracted outside of the
+// output directory. errors.Is can be used to check it against ErrUnsafePath.
+type UnsafePathsError struct {
+	// Entries - all rejected entries.
+	Entries []UnsafeEntry
+}
+
+func (e *UnsafePathsError) Error() string {
+	report := make([]string, 0, len(e.Entries))
+	for _, u := range e.Entries {
+		report = append(report, fmt.Sprintf("%q (%s)", u.Path, u.Reason))
+	}
+
+	return fmt.Sprintf("%s, %d rejected: %s", ErrUnsafePath, len(e.Entries), strings.Join(report, ", "))
+}
+
+// Is - reports whether the target is ErrUnsafePath.
+func (e *UnsafePathsError) Is(target error) bool {
+	return errors.Is(ErrUnsafePath, target)
+}
+
+// checkEntries - returns UnsafePathsError reporting every entry whose path is absolute or refers to a parent
+// directory, or which is a link pointing outside of the output directory (or a symlink whose target isn't listed).
+// Rejected entries are logged as well.
+func checkEntries(log Logger, entries []Entry) error {
+	unsafe := make([]UnsafeEntry, 0)
+
+	for i := range entries {
+		reason := unsafeReason(&entries[i])
+		if reason == "" {
+			continue
+		}
+
+		log.Warn("rejected unsafe archive entry", "path", entries[i].Path, "reason", reason)
+		unsafe = append(unsafe, UnsafeEntry{Path: entries[i].Path, Reason: reason})
+	}
+
+	if len(unsafe) == 0 {
+		return nil
+	}
+
+	return &UnsafePathsError{Entries: unsafe}
+}
+
+// unsafeReason - returns why the entry is unsafe to extract, or an empty string if it's safe.
+// Paths are checked lexically, so symlinks are resolved relative to the directory they're archived in.
+func unsafeReason(e *Entry) string {
+	name := filepath.ToSlash(e.Path)
+
+	switch {
+	case isAbsPath(e.Path):
+		return unsafeAbsolutePath
+	case hasParentRef(name):
+		return unsafeParentDir
+	case e.Symlink == "" && isSymlinkAttributes(e.Attributes):
+		// 7z format doesn't list targets of symlinks, which are stored as their content.
+		return unsafeUnknownSymlink
+	case e.Symlink != "" && (isAbsPath(e.Symlink) || escapes(path.Join(path.Dir(name), filepath.ToSlash(e.Symlink)))):
+		return unsafeSymlink
+	case e.HardLink != "" && (isAbsPath(e.HardLink) || hasParentRef(filepath.ToSlash(e.HardLink))):
+		return unsafeHardLink
+	default:
+		return ""
+	}
+}
+
+// isSymlinkAttributes - reports whether the attributes reported by 7zip are the ones of a symlink, either by the unix
+// mode, i.e. 'A lrwxrwxrwx', or by the raw value of the attributes, i.e. '0xA1FF8020'.
+func isSymlinkAttributes(attrs string) bool {
+	for _, f := range strings.Fields(attrs) {
+		if len(f) == len("lrwxrwxrwx") && f[0] == 'l' {
+			return true
+		}
+
+		if !strings.HasPrefix(f, "0x") {
+			continue
+		}
+
+		v, err := strconv.ParseUint(f[2:], 16, 32)
+		if err == nil && v&attrUnixExtension != 0 && (v>>16)&modeTypeMask == modeTypeSymlink {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isAbsPath - reports whether the path is absolute, either on this OS or in the slash separated form.
+func isAbsPath(p string) bool {
+	return filepath.IsAbs(p) || filepath.VolumeName(p) != "" || strings.HasPrefix(filepath.ToSlash(p), "/")
+}
+
+// hasParentRef - reports whether any component of the slash separated path is '..'.
+func hasParentRef(p string) bool {
+	for _, c := range strings.Split(p, "/") {
+		if c == ".." {
+			return true
+		}
+	}
+
+	return false
+}
+
+// escapes - reports whether the cleaned, slash separated path is outside of the directory it's relative to.
+func escapes(p string) bool {
+	return p == ".." || strings.HasPrefix(p, "../")
+}
+
+// resolvePath - returns the path with symlinks of its existing parent directories resolved. Directories which don't
+// exist yet are going to be created by ax, so they can't be symlinks.
+func resolvePath(p string) (string, error) {
+	dir, rest := filepath.Dir(p), filepath.Base(p)
+
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+
+		dir, rest = parent, filepath.Join(filepath.Base(dir), rest)
+	}
+
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed resolving %s: %w", dir, err)
+	}
+
+	return filepath.Join(resolved, rest), nil
+}
+
+// ensureWithin - returns UnsafePathsError if the target, resolved by resolvePath, is outside of root, which has to be
+// resolved already. This catches entries extracted through symlinks, whether they have been extracted before or have
+// already existed in the output directory.
+func ensureWithin(root, target, entryPath string) error {
+	resolved, err := resolvePath(target)
+	if err != nil {
+		return err
+	}
+
+	if !isWithin(root, resolved) {
+		return &UnsafePathsError{Entries: []UnsafeEntry{{Path: entryPath, Reason: unsafeResolvedParent}}}
+	}
+
+	return nil
+}
+
+// ensureEntriesWithin - returns UnsafePathsError reporting every selected entry which would be extracted outside of the
+// output directory through a symlink, whether it already exists in the directory or it's extracted before the entry,
+// as well as every extracted link which would point outside of it through symlinks, once everything is extracted.
+// Extraction is simulated on an extractionTree, so that nothing is extracted unless all the entries are safe.
+// Rejected entries are logged as well.
+func ensureEntriesWithin(
+	log Logger, outDir string, policy OverwritePolicy, entries []Entry, selected func(e *Entry) bool,
+) error {
+	root, err := resolveOutputDir(outDir)
+	if err != nil {
+		return err
+	}
+
+	var (
+		tree     = newExtractionTree(root)
+		symlinks = make([]string, 0)
+		reasons  = make(map[*Entry]string)
+	)
+
+	for i := range entries {
+		e := &entries[i]
+		if !selected(e) {
+			continue
+		}
+
+		name := filepath.ToSlash(e.Path)
+
+		dir, ok := tree.resolve(path.Dir(name))
+		if !ok {
+			reasons[e] = unsafeResolvedParent
+
+			continue
+		}
+
+		if e.HardLink != "" {
+			if _, ok := tree.resolve(path.Dir(filepath.ToSlash(e.HardLink))); !ok {
+				reasons[e] = unsafeHardLink
+
+				continue
+			}
+		}
+
+		p := path.Join(dir, path.Base(name))
+		if tree.extract(p, e, policy) && e.Symlink != "" {
+			symlinks = append(symlinks, p)
+		}
+	}
+
+	// Symlinks are checked once everything is extracted, as they may point through ones extracted after them.
+	for _, p := range symlinks {
+		n := tree.nodes[p]
+		if n.symlink == "" {
+			continue
+		}
+
+		if _, ok := tree.resolve(path.Dir(p) + "/" + n.symlink); !ok {
+			reasons[n.entry] = unsafeSymlink
+		}
+	}
+
+	unsafe := make([]UnsafeEntry, 0)
+
+	for i := range entries {
+		if reason, ok := reasons[&entries[i]]; ok {
+			log.Warn("rejected unsafe archive entry", "path", entries[i].Path, "reason", reason)
+			unsafe = append(unsafe, UnsafeEntry{Path: entries[i].Path, Reason: reason})
+		}
+	}
+
+	if len(unsafe) == 0 {
+		return nil
+	}
+
+	return &UnsafePathsError{Entries: unsafe}
+}
+
+// resolveOutputDir - returns the output directory with its symlinks resolved. If it doesn't exist yet, it's going to
+// be created, so only its existing parent directories are resolved.
+func resolveOutputDir(dir string) (string, error) {
+	if _, err := os.Lstat(dir); err != nil {
+		return resolvePath(dir)
+	}
+
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed resolving output dir: %w", err)
+	}
+
+	return root, nil
+}
+
+// ensureSymlinkWithin - returns UnsafePathsError if the symlink created at the target would point outside of root,
+// once symlinks of the directory holding it, and the ones its target passes through, are resolved.
+func ensureSymlinkWithin(root, target, linkname, entryPath string) error {
+	resolved, err := resolvePath(target)
+	if err != nil {
+		return err
+	}
+
+	dir, err := filepath.Rel(root, filepath.Dir(resolved))
+	if err != nil || isAbsPath(linkname) {
+		return &UnsafePathsError{Entries: []UnsafeEntry{{Path: entryPath, Reason: unsafeSymlink}}}
+	}
+
+	if _, ok := newExtractionTree(root).resolve(filepath.ToSlash(dir) + "/" + filepath.ToSlash(linkname)); !ok {
+		return &UnsafePathsError{Entries: []UnsafeEntry{{Path: entryPath, Reason: unsafeSymlink}}}
+	}
+
+	return nil
+}
+
+// extractedNode - represents an entry extracted into an extractionTree.
+type extractedNode struct {
+	entry *Entry
+
+	// symlink - target of the symlink, or an empty string if the entry isn't one.
+	symlink string
+}
+
+// extractionTree - represents the output directory as it would be once the entries added to it are extracted, on top
+// of whatever it already holds, so that paths are resolved the way they would be by the file system, without anything
+// being written.
+type extractionTree struct {
+	// root - output directory, with its symlinks resolved.
+	root string
+
+	// nodes - extracted entries by their (slash separated) path within root, with its symlinks resolved.
+	nodes map[string]extractedNode
+}
+
+func newExtractionTree(root string) *extractionTree {
+	return &extractionTree{root: root, nodes: make(map[string]extractedNode)}
+}
+
+// extract - adds the entry at the path (see resolve) to the tree, as it would be extracted under the overwrite policy,
+// returning false if it wouldn't replace what's already at the path. Directories are merged into whatever is there.
+func (t *extractionTree) extract(p string, e *Entry, policy OverwritePolicy) bool {
+	if p == "" || (t.exists(p) && (e.IsDir || policy != OverwriteAll)) {
+		return false
+	}
+
+	t.nodes[p] = extractedNode{entry: e, symlink: filepath.ToSlash(e.Symlink)}
+
+	return true
+}
+
+// exists - reports whether anything is at the path, either extracted or already existing.
+func (t *extractionTree) exists(p string) bool {
+	if _, ok := t.nodes[p]; ok {
+		return true
+	}
+
+	_, err := os.Lstat(filepath.Join(t.root, filepath.FromSlash(p)))
+
+	return err == nil
+}
+
+// symlink - returns the (slash separated) target of the symlink at the path, if there is one, either extracted or
+// already existing.
+func (t *extractionTree) symlink(p string) (string, bool) {
+	if n, ok := t.nodes[p]; ok {
+		return n.symlink, n.symlink != ""
+	}
+
+	target, err := os.Readlink(filepath.Join(t.root, filepath.FromSlash(p)))
+	if err != nil {
+		return "", false
+	}
+
+	return filepath.ToSlash(target), true
+}
+
+// resolve - returns the slash separated path, relative to root, with its symlinks resolved one component after
+// another, just like the file system does, so that '..' following a symlink refers to the parent of its target.
+// False is returned if the path resolves outside of root, or through too many symlinks.
+func (t *extractionTree) resolve(p string) (string, bool) {
+	var (
+		resolved = make([]string, 0)
+		rest     = strings.Split(p, "/")
+		followed = 0
+	)
+
+	for len(rest) > 0 {
+		c := rest[0]
+		rest = rest[1:]
+
+		switch c {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return "", false
+			}
+
+			resolved = resolved[:len(resolved)-1]
+
+			continue
+		}
+
+		next := path.Join(path.Join(resolved...), c)
+
+		target, ok := t.symlink(next)
+		if !ok {
+			resolved = append(resolved, c)
+
+			continue
+		}
+
+		followed++
+		if followed > maxSymlinks {
+			return "", false
+		}
+
+		if isAbsPath(target) {
+			// Only symlinks which already exist may be absolute, as extracted ones are rejected by unsafeReason.
+			rel, err := filepath.Rel(t.root, filepath.Clean(filepath.FromSlash(target)))
+			if err != nil || !isWithin(t.root, filepath.FromSlash(target)) {
+				return "", false
+			}
+
+			resolved, target = resolved[:0], filepath.ToSlash(rel)
+		}
+
+		rest = append(strings.Split(target, "/"), rest...)
+	}
+
+	return path.Join(resolved...), true
+}
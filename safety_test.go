@@ -0,0 +1,306 @@
+package ax
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testPathSymlinkEscape7z - 7z archive holding the symlink 'escape -> ../outside', followed by 'escape/evil'.
+const testPathSymlinkEscape7z = "./tests/unsafe/symlink-escape.7z"
+
+func (s *Suite) TestUnitUnsafeReason() {
+	testCases := []TestCase{
+		{
+			Name: "success safe entries",
+			Assert: func() {
+				for _, e := range []Entry{
+					{Path: "etc/hosts"},
+					{Path: "etc/..hidden"},
+					{Path: "etc/nginx/current", Symlink: "../nginx-1.20"},
+					{Path: "etc/hosts.bak", HardLink: "etc/hosts"},
+					{Path: "etc/hosts", Attributes: "A -rw-r--r--"},
+					{Path: "etc/nginx/current", Attributes: "A lrwxrwxrwx", Symlink: "../nginx-1.20"},
+				} {
+					assert.Empty(s.T(), unsafeReason(&e), e.Path)
+				}
+			},
+		},
+		{
+			Name: "success unsafe entries",
+			Assert: func() {
+				for reason, e := range map[string]Entry{
+					unsafeAbsolutePath: {Path: "/etc/cron.d/evil"},
+					unsafeParentDir:    {Path: "etc/../../evil"},
+					unsafeSymlink:      {Path: "etc/passwd", Symlink: "/etc/passwd"},
+					unsafeHardLink:     {Path: "etc/shadow", HardLink: "../shadow"},
+				} {
+					assert.Equal(s.T(), reason, unsafeReason(&e), e.Path)
+				}
+
+				e := Entry{Path: "etc/ssh", Symlink: "../../root/.ssh"}
+				assert.Equal(s.T(), unsafeSymlink, unsafeReason(&e))
+
+				for _, attrs := range []string{"A lrwxrwxrwx", "A_ lrwxrwxrwx 0x8000", "0xA1FF8020"} {
+					e = Entry{Path: "a", Attributes: attrs}
+					assert.Equal(s.T(), unsafeUnknownSymlink, unsafeReason(&e), attrs)
+				}
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitEnsureEntriesWithin() {
+	var outDir, outsideDir string
+
+	prepare := func() {
+		parent := s.T().TempDir()
+		outDir, outsideDir = filepath.Join(parent, "out"), filepath.Join(parent, "outside")
+
+		s.Require().Nil(os.Mkdir(outsideDir, 0o700))
+	}
+
+	entries := []Entry{{Path: "etc", IsDir: true}, {Path: "etc/evil"}, {Path: "etc/sub/evil"}, {Path: "hosts"}}
+	all := func(*Entry) bool { return true }
+
+	testCases := []TestCase{
+		{
+			Name:          "success output doesn't exist yet",
+			PreRequisites: prepare,
+			Assert: func() {
+				assert.Nil(s.T(), ensureEntriesWithin(nopLogger{}, outDir, OverwriteFail, entries, all))
+			},
+		},
+		{
+			Name: "success output is a symlink itself",
+			PreRequisites: func() {
+				prepare()
+
+				s.Require().Nil(os.Symlink(outsideDir, outDir))
+			},
+			Assert: func() {
+				assert.Nil(s.T(), ensureEntriesWithin(nopLogger{}, outDir, OverwriteFail, entries, all))
+			},
+		},
+		{
+			Name: "err symlink already existing in the output",
+			PreRequisites: func() {
+				prepare()
+
+				s.Require().Nil(os.Mkdir(outDir, 0o700))
+				s.Require().Nil(os.Symlink(outsideDir, filepath.Join(outDir, "etc")))
+			},
+			Assert: func() {
+				err := ensureEntriesWithin(nopLogger{}, outDir, OverwriteFail, entries, all)
+
+				var unsafeErr *UnsafePathsError
+
+				s.Require().True(errors.As(err, &unsafeErr), err)
+				assert.Equal(s.T(), []UnsafeEntry{
+					{Path: "etc/evil", Reason: unsafeResolvedParent},
+					{Path: "etc/sub/evil", Reason: unsafeResolvedParent},
+				}, unsafeErr.Entries)
+
+				onlyHosts := func(e *Entry) bool { return e.Path == "hosts" }
+				assert.Nil(s.T(), ensureEntriesWithin(nopLogger{}, outDir, OverwriteFail, entries, onlyHosts))
+			},
+		},
+		{
+			Name: "err symlink already existing in the output is kept, unless it's overwritten",
+			PreRequisites: func() {
+				prepare()
+
+				s.Require().Nil(os.Mkdir(outDir, 0o700))
+				s.Require().Nil(os.Symlink(outsideDir, filepath.Join(outDir, "hosts")))
+			},
+			Assert: func() {
+				replaced := []Entry{{Path: "hosts", IsDir: true}, {Path: "hosts", Symlink: "."}, {Path: "hosts/evil"}}
+
+				err := ensureEntriesWithin(nopLogger{}, outDir, OverwriteSkip, replaced, all)
+
+				var unsafeErr *UnsafePathsError
+
+				s.Require().True(errors.As(err, &unsafeErr), err)
+				assert.Equal(s.T(), []UnsafeEntry{{Path: "hosts/evil", Reason: unsafeResolvedParent}}, unsafeErr.Entries)
+
+				assert.Nil(s.T(), ensureEntriesWithin(nopLogger{}, outDir, OverwriteAll, replaced, all))
+			},
+		},
+		{
+			Name:          "err symlink loop",
+			PreRequisites: prepare,
+			Assert: func() {
+				loop := []Entry{{Path: "a", Symlink: "b"}, {Path: "b", Symlink: "a"}, {Path: "c", Symlink: "a/x"}}
+
+				err := ensureEntriesWithin(nopLogger{}, outDir, OverwriteFail, loop, all)
+
+				var unsafeErr *UnsafePathsError
+
+				s.Require().True(errors.As(err, &unsafeErr), err)
+				assert.Equal(s.T(), []UnsafeEntry{
+					{Path: "a", Reason: unsafeSymlink}, {Path: "b", Reason: unsafeSymlink}, {Path: "c", Reason: unsafeSymlink},
+				}, unsafeErr.Entries)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitExtractUnsafePaths() {
+	var archiveDir, outDir, outsideDir string
+
+	prepare := func(files ...string) func() {
+		return func() {
+			parent := s.T().TempDir()
+			archiveDir, outDir, outsideDir = s.T().TempDir(), filepath.Join(parent, "out"), filepath.Join(parent, "outside")
+
+			s.Require().Nil(os.Mkdir(outsideDir, 0o700))
+			s.Require().Nil(os.WriteFile(filepath.Join(archiveDir, "evil.tar.gz"), s.testTarball(true, files...), 0o600))
+		}
+	}
+
+	// prepare7z - copies the archive, created by 7zip out of the malicious entries, as the one which is extracted.
+	prepare7z := func(fixture string) func() {
+		return func() {
+			if _, err := exec.LookPath(sevenZipCmd()); err != nil {
+				s.T().Skip("7zip isn't installed")
+			}
+
+			prepare()()
+
+			raw, err := os.ReadFile(fixture)
+			s.Require().Nil(err)
+			s.Require().Nil(os.Remove(filepath.Join(archiveDir, "evil.tar.gz")))
+			s.Require().Nil(os.WriteFile(filepath.Join(archiveDir, filepath.Base(fixture)), raw, 0o600))
+		}
+	}
+
+	extract := func(allowUnsafe bool) error {
+		return Extract(&ExtractConfig{ArchivePath: archiveDir, OutputDir: outDir, AllowUnsafePaths: allowUnsafe})
+	}
+
+	assertRejected := func(err error, paths ...string) {
+		var unsafeErr *UnsafePathsError
+
+		s.Require().True(errors.As(err, &unsafeErr), err)
+		assert.ErrorIs(s.T(), err, ErrUnsafePath)
+
+		rejected := make([]string, 0)
+		for _, u := range unsafeErr.Entries {
+			rejected = append(rejected, u.Path)
+		}
+
+		assert.Equal(s.T(), paths, rejected)
+	}
+
+	assertOutsideEmpty := func() {
+		entries, err := os.ReadDir(outsideDir)
+		s.Require().Nil(err)
+		assert.Empty(s.T(), entries)
+	}
+
+	testCases := []TestCase{
+		{
+			Name:          "err traversal and absolute paths are all reported, nothing extracted",
+			PreRequisites: prepare("etc/hosts", "../outside/evil", "/tmp/evil", "etc/../../outside/evil"),
+			Assert: func() {
+				assertRejected(extract(false), "../outside/evil", "/tmp/evil", "etc/../../outside/evil")
+				assert.NoDirExists(s.T(), outDir)
+				assertOutsideEmpty()
+			},
+		},
+		{
+			Name:          "err symlink pointing outside",
+			PreRequisites: prepare("etc/", "etc/escape -> ../../outside", "etc/escape/evil"),
+			Assert: func() {
+				assertRejected(extract(false), "etc/escape")
+				assertOutsideEmpty()
+			},
+		},
+		{
+			Name:          "err hard link pointing outside",
+			PreRequisites: prepare("etc/passwd => ../outside/passwd"),
+			Assert: func() {
+				assertRejected(extract(false), "etc/passwd")
+			},
+		},
+		{
+			Name:          "err symlink chain resolving outside",
+			PreRequisites: prepare("root -> .", "root/escape -> ../outside", "escape/evil"),
+			Assert: func() {
+				assertRejected(extract(false), "root/escape", "escape/evil")
+				assertOutsideEmpty()
+			},
+		},
+		{
+			Name:          "err symlink resolving outside through another one, nothing extracted",
+			PreRequisites: prepare("p -> .", "q -> p/..", "q/outside/evil"),
+			Assert: func() {
+				assertRejected(extract(false), "q", "q/outside/evil")
+				assert.NoDirExists(s.T(), outDir)
+				assertOutsideEmpty()
+			},
+		},
+		{
+			Name:          "err symlink resolving outside through one extracted after it, nothing extracted",
+			PreRequisites: prepare("etc/hosts", "q -> p/..", "p -> ."),
+			Assert: func() {
+				assertRejected(extract(false), "q")
+				assert.NoDirExists(s.T(), outDir)
+			},
+		},
+		{
+			Name: "err symlink already existing in the output",
+			PreRequisites: func() {
+				prepare("etc/", "etc/evil")()
+
+				s.Require().Nil(os.Mkdir(outDir, 0o700))
+				s.Require().Nil(os.Symlink(outsideDir, filepath.Join(outDir, "etc")))
+			},
+			Assert: func() {
+				assertRejected(extract(false), "etc/evil")
+				assertOutsideEmpty()
+			},
+		},
+		{
+			Name:          "err 7z symlink, whose target isn't listed, followed by an entry within it",
+			PreRequisites: prepare7z(testPathSymlinkEscape7z),
+			Assert: func() {
+				assertRejected(extract(false), "escape")
+				assert.NoDirExists(s.T(), outDir)
+				assertOutsideEmpty()
+			},
+		},
+		{
+			Name:          "success symlink within the output",
+			PreRequisites: prepare("etc/nginx-1.20/nginx.conf", "etc/nginx -> nginx-1.20"),
+			Assert: func() {
+				assert.Nil(s.T(), extract(false))
+
+				link, err := os.Readlink(filepath.Join(outDir, "etc", "nginx"))
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), "nginx-1.20", link)
+				assert.FileExists(s.T(), filepath.Join(outDir, "etc", "nginx", "nginx.conf"))
+			},
+		},
+		{
+			Name:          "success unsafe paths allowed",
+			PreRequisites: prepare("etc/hosts", "etc/escape -> ../../outside"),
+			Assert: func() {
+				assert.Nil(s.T(), extract(true))
+
+				link, err := os.Readlink(filepath.Join(outDir, "etc", "escape"))
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), "../../outside", link)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
@@ -0,0 +1,131 @@
+package ax
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSize - size isn't a non-negative number of bytes, optionally followed by one of the b, k, m or g units.
+var ErrInvalidSize = errors.New("invalid size")
+
+// sizeUnits - units of sizes, from the largest one, along with their value in bytes. Units are binary, as in 7zip.
+func sizeUnits() []struct {
+	unit  BlockSize
+	bytes uint64
+} {
+	return []struct {
+		unit  BlockSize
+		bytes uint64
+	}{
+		{BlockSizeGB, 1 << 30},
+		{BlockSizeMB, 1 << 20},
+		{BlockSizeKB, 1 << 10},
+		{BlockSizeByte, 1},
+	}
+}
+
+// unitBytes - returns the value of the unit in bytes, or 0 if it's not one of b, k, m or g.
+func unitBytes(unit BlockSize) uint64 {
+	for _, u := range sizeUnits() {
+		if u.unit == unit {
+			return u.bytes
+		}
+	}
+
+	return 0
+}
+
+// ParseSize - parses the human readable size into bytes, i.e. '1.5g', '256m', '512KiB' or '4096'. A number without
+// a unit is taken in the chosen one, or in bytes if it's empty. Units are binary, so '1k' is 1024 bytes.
+func ParseSize(s string, unit BlockSize) (uint64, error) {
+	num := strings.ToLower(strings.TrimSpace(s))
+
+	// Allows the 'kb' and 'kib' forms, while '100b' stays in bytes.
+	if strings.HasSuffix(num, "ib") {
+		num = strings.TrimSuffix(num, "ib")
+	} else if len(num) > 1 && num[len(num)-1] == 'b' && strings.ContainsRune("kmg", rune(num[len(num)-2])) {
+		num = num[:len(num)-1]
+	}
+
+	if num != "" && unitBytes(BlockSize(num[len(num)-1:])) != 0 {
+		unit, num = BlockSize(num[len(num)-1:]), num[:len(num)-1]
+	}
+
+	if num == "" || strings.Trim(num, "0123456789.") != "" || strings.Count(num, ".") > 1 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, s)
+	}
+
+	multiplier := uint64(1)
+	if unit != "" {
+		multiplier = unitBytes(unit)
+	}
+
+	if multiplier == 0 {
+		return 0, fmt.Errorf("%w: %q has unknown unit %q", ErrInvalidSize, s, unit)
+	}
+
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil || (n != 0 && n > math.MaxUint64/multiplier) {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidSize, s)
+		}
+
+		return n * multiplier, nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f*float64(multiplier) >= math.MaxUint64 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, s)
+	}
+
+	size := f * float64(multiplier)
+	if size != math.Trunc(size) {
+		return 0, fmt.Errorf("%w: %q isn't a whole number of bytes", ErrInvalidSize, s)
+	}
+
+	return uint64(size), nil
+}
+
+// splitSize - returns the size in bytes as a number in the largest unit which represents it exactly.
+func splitSize(size uint64) (uint64, BlockSize) {
+	for _, u := range sizeUnits() {
+		if size%u.bytes == 0 {
+			return size / u.bytes, u.unit
+		}
+	}
+
+	return size, BlockSizeByte
+}
+
+// formatSize - returns the size in bytes as 7zip expects it, i.e. '1536m' for 1.5 GB.
+func formatSize(size uint64) string {
+	n, unit := splitSize(size)
+
+	return fmt.Sprintf("%d%s", n, unit)
+}
+
+// SetVolumeSize - sets VolumeSize and BlockSize, so that volumes are the chosen number of bytes large.
+// Zero disables splitting into volumes.
+func (ac *ArchiveConfig) SetVolumeSize(size uint64) {
+	if size == 0 {
+		ac.VolumeSize = 0
+
+		return
+	}
+
+	ac.VolumeSize, ac.BlockSize = splitSize(size)
+}
+
+// HumanSize - returns the size in bytes in the largest unit it's at least one of, i.e. '1.5 GiB'.
+func HumanSize(size uint64) string {
+	for _, u := range sizeUnits() {
+		if size >= u.bytes && u.unit != BlockSizeByte {
+			return fmt.Sprintf("%.1f %siB", float64(size)/float64(u.bytes), strings.ToUpper(string(u.unit)))
+		}
+	}
+
+	return fmt.Sprintf("%d B", size)
+}
@@ -0,0 +1,85 @@
+package ax
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitParseSize() {
+	testCases := []TestCase{
+		{
+			Name: "success human readable sizes",
+			Assert: func() {
+				for in, expected := range map[string]uint64{
+					"0":      0,
+					"4096":   4096,
+					"100b":   100,
+					"512k":   512 << 10,
+					"256m":   256 << 20,
+					"256MB":  256 << 20,
+					"1.5g":   3 << 29,
+					"1.5GiB": 3 << 29,
+					" 2G ":   2 << 30,
+					"0.5k":   512,
+				} {
+					size, err := ParseSize(in, "")
+
+					assert.Nil(s.T(), err, in)
+					assert.Equal(s.T(), expected, size, in)
+				}
+			},
+		},
+		{
+			Name: "success number without a unit is in the chosen one",
+			Assert: func() {
+				size, err := ParseSize("90", BlockSizeMB)
+
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), uint64(90<<20), size)
+
+				size, err = ParseSize("2k", BlockSizeMB)
+
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), uint64(2048), size)
+			},
+		},
+		{
+			Name: "err invalid sizes",
+			Assert: func() {
+				for _, in := range []string{"", "m", "-1m", "1.5", "1.2.3m", "1e3", "10t", "inf", "0x10", "1.0000001b"} {
+					_, err := ParseSize(in, "")
+
+					assert.ErrorIs(s.T(), err, ErrInvalidSize, in)
+				}
+
+				_, err := ParseSize("17179869184g", "")
+				assert.ErrorIs(s.T(), err, ErrInvalidSize)
+
+				_, err = ParseSize("10", "t")
+				assert.ErrorIs(s.T(), err, ErrInvalidSize)
+			},
+		},
+		{
+			Name: "success volume size is set in the largest exact unit",
+			Assert: func() {
+				ac := NewDefaultArchiveConfig()
+
+				for size, expected := range map[uint64]string{
+					3 << 29:   "1536m",
+					2 << 30:   "2g",
+					100 << 10: "100k",
+					1000:      "1000b",
+				} {
+					ac.SetVolumeSize(size)
+
+					assert.Equal(s.T(), expected, formatSize(size))
+					assert.Equal(s.T(), size, ac.VolumeSize*unitBytes(ac.BlockSize))
+				}
+
+				ac.SetVolumeSize(0)
+				assert.Zero(s.T(), ac.VolumeSize)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
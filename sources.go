@@ -0,0 +1,192 @@
+package ax
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrSourceOutsideRoot - source isn't within the Root, relative to which it should be stored.
+	ErrSourceOutsideRoot = errors.New("source is outside of the root")
+
+	// ErrNothingToArchive - sources hold no files which are left in by the include/exclude patterns.
+	ErrNothingToArchive = errors.New("nothing to archive")
+)
+
+// sources - returns every path which should be archived, PathToArchive first.
+func (pc *PathConfig) sources() []string {
+	sources := make([]string, 0, len(pc.Sources)+1)
+
+	if pc.PathToArchive != "" {
+		sources = append(sources, pc.PathToArchive)
+	}
+
+	return append(sources, pc.Sources...)
+}
+
+// absSources - returns absolute paths of the sources, and the absolute root the sources are stored relative to.
+//
+// Unless Root has been set, it's the deepest directory holding all of the sources, i.e. for a single source it's its
+// parent directory, so that the source is stored under its own name.
+func (pc *PathConfig) absSources() ([]string, string, error) {
+	sources := pc.sources()
+	if len(sources) == 0 {
+		return nil, "", ErrPathEmpty
+	}
+
+	abs := make([]string, len(sources))
+
+	for i, src := range sources {
+		a, err := filepath.Abs(src)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed resolving source path: %s: %w", src, err)
+		}
+
+		abs[i] = a
+	}
+
+	if pc.Root != "" {
+		root, err := filepath.Abs(pc.Root)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed resolving root path: %s: %w", pc.Root, err)
+		}
+
+		for _, src := range abs {
+			if !isWithin(root, src) {
+				return nil, "", fmt.Errorf("%w: %s is not within %s", ErrSourceOutsideRoot, src, root)
+			}
+		}
+
+		return abs, root, nil
+	}
+
+	root := filepath.Dir(abs[0])
+
+	for _, src := range abs[1:] {
+		for !isWithin(root, filepath.Dir(src)) {
+			root = filepath.Dir(root)
+		}
+	}
+
+	return abs, root, nil
+}
+
+// isWithin - reports whether the path is the root itself, or anything below it.
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// listArchiveEntries - returns the root, and entries relative to it, which are passed to 7zip.
+//
+// Directory sources are walked, leaving out whatever their IgnoreFileName and include/exclude patterns match, while file
+// sources are always archived. Empty directories are listed (with a trailing separator), as they wouldn't be archived
+// otherwise.
+func listArchiveEntries(conf *ArchiveConfig) (string, []string, error) {
+	sources, root, err := conf.absSources()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		entries = make([]string, 0)
+		seen    = make(map[string]bool)
+	)
+
+	add := func(path string, isDir bool) error {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf("failed resolving path: %s: %w", path, err)
+		}
+
+		if isDir {
+			rel += string(os.PathSeparator)
+		}
+
+		if rel != "."+string(os.PathSeparator) && !seen[rel] {
+			seen[rel] = true
+			entries = append(entries, rel)
+		}
+
+		return nil
+	}
+
+	for _, src := range sources {
+		stat, err := os.Stat(src)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed getting path stat: %w", err)
+		}
+
+		if !stat.IsDir() {
+			err = add(src, false)
+		} else {
+			err = walkSource(src, conf.Include, conf.Exclude, add)
+		}
+
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	if len(entries) == 0 {
+		return "", nil, ErrNothingToArchive
+	}
+
+	return root, entries, nil
+}
+
+// walkSource - walks the directory source, passing each file and empty directory left in by its Filter to `add`.
+func walkSource(src string, include, exclude []string, add func(path string, isDir bool) error) error {
+	filter, err := NewFilter(src, include, exclude)
+	if err != nil {
+		return err
+	}
+
+	walkFn := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk initiated with an error: %w", err)
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return fmt.Errorf("failed resolving path: %s: %w", path, err)
+		}
+
+		if rel != "." && filter.Excluded(rel, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !info.IsDir() {
+			return add(path, false)
+		}
+
+		dirEntries, err := os.ReadDir(path)
+		if err != nil {
+			return fmt.Errorf("failed reading dir: %s: %w", path, err)
+		}
+
+		if len(dirEntries) == 0 {
+			return add(path, true)
+		}
+
+		return nil
+	}
+
+	err = filepath.Walk(src, walkFn)
+	if err != nil {
+		return fmt.Errorf("failed walking path: %s with error: %w", src, err)
+	}
+
+	return nil
+}
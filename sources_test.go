@@ -0,0 +1,134 @@
+package ax
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitListArchiveEntries() {
+	var root string
+
+	testCases := []TestCase{
+		{
+			Name: "success single directory stored under its name",
+			PreRequisites: func() {
+				root = s.writeTree("nginx/nginx.conf", "nginx/sites/default")
+				s.ac = &ArchiveConfig{PathConfig: PathConfig{PathToArchive: filepath.Join(root, "nginx")}}
+			},
+			Assert: func() {
+				gotRoot, entries, err := listArchiveEntries(s.ac)
+
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), root, gotRoot)
+				assert.ElementsMatch(s.T(), []string{
+					filepath.Join("nginx", "nginx.conf"), filepath.Join("nginx", "sites", "default"),
+				}, entries)
+			},
+		},
+		{
+			Name: "success mixed sources stored relative to their common dir",
+			PreRequisites: func() {
+				root = s.writeTree("etc/nginx/nginx.conf", "home/user/.ssh/config", "dump.sql")
+				s.ac = &ArchiveConfig{PathConfig: PathConfig{
+					Sources: []string{
+						filepath.Join(root, "etc", "nginx"),
+						filepath.Join(root, "home", "user", ".ssh", "config"),
+						filepath.Join(root, "dump.sql"),
+						// Sources overlapping with the previous ones are stored just once.
+						filepath.Join(root, "etc", "nginx", "nginx.conf"),
+					},
+				}}
+			},
+			Assert: func() {
+				gotRoot, entries, err := listArchiveEntries(s.ac)
+
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), root, gotRoot)
+				assert.Equal(s.T(), []string{
+					filepath.Join("etc", "nginx", "nginx.conf"),
+					filepath.Join("home", "user", ".ssh", "config"),
+					"dump.sql",
+				}, entries)
+			},
+		},
+		{
+			Name: "success stored relative to the configured root, with empty dirs",
+			PreRequisites: func() {
+				root = s.writeTree("project/main.go")
+				assert.Nil(s.T(), os.MkdirAll(filepath.Join(root, "project", "empty"), os.ModePerm))
+
+				s.ac = &ArchiveConfig{PathConfig: PathConfig{
+					PathToArchive: filepath.Join(root, "project"),
+					Root:          filepath.Join(root, "project"),
+				}}
+			},
+			Assert: func() {
+				_, entries, err := listArchiveEntries(s.ac)
+
+				assert.Nil(s.T(), err)
+				assert.ElementsMatch(s.T(), []string{"main.go", "empty" + string(os.PathSeparator)}, entries)
+			},
+		},
+		{
+			Name: "err source outside of the root",
+			PreRequisites: func() {
+				root = s.writeTree("a/file", "b/file")
+				s.ac = &ArchiveConfig{PathConfig: PathConfig{
+					Sources: []string{filepath.Join(root, "a"), filepath.Join(root, "b")},
+					Root:    filepath.Join(root, "a"),
+				}}
+			},
+			Assert: func() {
+				_, _, err := listArchiveEntries(s.ac)
+
+				assert.ErrorIs(s.T(), err, ErrSourceOutsideRoot)
+			},
+		},
+		{
+			Name: "err nothing to archive",
+			PreRequisites: func() {
+				root = s.writeTree("a/file.log")
+				s.ac = &ArchiveConfig{
+					PathConfig: PathConfig{PathToArchive: filepath.Join(root, "a")},
+					Exclude:    []string{"*.log"},
+				}
+			},
+			Assert: func() {
+				_, _, err := listArchiveEntries(s.ac)
+
+				assert.ErrorIs(s.T(), err, ErrNothingToArchive)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitCmdArgsArchive() {
+	testCases := []TestCase{
+		{
+			Name: "success args hold spaces as they are",
+			PreRequisites: func() {
+				ac := NewDefaultArchiveConfig()
+				ac.Password = []byte("pass with spaces")
+				ac.ApplyPassword = true
+				ac.OutputPath = "/tmp/out dir"
+				ac.NewArchiveName = "backup"
+				s.ac = &ac
+			},
+			Assert: func() {
+				args, err := cmdArgsArchive(s.ac, "@/tmp/list.txt")
+
+				assert.Nil(s.T(), err)
+				assert.Equal(s.T(), []string{
+					"a", "-mhe=on", "-ppass with spaces", "-t7z", "-mx=9", "-mfb=64", "-md=64m", "-v90m", "-ms=on",
+					"-scsUTF-8", filepath.Join("/tmp/out dir", "backup.7z"), "@/tmp/list.txt",
+				}, args)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
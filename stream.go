@@ -0,0 +1,474 @@
+package ax
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// streamExt - extension of the archive(s) written by StreamArchive, followed by the volume suffix if they're split.
+	streamExt = ".tar.gz.ax"
+
+	// streamVolumeFormat - format of the volume suffix, which is the same as the one 7zip appends.
+	streamVolumeFormat = "%s.%03d"
+
+	// streamSaltSize & streamCheckSize - sizes of the random salt the keys of the stream are derived with, and of the
+	// value checking the password, which follow streamMagic in the header.
+	streamSaltSize  = 16
+	streamCheckSize = 16
+
+	// streamChunkSize - size of the content sealed at once, see streamSealer.
+	streamChunkSize = 64 << 10
+
+	// Labels the keys of the stream are derived with, see streamKeys.
+	streamKeyLabel   = "ax stream key"
+	streamCheckLabel = "ax stream check"
+)
+
+// streamMagic - bytes the archive(s) written by StreamArchive start with, followed by the salt and the password check.
+//
+//nolint:gochecknoglobals // Read-only, a slice can't be a constant.
+var streamMagic = []byte{'A', 'X', 'S', 'T', 'R', 'E', 'A', 'M', 0x02}
+
+// ErrEncryptionPassword - encryption password is required to read the archive(s) written by StreamArchive.
+var ErrEncryptionPassword = errors.New("encryption password is required")
+
+// StreamArchive - archives the sources just as Archive does, but the archive is never written to disk unencrypted.
+//
+// Sources are written as a gzip compressed PAX tarball (so their metadata is always preserved, see PreserveMetadata),
+// which is encrypted and authenticated with AES-256-GCM, chunk by chunk, by the key derived from passwd, and split into
+// volumes of VolumeSize on the fly, i.e. 'name.tar.gz.ax.001'. Compression is used as the gzip level, while ArchiveType, Method and the
+// 7zip password are left out, as 7zip isn't used at all. Paths of the written volume(s) are returned.
+//
+// The volume(s) are read back by Extract, List and TestArchive with ExtractConfig.EncryptionPassword, which decrypt
+// them as a stream too, so neither archiving nor extracting needs disk space for more than the encrypted volume(s).
+// Nothing is decrypted before it's authenticated, and Extract reads the whole stream before extracting any of it, so
+// volume(s) which have been modified, reordered or cut off are rejected with ErrIntegrity.
+func StreamArchive(conf *ArchiveConfig, passwd []byte) ([]string, error) {
+	return StreamArchiveWithContext(context.Background(), conf, passwd)
+}
+
+// StreamArchiveWithContext - same as StreamArchive, but it stops once the context is done, returning its error. Volumes
+// written so far are removed then, just as on any other failure.
+func StreamArchiveWithContext(ctx context.Context, conf *ArchiveConfig, passwd []byte) ([]string, error) {
+	started := time.Now()
+
+	err := validatePathToArchive(conf)
+	if err != nil {
+		return nil, fmt.Errorf("path validation issue: %w", err)
+	}
+
+	if len(passwd) == 0 {
+		return nil, ErrEncryptionPassword
+	}
+
+	log := loggerOr(conf.Logger)
+
+	root, entries, err := listArchiveEntries(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	vw := &volumeWriter{ctx: ctx, base: streamBase(conf), size: streamVolumeSize(conf)}
+
+	err = writeStream(log, vw, conf, passwd, root, entries)
+	if closeErr := vw.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		// Volumes are encrypted, so removing them is enough.
+		for _, vol := range vw.volumes {
+			_ = os.Remove(vol)
+		}
+
+		return nil, err
+	}
+
+	// Volumes left by a previous, larger, archive of the same name would be read as its continuation.
+	for i := len(vw.volumes) + 1; vw.size != 0; i++ {
+		if os.Remove(fmt.Sprintf(streamVolumeFormat, vw.base, i)) != nil {
+			break
+		}
+	}
+
+	bytesIn, _ := entriesSize(root, entries)
+
+	log.Info(EventStreamed, "sources", conf.sources(), AttrVolumes, len(vw.volumes), AttrDuration, time.Since(started),
+		AttrBytesIn, bytesIn, AttrBytesOut, filesSize(vw.volumes))
+
+	return vw.volumes, nil
+}
+
+// writeStream - writes the header, followed by the sealed chunks of the compressed tarball of the entries.
+func writeStream(log Logger, w io.Writer, conf *ArchiveConfig, passwd []byte, root string, entries []string) error {
+	salt := make([]byte, streamSaltSize)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return fmt.Errorf("failed generating salt: %w", err)
+	}
+
+	aead, check, err := streamKeys(passwd, salt)
+	if err != nil {
+		return err
+	}
+
+	header := append(append(append([]byte{}, streamMagic...), salt...), check...)
+
+	_, err = w.Write(header)
+	if err != nil {
+		return fmt.Errorf("failed writing stream header: %w", err)
+	}
+
+	level := gzip.DefaultCompression
+	if conf.Compression != 0 {
+		level = int(conf.Compression)
+	}
+
+	sealer := newStreamSealer(w, aead, header)
+
+	gw, err := gzip.NewWriterLevel(sealer, level)
+	if err != nil {
+		return fmt.Errorf("failed creating gzip writer: %w", err)
+	}
+
+	err = writeTar(log, gw, root, entries)
+	if err != nil {
+		return err
+	}
+
+	err = gw.Close()
+	if err != nil {
+		return fmt.Errorf("failed closing gzip writer: %w", err)
+	}
+
+	return sealer.Close()
+}
+
+// decryptStream - reads the header of the stream, returning the reader of its decrypted content, which fails with
+// ErrIntegrity as soon as a chunk of it can't be authenticated.
+func decryptStream(r io.Reader, passwd []byte) (io.Reader, error) {
+	if len(passwd) == 0 {
+		return nil, ErrEncryptionPassword
+	}
+
+	header := make([]byte, len(streamMagic)+streamSaltSize+streamCheckSize)
+
+	_, err := io.ReadFull(r, header)
+	if err != nil || !bytes.HasPrefix(header, streamMagic) {
+		return nil, fmt.Errorf("%w: invalid stream header", ErrIntegrity)
+	}
+
+	salt := header[len(streamMagic) : len(streamMagic)+streamSaltSize]
+
+	aead, check, err := streamKeys(passwd, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal(check, header[len(streamMagic)+streamSaltSize:]) {
+		return nil, fmt.Errorf("%w: encryption password doesn't match the stream", ErrWrongPassword)
+	}
+
+	return newStreamOpener(r, aead, header), nil
+}
+
+// streamKeys - returns AES-256-GCM sealing the chunks of the stream, and the value checking the password, both
+// derived by HMAC-SHA256 from the salt and the key of DefaultFileEncryption.
+func streamKeys(passwd, salt []byte) (cipher.AEAD, []byte, error) {
+	key := sha256.Sum256(passwd)
+
+	derive := func(label string) []byte {
+		mac := hmac.New(sha256.New, key[:])
+		_, _ = mac.Write([]byte(label))
+		_, _ = mac.Write(salt)
+
+		return mac.Sum(nil)
+	}
+
+	block, err := aes.NewCipher(derive(streamKeyLabel))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed creating cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed creating cipher: %w", err)
+	}
+
+	return aead, derive(streamCheckLabel)[:streamCheckSize], nil
+}
+
+// streamNonce - returns nonce of the chunk, which holds its index and whether it's the last one, so that chunks can't
+// be reordered or cut off unnoticed. Keys are unique to each stream, thanks to the salt, so nonces are never reused.
+func streamNonce(aead cipher.AEAD, index uint64, last bool) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce, index)
+
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+
+	return nonce
+}
+
+// streamSealer - seals what's written into chunks of streamChunkSize, each authenticated along with the header of the
+// stream. The last chunk, which may be empty, is written by Close.
+type streamSealer struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+
+	buf   []byte
+	index uint64
+}
+
+func newStreamSealer(w io.Writer, aead cipher.AEAD, header []byte) *streamSealer {
+	return &streamSealer{w: w, aead: aead, header: header, buf: make([]byte, 0, streamChunkSize)}
+}
+
+func (s *streamSealer) Write(p []byte) (int, error) {
+	n := 0
+
+	for len(p) > 0 {
+		if len(s.buf) == streamChunkSize {
+			err := s.seal(false)
+			if err != nil {
+				return n, err
+			}
+		}
+
+		written := copy(s.buf[len(s.buf):streamChunkSize], p)
+		s.buf = s.buf[:len(s.buf)+written]
+		n += written
+		p = p[written:]
+	}
+
+	return n, nil
+}
+
+// Close - seals the last chunk.
+func (s *streamSealer) Close() error {
+	return s.seal(true)
+}
+
+func (s *streamSealer) seal(last bool) error {
+	sealed := s.aead.Seal(nil, streamNonce(s.aead, s.index, last), s.buf, s.header)
+
+	_, err := s.w.Write(sealed)
+	if err != nil {
+		return fmt.Errorf("failed writing stream: %w", err)
+	}
+
+	s.buf = s.buf[:0]
+	s.index++
+
+	return nil
+}
+
+// streamOpener - reads chunks sealed by streamSealer, returning their content only once they're authenticated.
+type streamOpener struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+
+	// chunk & out - buffers of the sealed chunk, and of its content, which plain is the unread rest of.
+	chunk []byte
+	out   []byte
+	plain []byte
+	index uint64
+	done  bool
+}
+
+func newStreamOpener(r io.Reader, aead cipher.AEAD, header []byte) *streamOpener {
+	return &streamOpener{
+		r:      bufio.NewReader(r),
+		aead:   aead,
+		header: header,
+		chunk:  make([]byte, streamChunkSize+aead.Overhead()),
+		out:    make([]byte, 0, streamChunkSize),
+	}
+}
+
+func (o *streamOpener) Read(p []byte) (int, error) {
+	for len(o.plain) == 0 {
+		if o.done {
+			return 0, io.EOF
+		}
+
+		err := o.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, o.plain)
+	o.plain = o.plain[n:]
+
+	return n, nil
+}
+
+// open - reads and authenticates the next chunk. It's the last one if the stream ends with it.
+func (o *streamOpener) open() error {
+	n, err := io.ReadFull(o.r, o.chunk)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("failed reading stream: %w", err)
+	}
+
+	last := n < len(o.chunk)
+	if !last {
+		_, err = o.r.Peek(1)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed reading stream: %w", err)
+		}
+
+		last = err != nil
+	}
+
+	o.plain, err = o.aead.Open(o.out[:0], streamNonce(o.aead, o.index, last), o.chunk[:n], o.header)
+	if err != nil {
+		return fmt.Errorf("%w: chunk %d of the stream has been modified, reordered or cut off", ErrIntegrity, o.index)
+	}
+
+	o.index++
+	o.done = last
+
+	return nil
+}
+
+// streamBase - returns path of the streamed archive, without the volume suffix.
+func streamBase(conf *ArchiveConfig) string {
+	name, out := "archive", conf.OutputPath
+
+	if conf.NewArchiveName != "" {
+		name = conf.NewArchiveName
+	}
+
+	if out == "" {
+		out = defaultArchiveOutput
+	}
+
+	return filepath.Join(out, name+streamExt)
+}
+
+// streamVolumeSize - returns size of the volumes in bytes, or 0 if the archive isn't split.
+func streamVolumeSize(conf *ArchiveConfig) uint64 {
+	unit := conf.BlockSize
+	if unit == "" {
+		unit = BlockSizeMB
+	}
+
+	return conf.VolumeSize * unitBytes(unit)
+}
+
+// volumeWriter - writes the stream into volumes of the chosen size, creating the next one once the previous is full.
+// If the size is 0, the stream is written into a single file, without the volume suffix. Writing fails with the error
+// of the context, once it's done.
+type volumeWriter struct {
+	ctx  context.Context
+	base string
+	size uint64
+
+	current *os.File
+	written uint64
+	volumes []string
+}
+
+func (v *volumeWriter) Write(p []byte) (int, error) {
+	n := 0
+
+	err := v.ctx.Err()
+	if err != nil {
+		return 0, fmt.Errorf("streaming interrupted: %w", err)
+	}
+
+	for len(p) > 0 {
+		if v.current == nil || (v.size != 0 && v.written == v.size) {
+			err := v.next()
+			if err != nil {
+				return n, err
+			}
+		}
+
+		chunk := p
+		if v.size != 0 && uint64(len(chunk)) > v.size-v.written {
+			chunk = chunk[:v.size-v.written]
+		}
+
+		written, err := v.current.Write(chunk)
+		n += written
+		v.written += uint64(written)
+
+		if err != nil {
+			return n, fmt.Errorf("failed writing volume: %w", err)
+		}
+
+		p = p[written:]
+	}
+
+	return n, nil
+}
+
+// next - closes the current volume, and creates the next one.
+func (v *volumeWriter) next() error {
+	err := v.Close()
+	if err != nil {
+		return err
+	}
+
+	name := v.base
+	if v.size != 0 {
+		name = fmt.Sprintf(streamVolumeFormat, v.base, len(v.volumes)+1)
+	}
+
+	err = os.MkdirAll(filepath.Dir(name), workDirPerm)
+	if err != nil {
+		return fmt.Errorf("failed creating output dir: %w", err)
+	}
+
+	v.current, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, encFilePerm)
+	if err != nil {
+		return fmt.Errorf("failed creating volume: %w", err)
+	}
+
+	v.volumes = append(v.volumes, name)
+	v.written = 0
+
+	return nil
+}
+
+// Close - syncs and closes the current volume, if any.
+func (v *volumeWriter) Close() error {
+	if v.current == nil {
+		return nil
+	}
+
+	f := v.current
+	v.current = nil
+
+	err := f.Sync()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed closing volume: %w", err)
+	}
+
+	return nil
+}
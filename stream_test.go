@@ -0,0 +1,250 @@
+package ax
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitStreamArchive() {
+	var (
+		srcDir, outDir string
+		content        []byte
+		passwd         = []byte("stream-secret")
+	)
+
+	prepare := func() {
+		srcDir, outDir = s.T().TempDir(), s.T().TempDir()
+
+		// Random content, so it isn't compressed below the size of a volume.
+		content = make([]byte, 5000)
+		_, err := rand.Read(content)
+		s.Require().Nil(err)
+
+		s.Require().Nil(os.MkdirAll(filepath.Join(srcDir, "sub"), 0o755))
+		s.Require().Nil(os.WriteFile(filepath.Join(srcDir, "sub", "data.bin"), content, 0o600))
+		s.Require().Nil(os.WriteFile(filepath.Join(srcDir, "plain.txt"), []byte("PLAINTEXT-MARKER"), 0o600))
+	}
+
+	config := func(volumeSize uint64) *ArchiveConfig {
+		ac := NewDefaultArchiveConfig()
+		ac.PathToArchive = srcDir
+		ac.OutputPath = outDir
+		ac.NewArchiveName = "backup"
+		ac.SetVolumeSize(volumeSize)
+
+		return &ac
+	}
+
+	extractConfig := func(passwd []byte) *ExtractConfig {
+		return &ExtractConfig{
+			ArchivePath:        filepath.Join(outDir, "backup.tar.gz.ax.001"),
+			OutputDir:          filepath.Join(outDir, "restored"),
+			EncryptionPassword: passwd,
+		}
+	}
+
+	testCases := []TestCase{
+		{
+			Name:          "success round trip through encrypted volumes",
+			PreRequisites: prepare,
+			Assert: func() {
+				volumes, err := StreamArchive(config(2<<10), passwd)
+				s.Require().Nil(err)
+
+				s.Require().True(len(volumes) >= 3)
+				assert.Equal(s.T(), filepath.Join(outDir, "backup.tar.gz.ax.001"), volumes[0])
+				assert.Equal(s.T(), filepath.Join(outDir, "backup.tar.gz.ax.002"), volumes[1])
+
+				for _, vol := range volumes {
+					raw, err := os.ReadFile(vol)
+					s.Require().Nil(err)
+
+					assert.LessOrEqual(s.T(), len(raw), 2<<10)
+					assert.False(s.T(), bytes.Contains(raw, []byte("PLAINTEXT-MARKER")), vol)
+				}
+
+				entries, err := List(extractConfig(passwd))
+				s.Require().Nil(err)
+				assert.Len(s.T(), filePaths(entries, func(e *Entry) bool { return true }), 2)
+
+				s.Require().Nil(TestArchive(extractConfig(passwd)))
+				s.Require().Nil(Extract(extractConfig(passwd)))
+
+				restored, err := os.ReadFile(filepath.Join(outDir, "restored", filepath.Base(srcDir), "sub", "data.bin"))
+				s.Require().Nil(err)
+				assert.Equal(s.T(), content, restored)
+			},
+		},
+		{
+			Name:          "success stale volumes of a larger archive are removed",
+			PreRequisites: prepare,
+			Assert: func() {
+				volumes, err := StreamArchive(config(2<<10), passwd)
+				s.Require().Nil(err)
+
+				stale := make([]string, 0)
+				for i := len(volumes) + 1; i <= len(volumes)+3; i++ {
+					p := fmt.Sprintf(streamVolumeFormat, filepath.Join(outDir, "backup"+streamExt), i)
+					s.Require().Nil(os.WriteFile(p, []byte("old"), 0o600))
+
+					stale = append(stale, p)
+				}
+
+				_, err = StreamArchive(config(2<<10), passwd)
+				s.Require().Nil(err)
+
+				for _, p := range stale {
+					assert.NoFileExists(s.T(), p)
+				}
+
+				s.Require().Nil(TestArchive(extractConfig(passwd)))
+			},
+		},
+		{
+			Name:          "success single file if the archive isn't split",
+			PreRequisites: prepare,
+			Assert: func() {
+				volumes, err := StreamArchive(config(0), passwd)
+				s.Require().Nil(err)
+				assert.Equal(s.T(), []string{filepath.Join(outDir, "backup.tar.gz.ax")}, volumes)
+
+				ec := extractConfig(passwd)
+				ec.ArchivePath = volumes[0]
+
+				s.Require().Nil(TestArchive(ec))
+			},
+		},
+		{
+			Name:          "err wrong or missing encryption password",
+			PreRequisites: prepare,
+			Assert: func() {
+				_, err := StreamArchive(config(2<<10), nil)
+				assert.ErrorIs(s.T(), err, ErrEncryptionPassword)
+
+				_, err = StreamArchive(config(2<<10), passwd)
+				s.Require().Nil(err)
+
+				assert.ErrorIs(s.T(), Extract(extractConfig([]byte("wrong"))), ErrWrongPassword)
+				assert.ErrorIs(s.T(), TestArchive(extractConfig(nil)), ErrEncryptionPassword)
+				assert.NoDirExists(s.T(), filepath.Join(outDir, "restored"))
+			},
+		},
+		{
+			Name:          "err volume modified or cut off, nothing extracted",
+			PreRequisites: prepare,
+			Assert: func() {
+				volumes, err := StreamArchive(config(2<<10), passwd)
+				s.Require().Nil(err)
+
+				raw, err := os.ReadFile(volumes[1])
+				s.Require().Nil(err)
+
+				tampered := append([]byte{}, raw...)
+				tampered[len(tampered)/2] ^= 0x01
+				s.Require().Nil(os.WriteFile(volumes[1], tampered, 0o600))
+
+				assert.ErrorIs(s.T(), Extract(extractConfig(passwd)), ErrIntegrity)
+				assert.NoDirExists(s.T(), filepath.Join(outDir, "restored"))
+
+				s.Require().Nil(os.WriteFile(volumes[1], raw, 0o600))
+				s.Require().Nil(TestArchive(extractConfig(passwd)))
+
+				last := volumes[len(volumes)-1]
+
+				raw, err = os.ReadFile(last)
+				s.Require().Nil(err)
+				s.Require().Nil(os.WriteFile(last, raw[:len(raw)-1], 0o600))
+
+				assert.ErrorIs(s.T(), Extract(extractConfig(passwd)), ErrIntegrity)
+				assert.NoDirExists(s.T(), filepath.Join(outDir, "restored"))
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
+
+func (s *Suite) TestUnitStreamSealer() {
+	header := append(append([]byte{}, streamMagic...), make([]byte, streamSaltSize+streamCheckSize)...)
+
+	aead, _, err := streamKeys([]byte("stream-secret"), header[len(streamMagic):len(streamMagic)+streamSaltSize])
+	s.Require().Nil(err)
+
+	chunkSize := streamChunkSize + aead.Overhead()
+
+	// seal - returns the content sealed into chunks.
+	seal := func(content []byte) []byte {
+		var buf bytes.Buffer
+
+		sealer := newStreamSealer(&buf, aead, header)
+
+		_, err := sealer.Write(content)
+		s.Require().Nil(err)
+		s.Require().Nil(sealer.Close())
+
+		return buf.Bytes()
+	}
+
+	open := func(sealed []byte) ([]byte, error) {
+		return io.ReadAll(newStreamOpener(bytes.NewReader(sealed), aead, header))
+	}
+
+	testCases := []TestCase{
+		{
+			Name: "success round trip of any size",
+			Assert: func() {
+				for _, size := range []int{0, 1, streamChunkSize, 2*streamChunkSize + 5} {
+					content := bytes.Repeat([]byte{'a'}, size)
+
+					// Only the last chunk may be empty, or full.
+					chunks := (size + streamChunkSize - 1) / streamChunkSize
+					if chunks == 0 {
+						chunks = 1
+					}
+
+					sealed := seal(content)
+					assert.Equal(s.T(), chunks*aead.Overhead()+size, len(sealed), size)
+
+					opened, err := open(sealed)
+					s.Require().Nil(err, size)
+					assert.Equal(s.T(), content, opened, size)
+				}
+			},
+		},
+		{
+			Name: "err chunks modified, reordered or cut off",
+			Assert: func() {
+				sealed := seal(bytes.Repeat([]byte{'a'}, 2*streamChunkSize+5))
+
+				modified := append([]byte{}, sealed...)
+				modified[chunkSize+1] ^= 0x01
+
+				reordered := append(append(append([]byte{}, sealed[chunkSize:2*chunkSize]...), sealed[:chunkSize]...),
+					sealed[2*chunkSize:]...)
+
+				for name, broken := range map[string][]byte{
+					"modified":           modified,
+					"reordered":          reordered,
+					"cut off":            sealed[:2*chunkSize],
+					"cut off mid chunk":  sealed[:chunkSize+10],
+					"without last chunk": sealed[:len(sealed)-5-aead.Overhead()],
+				} {
+					_, err := open(broken)
+					assert.ErrorIs(s.T(), err, ErrIntegrity, name)
+				}
+
+				// Chunks are authenticated along with the header of their stream.
+				_, err := io.ReadAll(newStreamOpener(bytes.NewReader(sealed), aead, streamMagic))
+				assert.ErrorIs(s.T(), err, ErrIntegrity)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
@@ -0,0 +1,172 @@
+package ax
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Compression methods, which can be set as ArchiveConfig.Method.
+const (
+	// MethodLZMA2 - default method of 7z archives.
+	MethodLZMA2 = "LZMA2"
+
+	// MethodPPMd - usually compresses text better than LZMA2, but it's as slow to decompress as to compress.
+	MethodPPMd = "PPMd"
+
+	// MethodBZip2 - faster and weaker than LZMA2, which compresses in multiple threads.
+	MethodBZip2 = "BZip2"
+
+	// MethodZstd - Zstandard, which is supported only by 7zip builds with zstd codecs (i.e. 7-Zip-zstd or p7zip-zstd).
+	MethodZstd = "zstd"
+
+	// methodDeflate - default method of zip archives.
+	methodDeflate = "Deflate"
+)
+
+// Archive types which are validated by ArchiveConfig.Validate.
+const (
+	archiveType7z  = archiveType
+	archiveTypeZip = "zip"
+	archiveTypeTar = "tar"
+)
+
+// Limits of the compression settings.
+const (
+	maxCompression      = 9
+	minFastBytesLZMA2   = 5
+	maxFastBytesLZMA2   = 273
+	minFastBytesDeflate = 3
+	maxFastBytesDeflate = 258
+	maxDictSizeLZMA2    = 1536
+)
+
+// ErrInvalidArchiveConfig - ArchiveConfig holds a setting which isn't supported by the archive type, or is out of
+// the supported range.
+var ErrInvalidArchiveConfig = errors.New("invalid archive config")
+
+// archiveMethods - returns compression methods supported by the archive type, the first one being its default, and
+// whether the archive type is supported at all. Tarballs aren't compressed, so they have none.
+func archiveMethods(typ string) ([]string, bool) {
+	switch typ {
+	case archiveType7z:
+		return []string{MethodLZMA2, MethodPPMd, MethodBZip2, MethodZstd}, true
+	case archiveTypeZip:
+		return []string{methodDeflate, MethodBZip2, MethodPPMd}, true
+	case archiveTypeTar:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+// SupportsHeadersEncryption - reports whether archives of the type (or of the default one, if it's empty) can have
+// their headers encrypted.
+func SupportsHeadersEncryption(archiveType string) bool {
+	return archiveType == "" || archiveType == archiveType7z
+}
+
+// Validate - returns ErrInvalidArchiveConfig if any of the settings isn't supported by the archive type or the
+// compression method, or is out of the range 7zip accepts, so that it's reported before anything is archived.
+func (ac *ArchiveConfig) Validate() error {
+	typ := ac.archiveType()
+
+	methods, ok := archiveMethods(typ)
+	if !ok {
+		return invalidConfig("unsupported archive type %q (supported: %s, %s, %s)",
+			typ, archiveType7z, archiveTypeZip, archiveTypeTar)
+	}
+
+	if ac.BlockSize != "" && unitBytes(ac.BlockSize) == 0 {
+		return invalidConfig("unknown block size %q (supported: b, k, m, g)", ac.BlockSize)
+	}
+
+	if ac.Compression > maxCompression {
+		return invalidConfig("compression level %d is out of range 0-%d", ac.Compression, maxCompression)
+	}
+
+	method := ac.method()
+	if ac.Method != "" && method == "" {
+		return invalidConfig("method %q isn't supported by %s archives (supported: %s)",
+			ac.Method, typ, strings.Join(methods, ", "))
+	}
+
+	err := ac.validateMethod(method)
+	if err != nil {
+		return err
+	}
+
+	return ac.validateArchiveType(typ)
+}
+
+// validateMethod - validates settings of the compression method.
+func (ac *ArchiveConfig) validateMethod(method string) error {
+	switch method {
+	case MethodLZMA2:
+		if ac.FastBytes != 0 && (ac.FastBytes < minFastBytesLZMA2 || ac.FastBytes > maxFastBytesLZMA2) {
+			return invalidConfig("fast bytes %d are out of range %d-%d for %s",
+				ac.FastBytes, minFastBytesLZMA2, maxFastBytesLZMA2, method)
+		}
+
+		if ac.DictSize > maxDictSizeLZMA2 {
+			return invalidConfig("dictionary size %dm is out of range 1m-%dm for %s", ac.DictSize, maxDictSizeLZMA2, method)
+		}
+	case methodDeflate:
+		if ac.FastBytes != 0 && (ac.FastBytes < minFastBytesDeflate || ac.FastBytes > maxFastBytesDeflate) {
+			return invalidConfig("fast bytes %d are out of range %d-%d for %s",
+				ac.FastBytes, minFastBytesDeflate, maxFastBytesDeflate, method)
+		}
+	}
+
+	return nil
+}
+
+// validateArchiveType - validates settings which are supported only by some of the archive types.
+func (ac *ArchiveConfig) validateArchiveType(typ string) error {
+	switch {
+	case typ == archiveTypeTar && ac.Password != nil && ac.ApplyPassword:
+		return invalidConfig("%s archives can't be password-protected", typ)
+	case typ != archiveType7z && ac.HeadersEncryption:
+		return invalidConfig("headers encryption is supported only by %s archives", archiveType7z)
+	case typ != archiveType7z && ac.SolidBlockSize != 0:
+		return invalidConfig("solid blocks are supported only by %s archives", archiveType7z)
+	case ac.SolidBlockSize != 0 && !ac.SolidArchive:
+		return invalidConfig("solid block size requires a solid archive")
+	default:
+		return nil
+	}
+}
+
+// archiveType - returns the archive type, falling back to the default one.
+func (ac *ArchiveConfig) archiveType() string {
+	if ac.ArchiveType == "" {
+		return defaultArchiveType
+	}
+
+	return ac.ArchiveType
+}
+
+// method - returns the canonical name of the compression method, falling back to the default one of the archive type.
+// Empty string is returned if the archive type doesn't support it, or isn't compressed at all.
+func (ac *ArchiveConfig) method() string {
+	methods, _ := archiveMethods(ac.archiveType())
+	if len(methods) == 0 {
+		return ""
+	}
+
+	if ac.Method == "" {
+		return methods[0]
+	}
+
+	for _, m := range methods {
+		if strings.EqualFold(m, ac.Method) {
+			return m
+		}
+	}
+
+	return ""
+}
+
+func invalidConfig(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidArchiveConfig, fmt.Sprintf(format, args...))
+}
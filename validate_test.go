@@ -0,0 +1,115 @@
+package ax
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitValidateArchiveConfig() {
+	config := func(modify func(ac *ArchiveConfig)) *ArchiveConfig {
+		ac := NewDefaultArchiveConfig()
+		ac.ApplyPassword = true
+		modify(&ac)
+
+		return &ac
+	}
+
+	testCases := []TestCase{
+		{
+			Name: "success valid configs",
+			Assert: func() {
+				for name, ac := range map[string]*ArchiveConfig{
+					"default": config(func(ac *ArchiveConfig) {}),
+					"empty":   {},
+					"tuned 7z": config(func(ac *ArchiveConfig) {
+						ac.Method, ac.Threads, ac.SolidBlockSize = "ppmd", 4, 64<<20
+					}),
+					"lzma2 limits": config(func(ac *ArchiveConfig) { ac.FastBytes, ac.DictSize = 273, 1536 }),
+					"zip": config(func(ac *ArchiveConfig) {
+						ac.ArchiveType, ac.HeadersEncryption, ac.FastBytes, ac.Method = "zip", false, 258, MethodBZip2
+					}),
+					"tar": config(func(ac *ArchiveConfig) {
+						ac.ArchiveType, ac.HeadersEncryption, ac.ApplyPassword = "tar", false, false
+					}),
+				} {
+					assert.Nil(s.T(), ac.Validate(), name)
+				}
+			},
+		},
+		{
+			Name: "err invalid configs",
+			Assert: func() {
+				for name, ac := range map[string]*ArchiveConfig{
+					"archive type":   config(func(ac *ArchiveConfig) { ac.ArchiveType = "rar" }),
+					"block size":     config(func(ac *ArchiveConfig) { ac.BlockSize = "t" }),
+					"compression":    config(func(ac *ArchiveConfig) { ac.Compression = 10 }),
+					"method":         config(func(ac *ArchiveConfig) { ac.Method = "LZMA3" }),
+					"zip method":     config(func(ac *ArchiveConfig) { ac.ArchiveType, ac.Method = "zip", MethodLZMA2 }),
+					"fast bytes":     config(func(ac *ArchiveConfig) { ac.FastBytes = 274 }),
+					"min fast bytes": config(func(ac *ArchiveConfig) { ac.FastBytes = 4 }),
+					"dict size":      config(func(ac *ArchiveConfig) { ac.DictSize = 1537 }),
+					"zip headers":    config(func(ac *ArchiveConfig) { ac.ArchiveType = "zip" }),
+					"tar password": config(func(ac *ArchiveConfig) {
+						ac.ArchiveType, ac.HeadersEncryption = "tar", false
+					}),
+					"zip solid block": config(func(ac *ArchiveConfig) {
+						ac.ArchiveType, ac.HeadersEncryption, ac.SolidBlockSize = "zip", false, 1<<20
+					}),
+					"solid block without solid": config(func(ac *ArchiveConfig) {
+						ac.SolidArchive, ac.SolidBlockSize = false, 1<<20
+					}),
+				} {
+					assert.ErrorIs(s.T(), ac.Validate(), ErrInvalidArchiveConfig, name)
+				}
+			},
+		},
+		{
+			Name: "err archiving with invalid config",
+			Assert: func() {
+				err := Archive(config(func(ac *ArchiveConfig) {
+					ac.PathToArchive, ac.Compression = "tests", 10
+				}))
+
+				assert.ErrorIs(s.T(), err, ErrInvalidArchiveConfig)
+			},
+		},
+		{
+			Name: "success tuning args",
+			Assert: func() {
+				out := filepath.Join(s.T().TempDir(), "archive")
+
+				for expected, ac := range map[string]*ArchiveConfig{
+					"-t7z -m0=PPMd -mx=9 -mmt=4 -v90m -ms=64m": config(func(ac *ArchiveConfig) {
+						ac.Method, ac.Threads, ac.SolidBlockSize = "ppmd", 4, 64<<20
+					}),
+					"-t7z -m0=BZip2 -mx=9 -v1536m -ms=on": config(func(ac *ArchiveConfig) {
+						ac.Method = MethodBZip2
+						ac.SetVolumeSize(3 << 29)
+					}),
+					"-tzip -mm=Deflate -mx=9 -mfb=64 -v90m": config(func(ac *ArchiveConfig) {
+						ac.ArchiveType, ac.HeadersEncryption, ac.Method = "zip", false, "deflate"
+					}),
+					"-ttar -v90m": config(func(ac *ArchiveConfig) {
+						ac.ArchiveType, ac.HeadersEncryption, ac.ApplyPassword = "tar", false, false
+					}),
+				} {
+					ac.OutputPath, ac.ApplyPassword = out, false
+
+					args, err := cmdArgsArchive(ac, "@list.txt")
+					s.Require().Nil(err)
+
+					tuning := args[1 : len(args)-3]
+					if tuning[0] == "-mhe=on" {
+						tuning = tuning[1:]
+					}
+
+					assert.Equal(s.T(), expected, strings.Join(tuning, " "))
+				}
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
@@ -0,0 +1,55 @@
+package ax
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TestArchive - used to test integrity of the archive(s), without extracting anything to disk.
+// Archives are found the same way as by Extract, and tarballs are tested natively.
+//
+// Every archived file is decompressed (and decrypted, if Password is set) in memory, while its CRC is checked, so it
+// can be run both right after Archive, and on the volume(s) which have been pulled and decrypted in a restore drill.
+// Failures are reported as ErrIntegrity or ErrWrongPassword.
+func TestArchive(conf *ExtractConfig) error {
+	return TestArchiveWithContext(context.Background(), conf)
+}
+
+// TestArchiveWithContext - same as TestArchive, but it stops once the context is done, killing 7zip if it's running.
+func TestArchiveWithContext(ctx context.Context, conf *ExtractConfig) error {
+	started := time.Now()
+	log := loggerOr(conf.Logger)
+
+	sets, err := FindArchives(conf.archivePath())
+	if err != nil {
+		return err
+	}
+
+	for _, set := range sets {
+		if set.native() {
+			err = testNative(ctx, set, conf.EncryptionPassword)
+		} else {
+			err = executeCommandIn(ctx, log, "", sevenZipCmd(), cmdArgsArchiveTest(conf, set.Path()))
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed testing %s: %w", set.Path(), err)
+		}
+	}
+
+	log.Info(EventVerified, "path", conf.archivePath(), "archives", len(sets), AttrDuration, time.Since(started))
+
+	return nil
+}
+
+// cmdArgsArchiveTest - used to build command arguments for testing the archive.
+func cmdArgsArchiveTest(ec *ExtractConfig, archive string) []string {
+	args := []string{"t"}
+
+	if len(ec.Password) != 0 {
+		args = append(args, fmt.Sprintf("-p%s", ec.Password))
+	}
+
+	return append(args, archive)
+}
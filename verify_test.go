@@ -0,0 +1,29 @@
+package ax
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitTestArchive() {
+	testCases := []TestCase{
+		{
+			Name: "success args",
+			Assert: func() {
+				assert.Equal(s.T(), []string{"t", "out/a.7z.001"},
+					cmdArgsArchiveTest(&ExtractConfig{ArchivePath: "./out"}, "out/a.7z.001"))
+				assert.Equal(s.T(), []string{"t", "-psecret", "out/a.7z.001"},
+					cmdArgsArchiveTest(&ExtractConfig{Password: []byte("secret"), ArchivePath: "./out"}, "out/a.7z.001"))
+			},
+		},
+		{
+			Name: "err testing archive which does not exist",
+			Assert: func() {
+				err := TestArchive(&ExtractConfig{ArchivePath: s.T().TempDir()})
+
+				assert.NotNil(s.T(), err)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
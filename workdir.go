@@ -0,0 +1,350 @@
+package ax
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// WorkDirPattern - pattern of the names of work directories created by CreateWorkDir, '*' being a random string.
+	WorkDirPattern = "ax-work-*"
+
+	// WorkDirMarker - file marking a directory as created by ax, which is also the one locked while it's in use.
+	WorkDirMarker        = ".ax-workdir"
+	workDirMarkerContent = "This directory is used by ax for temporary archives, and it's deleted by ax once done.\n"
+
+	workDirPerm = 0o700
+
+	// jobWorkDirKeyLen - length of the job key within the name of the work directory of the job, see JobWorkDirPath.
+	jobWorkDirKeyLen = 16
+
+	// gitDir - repository within the work directory, which holds only encrypted volume(s).
+	gitDir = ".git"
+
+	// wipeChunkSize - size of the zeroes written at once over a file by wipeFile.
+	wipeChunkSize = 1 << 20
+)
+
+var (
+	// ErrNotWorkDir - directory holds files, but it hasn't been created by ax, so ax refuses to delete them.
+	ErrNotWorkDir = errors.New("directory hasn't been created by ax")
+
+	// ErrWorkDirLocked - work directory is used by another run of ax.
+	ErrWorkDirLocked = errors.New("work directory is locked by another run of ax")
+)
+
+// WorkDir - directory ax places intermediate archives into. It's marked as created by ax, which is the only kind of
+// directory ax ever deletes content of, and it's locked against concurrent runs until it's closed or removed.
+type WorkDir struct {
+	// Path - absolute path of the directory.
+	Path string
+
+	unlock func() error
+}
+
+// CreateWorkDir - creates a new, uniquely named, work directory within the parent, or within os.TempDir if it's empty.
+// It's accessible only by the current user.
+func CreateWorkDir(parent string) (*WorkDir, error) {
+	if parent == "" {
+		parent = os.TempDir()
+	}
+
+	dir, err := os.MkdirTemp(parent, WorkDirPattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating work directory: %w", err)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving work directory: %w", err)
+	}
+
+	return openMarkedWorkDir(abs)
+}
+
+// OpenWorkDir - opens the work directory at the path, creating it if it doesn't exist. An existing directory is used
+// only if it's empty, or it has been created by ax, otherwise ErrNotWorkDir is returned.
+func OpenWorkDir(path string) (*WorkDir, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving work directory: %w", err)
+	}
+
+	err = CheckWorkDir(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(abs, workDirPerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating work directory: %w", err)
+	}
+
+	return openMarkedWorkDir(abs)
+}
+
+// JobWorkDirPath - returns the work directory of the job with the key (see NewJobKey) within a directory of the
+// current user in the parent, or in os.TempDir if it's empty. It's the same for every run of the job, so that a run
+// finds the volume(s) left behind by an unfinished one.
+func JobWorkDirPath(parent, key string) string {
+	if parent == "" {
+		parent = os.TempDir()
+	}
+
+	if len(key) > jobWorkDirKeyLen {
+		key = key[:jobWorkDirKeyLen]
+	}
+
+	return filepath.Join(parent, "ax-"+strconv.Itoa(os.Getuid()), strings.Replace(WorkDirPattern, "*", key, 1))
+}
+
+// OpenJobWorkDir - opens the work directory of the job at JobWorkDirPath, creating it if it doesn't exist. As the
+// parent is usually shared with other users, the directory of the current user is used only if it's accessible by
+// no one else, otherwise ErrNotWorkDir is returned.
+func OpenJobWorkDir(parent, key string) (*WorkDir, error) {
+	path := JobWorkDirPath(parent, key)
+	userDir := filepath.Dir(path)
+
+	err := os.MkdirAll(userDir, workDirPerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating work directory: %w", err)
+	}
+
+	err = checkPrivateDir(userDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenWorkDir(path)
+}
+
+// CheckWorkDir - returns ErrNotWorkDir if OpenWorkDir would refuse to use the path, as it holds files not created
+// by ax. Nothing is changed.
+func CheckWorkDir(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed getting stat of work directory: %w", err)
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s isn't a directory", ErrNotWorkDir, path)
+	}
+
+	if _, err := os.Lstat(filepath.Join(path, WorkDirMarker)); err == nil {
+		return nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fmt.Errorf("failed reading work directory: %w", err)
+	}
+
+	if len(entries) != 0 {
+		return fmt.Errorf("%w: %s isn't empty, and it's missing the %s marker (choose another one, or empty it)",
+			ErrNotWorkDir, path, WorkDirMarker)
+	}
+
+	return nil
+}
+
+// openMarkedWorkDir - marks the directory as created by ax, unless it already is, and locks it.
+func openMarkedWorkDir(dir string) (*WorkDir, error) {
+	marker := filepath.Join(dir, WorkDirMarker)
+
+	f, err := os.OpenFile(marker, os.O_WRONLY|os.O_CREATE, encFilePerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed marking work directory: %w", err)
+	}
+
+	_, err = f.WriteString(workDirMarkerContent)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed marking work directory: %w", err)
+	}
+
+	unlock, err := lockWorkDir(marker)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WorkDir{Path: dir, unlock: unlock}, nil
+}
+
+// Clean - removes everything within the work directory but its marker (and lock). Files which may hold plaintext (anything but
+// encrypted volumes and the git repository holding them) are overwritten with zeroes before they're removed.
+//
+// Overwriting is a best effort, as journaling and copy-on-write file systems or SSDs may keep the previous content.
+func (w *WorkDir) Clean() error {
+	return w.cleanExcept(nil)
+}
+
+// cleanExcept - same as Clean, but leaves the entries of the work directory which are kept in place.
+func (w *WorkDir) cleanExcept(keep map[string]bool) error {
+	entries, err := os.ReadDir(w.Path)
+	if err != nil {
+		return fmt.Errorf("failed reading work directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), WorkDirMarker) || keep[entry.Name()] {
+			continue
+		}
+
+		p := filepath.Join(w.Path, entry.Name())
+
+		if entry.Name() != gitDir {
+			err = wipeTree(p)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = os.RemoveAll(p)
+		if err != nil {
+			return fmt.Errorf("failed cleaning work directory: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Files - returns paths of the files within the work directory, leaving out its marker and the git repository.
+func (w *WorkDir) Files() ([]string, error) {
+	files := make([]string, 0)
+
+	err := filepath.Walk(w.Path, func(p string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case info.IsDir() && info.Name() == gitDir:
+			return filepath.SkipDir
+		case info.IsDir() || filepath.Dir(p) == w.Path && strings.HasPrefix(info.Name(), WorkDirMarker):
+			return nil
+		default:
+			files = append(files, p)
+
+			return nil
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed listing work directory: %w", err)
+	}
+
+	return files, nil
+}
+
+// Close - releases the lock of the work directory, leaving whatever it holds in place.
+func (w *WorkDir) Close() error {
+	if w.unlock == nil {
+		return nil
+	}
+
+	err := w.unlock()
+	w.unlock = nil
+
+	return err
+}
+
+// Remove - cleans the work directory, and removes it along with its marker.
+func (w *WorkDir) Remove() error {
+	err := w.Clean()
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(w.Path)
+	if err != nil {
+		return fmt.Errorf("failed removing work directory: %w", err)
+	}
+
+	return nil
+}
+
+// wipeTree - overwrites the files within the path, or the file itself, which may hold plaintext.
+func wipeTree(path string) error {
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() || strings.Contains(info.Name(), encryptedFileMarker) {
+			return nil
+		}
+
+		return wipeFile(p, info.Size())
+	})
+	if err != nil {
+		return fmt.Errorf("failed wiping %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// wipeFile - overwrites the file with zeroes, and syncs it to the disk.
+func wipeFile(p string, size int64) error {
+	f, err := os.OpenFile(p, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed opening %s: %w", p, err)
+	}
+
+	defer f.Close()
+
+	zeroes := make([]byte, wipeChunkSize)
+
+	for size > 0 {
+		n := int64(len(zeroes))
+		if size < n {
+			n = size
+		}
+
+		_, err = f.Write(zeroes[:n])
+		if err != nil {
+			return fmt.Errorf("failed overwriting %s: %w", p, err)
+		}
+
+		size -= n
+	}
+
+	err = f.Sync()
+	if err != nil {
+		return fmt.Errorf("failed syncing %s: %w", p, err)
+	}
+
+	return nil
+}
+
+// removeWiped - overwrites the file with zeroes, and removes it.
+func removeWiped(p string) error {
+	info, err := os.Lstat(p)
+	if err != nil {
+		return fmt.Errorf("failed getting stat of %s: %w", p, err)
+	}
+
+	err = wipeFile(p, info.Size())
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(p)
+	if err != nil {
+		return fmt.Errorf("failed removing %s: %w", p, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package ax
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockFileSuffix - suffix of the file, next to the marker, whose existence locks the work directory.
+const lockFileSuffix = ".lock"
+
+// lockWorkDir - locks the work directory by exclusively creating a lock file next to its marker, which is removed by
+// the returned func. Unlike flock, the lock is left behind if ax dies, so it has to be removed by hand then.
+func lockWorkDir(marker string) (func() error, error) {
+	lock := marker + lockFileSuffix
+
+	f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, encFilePerm)
+	if os.IsExist(err) {
+		return nil, fmt.Errorf("%w: %s (remove it, if no other run is in progress)", ErrWorkDirLocked, lock)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed locking work directory: %w", err)
+	}
+
+	_ = f.Close()
+
+	return func() error {
+		err := os.Remove(lock)
+		if err != nil {
+			return fmt.Errorf("failed unlocking work directory: %w", err)
+		}
+
+		return nil
+	}, nil
+}
+
+// checkPrivateDir - returns ErrNotWorkDir unless the path is a directory, rather than a symlink. Its permissions are
+// left to the platform, whose temp dir is usually per user already.
+func checkPrivateDir(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("failed getting stat of work directory: %w", err)
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s has to be a directory", ErrNotWorkDir, path)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package ax
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *Suite) TestUnitWorkDir() {
+	var parent string
+
+	prepare := func() {
+		parent = s.T().TempDir()
+	}
+
+	write := func(p, content string) {
+		s.Require().Nil(os.MkdirAll(filepath.Dir(p), 0o700))
+		s.Require().Nil(os.WriteFile(p, []byte(content), 0o600))
+	}
+
+	testCases := []TestCase{
+		{
+			Name:          "success created work dir is marked and removed",
+			PreRequisites: prepare,
+			Assert: func() {
+				wd, err := CreateWorkDir(parent)
+				s.Require().Nil(err)
+
+				assert.Equal(s.T(), parent, filepath.Dir(wd.Path))
+				assert.FileExists(s.T(), filepath.Join(wd.Path, WorkDirMarker))
+
+				info, err := os.Stat(wd.Path)
+				s.Require().Nil(err)
+				assert.Equal(s.T(), os.FileMode(0o700), info.Mode().Perm())
+
+				write(filepath.Join(wd.Path, "backup.7z.001"), "plaintext")
+				write(filepath.Join(wd.Path, ".git", "HEAD"), "ref: refs/heads/master")
+
+				s.Require().Nil(wd.Remove())
+				assert.NoDirExists(s.T(), wd.Path)
+			},
+		},
+		{
+			Name:          "success empty or marked dir is opened and cleaned",
+			PreRequisites: prepare,
+			Assert: func() {
+				dir := filepath.Join(parent, "out")
+
+				wd, err := OpenWorkDir(dir)
+				s.Require().Nil(err)
+
+				write(filepath.Join(dir, "backup.7z.001"), "plaintext")
+				write(filepath.Join(dir, "backup.7z.002.enc.1"), "ciphertext")
+				write(filepath.Join(dir, ".git", "HEAD"), "ref: refs/heads/master")
+
+				files, err := wd.Files()
+				s.Require().Nil(err)
+				assert.ElementsMatch(s.T(), []string{
+					filepath.Join(dir, "backup.7z.001"), filepath.Join(dir, "backup.7z.002.enc.1"),
+				}, files)
+
+				s.Require().Nil(wd.Close())
+
+				// Marked by the previous run, so it's opened even though it isn't empty.
+				wd, err = OpenWorkDir(dir)
+				s.Require().Nil(err)
+				s.Require().Nil(wd.Clean())
+				s.Require().Nil(wd.Close())
+
+				entries, err := os.ReadDir(dir)
+				s.Require().Nil(err)
+				s.Require().Len(entries, 1)
+				assert.Equal(s.T(), WorkDirMarker, entries[0].Name())
+			},
+		},
+		{
+			Name:          "err dir not created by ax is left untouched",
+			PreRequisites: prepare,
+			Assert: func() {
+				write(filepath.Join(parent, "important"), "data")
+
+				_, err := OpenWorkDir(parent)
+				assert.ErrorIs(s.T(), err, ErrNotWorkDir)
+				assert.ErrorIs(s.T(), CheckWorkDir(parent), ErrNotWorkDir)
+				assert.ErrorIs(s.T(), CheckWorkDir(filepath.Join(parent, "important")), ErrNotWorkDir)
+				assert.Nil(s.T(), CheckWorkDir(filepath.Join(parent, "missing")))
+
+				entries, err := os.ReadDir(parent)
+				s.Require().Nil(err)
+				assert.Len(s.T(), entries, 1)
+			},
+		},
+		{
+			Name:          "err work dir is locked by another run",
+			PreRequisites: prepare,
+			Assert: func() {
+				wd, err := CreateWorkDir(parent)
+				s.Require().Nil(err)
+
+				_, err = OpenWorkDir(wd.Path)
+				assert.ErrorIs(s.T(), err, ErrWorkDirLocked)
+
+				s.Require().Nil(wd.Close())
+
+				again, err := OpenWorkDir(wd.Path)
+				s.Require().Nil(err)
+				s.Require().Nil(again.Remove())
+			},
+		},
+		{
+			Name:          "success work dir of the job is found by its next run",
+			PreRequisites: prepare,
+			Assert: func() {
+				key := strings.Repeat("ab", 32)
+
+				wd, err := OpenJobWorkDir(parent, key)
+				s.Require().Nil(err)
+				assert.Equal(s.T(), JobWorkDirPath(parent, key), wd.Path)
+				assert.Equal(s.T(), "ax-work-abababababababab", filepath.Base(wd.Path))
+
+				write(filepath.Join(wd.Path, "backup.7z.001.enc.1"), "ciphertext")
+				s.Require().Nil(wd.Close())
+
+				again, err := OpenJobWorkDir(parent, key)
+				s.Require().Nil(err)
+				assert.FileExists(s.T(), filepath.Join(again.Path, "backup.7z.001.enc.1"))
+
+				_, err = OpenJobWorkDir(parent, key)
+				assert.ErrorIs(s.T(), err, ErrWorkDirLocked)
+
+				s.Require().Nil(again.Remove())
+			},
+		},
+		{
+			Name:          "err work dir of the job within a dir which isn't the user's own",
+			PreRequisites: prepare,
+			Assert: func() {
+				key := strings.Repeat("ab", 32)
+				other := filepath.Join(parent, "other")
+
+				s.Require().Nil(os.Mkdir(other, 0o700))
+				s.Require().Nil(os.Symlink(other, filepath.Dir(JobWorkDirPath(parent, key))))
+
+				_, err := OpenJobWorkDir(parent, key)
+				assert.ErrorIs(s.T(), err, ErrNotWorkDir)
+				assert.NoDirExists(s.T(), JobWorkDirPath(parent, key))
+			},
+		},
+		{
+			Name:          "success plaintext is overwritten before removal",
+			PreRequisites: prepare,
+			Assert: func() {
+				p := filepath.Join(parent, "backup.7z.001")
+				write(p, "plaintext")
+
+				s.Require().Nil(wipeFile(p, int64(len("plaintext"))))
+
+				content, err := os.ReadFile(p)
+				s.Require().Nil(err)
+				assert.Equal(s.T(), bytes.Repeat([]byte{0}, len("plaintext")), content)
+
+				s.Require().Nil(removeWiped(p))
+				assert.NoFileExists(s.T(), p)
+			},
+		},
+	}
+
+	RunTestCases(s, testCases)
+}
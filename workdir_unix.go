@@ -0,0 +1,59 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package ax
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockWorkDir - locks the marker of the work directory with flock, which is released by the returned func, or by the
+// kernel if ax dies, so that stale locks are never left behind.
+func lockWorkDir(marker string) (func() error, error) {
+	f, err := os.Open(marker)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening work directory marker: %w", err)
+	}
+
+	err = unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if err != nil {
+		_ = f.Close()
+
+		if errors.Is(err, unix.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%w: %s", ErrWorkDirLocked, marker)
+		}
+
+		return nil, fmt.Errorf("failed locking work directory: %w", err)
+	}
+
+	return func() error {
+		err := f.Close()
+		if err != nil {
+			return fmt.Errorf("failed unlocking work directory: %w", err)
+		}
+
+		return nil
+	}, nil
+}
+
+// checkPrivateDir - returns ErrNotWorkDir unless the path is a directory, rather than a symlink, owned by the current
+// user and accessible by no one else.
+func checkPrivateDir(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("failed getting stat of work directory: %w", err)
+	}
+
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !info.IsDir() || info.Mode().Perm()&^workDirPerm != 0 || !ok || int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%w: %s has to be a directory of the current user, accessible by no one else",
+			ErrNotWorkDir, path)
+	}
+
+	return nil
+}